@@ -0,0 +1,26 @@
+package unused
+
+import "context"
+
+// Address represents an unused reserved IP address on a given cloud
+// provider.
+type Address interface {
+	Resource
+
+	// Address returns the IP address.
+	Address() string
+}
+
+// Addresses is a collection of Address.
+type Addresses []Address
+
+// AddressProvider is implemented by providers that can list and
+// delete unused IP addresses.
+type AddressProvider interface {
+	// ListUnusedAddresses returns a list of reserved IP addresses that
+	// aren't associated to any resource.
+	ListUnusedAddresses(ctx context.Context) (Addresses, error)
+
+	// DeleteAddress releases the given IP address.
+	DeleteAddress(ctx context.Context, addr Address) error
+}
@@ -0,0 +1,134 @@
+// Package audit records deletions of unused disks as structured
+// events.
+//
+// Events are recorded by a [Logger] to one or more sinks: a local
+// JSONL file, a generic HTTP webhook, or a Loki push endpoint.
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/unused"
+)
+
+// Result is the outcome of a deletion.
+type Result string
+
+const (
+	// Deleted means the disk was deleted.
+	Deleted Result = "deleted"
+
+	// Failed means deleting the disk failed.
+	Failed Result = "failed"
+
+	// DryRun means the disk was not deleted because of dry-run mode.
+	DryRun Result = "dry-run"
+)
+
+// Event is an audit record of a disk deletion.
+type Event struct {
+	Time       time.Time       `json:"time"`
+	Operator   string          `json:"operator"`
+	Source     string          `json:"source,omitempty"`
+	Provider   string          `json:"provider"`
+	ProviderID string          `json:"provider_id"`
+	DiskID     string          `json:"disk_id"`
+	DiskName   string          `json:"disk_name"`
+	SizeGB     int             `json:"size_gb"`
+	DiskType   unused.DiskType `json:"disk_type"`
+	Meta       unused.Meta     `json:"meta,omitempty"`
+	DryRun     bool            `json:"dry_run"`
+	Result     Result          `json:"result"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// Sink stores audit events.
+type Sink interface {
+	Record(ctx context.Context, e Event) error
+}
+
+// Logger deletes disks recording an audit event for each deletion.
+// A nil Logger deletes disks without recording events.
+type Logger struct {
+	// Operator is who is deleting disks.
+	Operator string
+
+	// Source describes how disks are being deleted, like interactive
+	// or apply.
+	Source string
+
+	Sinks []Sink
+
+	// OnError is called when recording the event of a deletion fails.
+	// Such errors don't change the deletion result.
+	OnError func(Event, error)
+}
+
+// NewLogger returns a logger recording events to the given sinks.
+func NewLogger(operator, source string, sinks ...Sink) *Logger {
+	return &Logger{Operator: operator, Source: source, Sinks: sinks}
+}
+
+// Delete deletes the given disk with the provider, unless dryRun is
+// true, and records the result to all sinks.
+//
+// Only the deletion error is returned, so that a disk that was deleted
+// isn't reported as failed nor deleted again; errors recording the
+// event are reported to OnError.
+func (l *Logger) Delete(ctx context.Context, p unused.Provider, d unused.Disk, dryRun bool) error {
+	var err error
+	if !dryRun {
+		err = p.Delete(ctx, d)
+	}
+
+	if l == nil {
+		return err
+	}
+
+	e := Event{
+		Time:       time.Now().UTC(),
+		Operator:   l.Operator,
+		Source:     l.Source,
+		Provider:   p.Name(),
+		ProviderID: p.ID(),
+		DiskID:     d.ID(),
+		DiskName:   d.Name(),
+		SizeGB:     d.SizeGB(),
+		DiskType:   d.DiskType(),
+		Meta:       d.Meta(),
+		DryRun:     dryRun,
+		Result:     Deleted,
+	}
+
+	switch {
+	case dryRun:
+		e.Result = DryRun
+	case err != nil:
+		e.Result, e.Error = Failed, err.Error()
+	}
+
+	if rerr := l.Record(ctx, e); rerr != nil && l.OnError != nil {
+		l.OnError(e, rerr)
+	}
+
+	return err
+}
+
+// Record records the given event to all sinks.
+func (l *Logger) Record(ctx context.Context, e Event) error {
+	var errs []error
+	for _, s := range l.Sinks {
+		if err := s.Record(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("recording audit event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,288 @@
+package audit_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/audit"
+	"github.com/grafana/unused/unusedtest"
+)
+
+type memorySink struct {
+	events []audit.Event
+}
+
+func (s *memorySink) Record(_ context.Context, e audit.Event) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+type failingSink struct{}
+
+var errSink = errors.New("sink failed")
+
+func (failingSink) Record(context.Context, audit.Event) error { return errSink }
+
+func TestLoggerDelete(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	newProvider := func() (*unusedtest.Provider, unused.Disk) {
+		d := unusedtest.NewDisk("disk-1", nil, now, now)
+		d.SetMeta(unused.Meta{"zone": "us-central1-a"})
+		d.SetSize(10)
+		d.SetDiskType(unused.SSD)
+		return unusedtest.NewProvider("GCP", nil, d), d
+	}
+
+	t.Run("deleted", func(t *testing.T) {
+		p, d := newProvider()
+		s := &memorySink{}
+		l := audit.NewLogger("jane", "interactive", s)
+
+		if err := l.Delete(ctx, p, d, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(s.events) != 1 {
+			t.Fatalf("expecting 1 event, got %d", len(s.events))
+		}
+
+		e := s.events[0]
+		if e.Operator != "jane" || e.Source != "interactive" || e.Provider != "GCP" || e.ProviderID != "my-id" {
+			t.Errorf("unexpected event origin %+v", e)
+		}
+		if e.DiskID != "disk-1" || e.DiskName != "disk-1" || e.SizeGB != 10 || e.DiskType != unused.SSD || e.Meta["zone"] != "us-central1-a" {
+			t.Errorf("unexpected event disk %+v", e)
+		}
+		if e.DryRun || e.Result != audit.Deleted || e.Error != "" {
+			t.Errorf("unexpected event result %+v", e)
+		}
+
+		if disks, _ := p.ListUnusedDisks(ctx); len(disks) != 0 {
+			t.Errorf("expecting disk to be deleted, got %v", disks)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		p, d := newProvider()
+		s := &memorySink{}
+
+		if err := audit.NewLogger("jane", "apply", s).Delete(ctx, p, d, true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if e := s.events[0]; !e.DryRun || e.Result != audit.DryRun {
+			t.Errorf("unexpected event result %+v", e)
+		}
+		if disks, _ := p.ListUnusedDisks(ctx); len(disks) != 1 {
+			t.Errorf("expecting disk to not be deleted, got %v", disks)
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		p := unusedtest.NewProvider("GCP", nil)
+		d := unusedtest.NewDisk("missing", p, now, now)
+		s := &memorySink{}
+
+		err := audit.NewLogger("jane", "apply", s).Delete(ctx, p, d, false)
+		if !errors.Is(err, unusedtest.ErrDiskNotFound) {
+			t.Fatalf("expecting error %v, got %v", unusedtest.ErrDiskNotFound, err)
+		}
+		if e := s.events[0]; e.Result != audit.Failed || e.Error != unusedtest.ErrDiskNotFound.Error() {
+			t.Errorf("unexpected event result %+v", e)
+		}
+	})
+
+	t.Run("sink error", func(t *testing.T) {
+		p, d := newProvider()
+		s := &memorySink{}
+
+		var reported error
+		l := audit.NewLogger("jane", "apply", failingSink{}, s)
+		l.OnError = func(_ audit.Event, err error) { reported = err }
+
+		if err := l.Delete(ctx, p, d, false); err != nil {
+			t.Fatalf("expecting sink errors not to fail the deletion, got %v", err)
+		}
+		if !errors.Is(reported, errSink) {
+			t.Fatalf("expecting reported error %v, got %v", errSink, reported)
+		}
+		if disks, _ := p.ListUnusedDisks(ctx); len(disks) != 0 {
+			t.Errorf("expecting disk to be deleted, got %v", disks)
+		}
+		if len(s.events) != 1 {
+			t.Errorf("expecting other sinks to record the event, got %d events", len(s.events))
+		}
+	})
+
+	t.Run("nil logger", func(t *testing.T) {
+		p, d := newProvider()
+
+		var l *audit.Logger
+		if err := l.Delete(ctx, p, d, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if disks, _ := p.ListUnusedDisks(ctx); len(disks) != 0 {
+			t.Errorf("expecting disk to be deleted, got %v", disks)
+		}
+	})
+}
+
+var event = audit.Event{
+	Time:       time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	Operator:   "jane",
+	Provider:   "AWS",
+	ProviderID: "my-id",
+	DiskID:     "vol-123",
+	DiskName:   "data",
+	SizeGB:     100,
+	DiskType:   unused.HDD,
+	Meta:       unused.Meta{"team": "foo"},
+	Result:     audit.Deleted,
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	s := audit.NewFileSink(path)
+
+	for range 2 {
+		if err := s.Record(context.Background(), event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening audit file: %v", err)
+	}
+	defer f.Close() // nolint:errcheck
+
+	var n int
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e audit.Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("decoding line %d: %v", n, err)
+		}
+		if e.DiskID != event.DiskID || !e.Time.Equal(event.Time) || e.Meta["team"] != "foo" {
+			t.Errorf("unexpected event %+v", e)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("expecting 2 lines, got %d", n)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got []audit.Event
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var e audit.Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		got = append(got, e)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	if err := audit.NewWebhookSink(ts.URL, ts.Client()).Record(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 1 || got[0].DiskID != event.DiskID {
+		t.Errorf("unexpected events %+v", got)
+	}
+
+	t.Run("error status", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "nope", http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		if err := audit.NewWebhookSink(ts.URL, ts.Client()).Record(context.Background(), event); err == nil {
+			t.Fatal("expecting error, got nil")
+		}
+	})
+}
+
+func TestLokiSink(t *testing.T) {
+	var (
+		tenant string
+		body   []byte
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/push" {
+			http.NotFound(w, r)
+			return
+		}
+		tenant = r.Header.Get("X-Scope-OrgID")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	s := audit.NewLokiSink(ts.URL+"/loki/api/v1/push", "ops", ts.Client())
+	if err := s.Record(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tenant != "ops" {
+		t.Errorf("expecting tenant ops, got %q", tenant)
+	}
+
+	var push struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(body, &push); err != nil {
+		t.Fatalf("decoding push request: %v", err)
+	}
+
+	if len(push.Streams) != 1 || len(push.Streams[0].Values) != 1 {
+		t.Fatalf("unexpected push request %s", body)
+	}
+
+	st := push.Streams[0]
+	if st.Stream["job"] != "unused" || st.Stream["provider"] != "aws" || st.Stream["result"] != "deleted" {
+		t.Errorf("unexpected labels %v", st.Stream)
+	}
+
+	if ts := st.Values[0][0]; ts != strconv.FormatInt(event.Time.UnixNano(), 10) {
+		t.Errorf("unexpected timestamp %s", ts)
+	}
+
+	var e audit.Event
+	if err := json.Unmarshal([]byte(st.Values[0][1]), &e); err != nil {
+		t.Fatalf("decoding log line: %v", err)
+	}
+	if e.DiskID != event.DiskID {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
@@ -0,0 +1,160 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	_ Sink = &FileSink{}
+	_ Sink = &WebhookSink{}
+	_ Sink = &LokiSink{}
+)
+
+// FileSink appends events as JSON lines to a local file.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileSink returns a sink appending events to the file in the
+// given path, creating it if needed.
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) Record(_ context.Context, e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening audit file: %w", err)
+	}
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close() // nolint:errcheck
+		return fmt.Errorf("writing audit file: %w", err)
+	}
+
+	return f.Close()
+}
+
+// WebhookSink posts each event as JSON to an HTTP endpoint.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink returns a sink posting events to the given URL. A nil
+// client uses [http.DefaultClient].
+func NewWebhookSink(url string, client *http.Client) *WebhookSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookSink{url: url, client: client}
+}
+
+func (s *WebhookSink) Record(ctx context.Context, e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	return post(ctx, s.client, s.url, b, nil)
+}
+
+// LokiSink pushes each event as a JSON log line to a Loki push
+// endpoint, like http://localhost:3100/loki/api/v1/push.
+//
+// Log lines are labeled with job="unused", and the lowercased provider
+// name and result of the event.
+type LokiSink struct {
+	url    string
+	tenant string
+	client *http.Client
+}
+
+// NewLokiSink returns a sink pushing events to the given Loki push
+// URL. If tenant is not empty, it's sent in the X-Scope-OrgID header.
+// A nil client uses [http.DefaultClient].
+func NewLokiSink(url, tenant string, client *http.Client) *LokiSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &LokiSink{url: url, tenant: tenant, client: client}
+}
+
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (s *LokiSink) Record(ctx context.Context, e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	b, err := json.Marshal(lokiPush{
+		Streams: []lokiStream{{
+			Stream: map[string]string{
+				"job":      "unused",
+				"provider": strings.ToLower(e.Provider),
+				"result":   string(e.Result),
+			},
+			Values: [][2]string{{strconv.FormatInt(e.Time.UnixNano(), 10), string(line)}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("encoding Loki push request: %w", err)
+	}
+
+	var hdr http.Header
+	if s.tenant != "" {
+		hdr = http.Header{"X-Scope-OrgID": []string{s.tenant}}
+	}
+
+	return post(ctx, s.client, s.url, b, hdr)
+}
+
+func post(ctx context.Context, client *http.Client, url string, body []byte, hdr http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	for k, vs := range hdr {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting event to %s: %w", url, err)
+	}
+	defer res.Body.Close() // nolint:errcheck
+
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("posting event to %s: unexpected status %s: %s", url, res.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
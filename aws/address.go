@@ -0,0 +1,59 @@
+package aws
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/grafana/unused"
+)
+
+var _ unused.Address = &Address{}
+
+// Address holds information about an AWS Elastic IP address.
+type Address struct {
+	ElasticIP types.Address
+	provider  *Provider
+	meta      unused.Meta
+}
+
+// ID returns the allocation ID of this AWS Elastic IP address, or its
+// public IP if it doesn't have one.
+func (a *Address) ID() string {
+	if a.ElasticIP.AllocationId != nil {
+		return *a.ElasticIP.AllocationId
+	}
+	return a.Address()
+}
+
+// Provider returns a reference to the provider used to instantiate
+// this address.
+func (a *Address) Provider() unused.Provider { return a.provider }
+
+// Name returns the name of this AWS Elastic IP address as stored in
+// its Name tag, or its public IP if it doesn't have one.
+func (a *Address) Name() string {
+	for _, t := range a.ElasticIP.Tags {
+		if *t.Key == "Name" {
+			return *t.Value
+		}
+	}
+	return a.Address()
+}
+
+// Address returns the public IP of this AWS Elastic IP address.
+func (a *Address) Address() string {
+	if a.ElasticIP.PublicIp == nil {
+		return ""
+	}
+	return *a.ElasticIP.PublicIp
+}
+
+// CreatedAt returns a zero [time.Time] value, as AWS does not provide
+// this information.
+func (a *Address) CreatedAt() time.Time { return time.Time{} }
+
+// Meta returns the address metadata.
+func (a *Address) Meta() unused.Meta { return a.meta }
+
+// Kind returns [unused.KindAddress].
+func (a *Address) Kind() unused.ResourceKind { return unused.KindAddress }
@@ -0,0 +1,220 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	cttypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/grafana/unused"
+)
+
+// LastAttachedInstanceMetaKey is the metadata key holding the ID of
+// the instance a volume was last detached from, when CloudTrail
+// enrichment is enabled. AWS reserves the aws: prefix, so it never
+// collides with tags.
+const LastAttachedInstanceMetaKey = "aws:last-attached-instance"
+
+// cloudTrailLookback is how far back CloudTrail events are looked up
+// the first time, which is how long CloudTrail keeps management
+// events.
+const cloudTrailLookback = 90 * 24 * time.Hour
+
+// cloudTrailDelay is how late CloudTrail events can be delivered;
+// lookups overlap the previous one by this much so that they don't
+// miss late events.
+const cloudTrailDelay = 15 * time.Minute
+
+// WithCloudTrail enables looking up when unused volumes were last
+// detached using the CloudTrail LookupEvents API.
+//
+// CloudTrail is regional, so events are looked up in the region of
+// each volume with the client returned by newClient for that region.
+// All DetachVolume events of a region are looked up with a single
+// paginated query instead of one per volume, and they are cached in the
+// provider so that later listings only look up events since the
+// previous lookup, overlapping it to catch events delivered late.
+func WithCloudTrail(newClient func(region string) cloudtrail.LookupEventsAPIClient) Option {
+	return func(p *Provider) { p.cloudTrail = newClient }
+}
+
+// addDetaches sets the last detach time and instance of the given
+// disks of a region from CloudTrail events, when enabled.
+func (p *Provider) addDetaches(ctx context.Context, region string, disks unused.Disks) {
+	if p.cloudTrail == nil || len(disks) == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	c, ok := p.detaches[region]
+	if !ok {
+		if p.detaches == nil {
+			p.detaches = make(map[string]*detachCache)
+		}
+		c = &detachCache{client: p.cloudTrail(region)}
+		p.detaches[region] = c
+	}
+	p.mu.Unlock()
+
+	ids := make([]string, len(disks))
+	for i, d := range disks {
+		ids[i] = d.ID()
+	}
+
+	detaches, err := c.lookup(ctx, time.Now(), ids)
+	if err != nil {
+		// the disks are still unused, they just lack this information
+		p.logger.Warn("cannot enrich disks with CloudTrail events", slog.String("region", region), slog.String("error", err.Error()))
+	}
+
+	for _, d := range disks {
+		d := d.(*Disk)
+		if dt, ok := detaches[d.ID()]; ok {
+			d.lastUsed = dt.at
+			if dt.instance != "" {
+				d.meta[LastAttachedInstanceMetaKey] = dt.instance
+			}
+		}
+	}
+}
+
+// detach is the most recent DetachVolume event of a volume.
+type detach struct {
+	at       time.Time
+	instance string
+}
+
+type detachCache struct {
+	client cloudtrail.LookupEventsAPIClient
+
+	mu         sync.Mutex
+	lastLookup time.Time
+	volumes    map[string]detach
+
+	// seen holds the time of the events already read in the window
+	// overlapping the next lookup, by event ID
+	seen map[string]time.Time
+}
+
+// lookup returns the most recent detach event of each of the given
+// volumes, looking up the events since the previous lookup. Only the
+// events of these volumes are kept for later lookups.
+func (c *detachCache) lookup(ctx context.Context, now time.Time, ids []string) (map[string]detach, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	start := c.lastLookup.Add(-cloudTrailDelay)
+	if c.lastLookup.IsZero() {
+		start = now.Add(-cloudTrailLookback)
+	}
+
+	pager := cloudtrail.NewLookupEventsPaginator(c.client, &cloudtrail.LookupEventsInput{
+		LookupAttributes: []cttypes.LookupAttribute{{
+			AttributeKey:   cttypes.LookupAttributeKeyEventName,
+			AttributeValue: aws.String("DetachVolume"),
+		}},
+		StartTime: aws.Time(start),
+		EndTime:   aws.Time(now),
+	})
+
+	listed := make(map[string]bool, len(ids))
+	volumes := make(map[string]detach, len(ids))
+	for _, id := range ids {
+		listed[id] = true
+		if d, ok := c.volumes[id]; ok {
+			volumes[id] = d
+		}
+	}
+
+	seen := make(map[string]time.Time, len(c.seen))
+	for id, at := range c.seen {
+		if !at.Before(now.Add(-cloudTrailDelay)) {
+			seen[id] = at
+		}
+	}
+
+	for pager.HasMorePages() {
+		res, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("looking up CloudTrail DetachVolume events: %w", err)
+		}
+
+		for _, e := range res.Events {
+			id := aws.ToString(e.EventId)
+			if _, ok := c.seen[id]; ok && id != "" {
+				continue
+			}
+
+			volume, d := parseDetachEvent(e)
+			if volume == "" || d.at.IsZero() {
+				continue
+			}
+			if id != "" && !d.at.Before(now.Add(-cloudTrailDelay)) {
+				seen[id] = d.at
+			}
+			if !listed[volume] {
+				continue
+			}
+			if prev, ok := volumes[volume]; !ok || d.at.After(prev.at) {
+				volumes[volume] = d
+			}
+		}
+	}
+
+	// only update the cache once all pages were read, so that a
+	// failed lookup is retried from the same start time
+	c.volumes, c.seen = volumes, seen
+	c.lastLookup = now
+
+	return volumes, nil
+}
+
+// parseDetachEvent returns the volume ID and detach information of a
+// DetachVolume event, using the event resources and falling back to
+// the request parameters in the raw event.
+func parseDetachEvent(e cttypes.Event) (string, detach) {
+	var (
+		volume string
+		d      detach
+	)
+
+	if e.EventTime != nil {
+		d.at = *e.EventTime
+	}
+
+	for _, r := range e.Resources {
+		if r.ResourceType == nil || r.ResourceName == nil {
+			continue
+		}
+		switch *r.ResourceType {
+		case "AWS::EC2::Volume":
+			volume = *r.ResourceName
+		case "AWS::EC2::Instance":
+			d.instance = *r.ResourceName
+		}
+	}
+
+	if (volume == "" || d.instance == "") && e.CloudTrailEvent != nil {
+		var raw struct {
+			RequestParameters struct {
+				VolumeID   string `json:"volumeId"`
+				InstanceID string `json:"instanceId"`
+			} `json:"requestParameters"`
+		}
+		if err := json.Unmarshal([]byte(*e.CloudTrailEvent), &raw); err == nil {
+			if volume == "" {
+				volume = raw.RequestParameters.VolumeID
+			}
+			if d.instance == "" {
+				d.instance = raw.RequestParameters.InstanceID
+			}
+		}
+	}
+
+	return volume, d
+}
@@ -1,8 +1,10 @@
 package aws
 
 import (
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/grafana/unused"
 )
@@ -14,6 +16,7 @@ type Disk struct {
 	types.Volume
 	provider *Provider
 	meta     unused.Meta
+	lastUsed time.Time
 }
 
 // ID returns the volume ID of this AWS EC2 volume.
@@ -43,24 +46,75 @@ func (d *Disk) CreatedAt() time.Time { return *d.CreateTime }
 // Meta returns the disk metadata.
 func (d *Disk) Meta() unused.Meta { return d.meta }
 
+// Kind returns [unused.KindDisk].
+func (d *Disk) Kind() unused.ResourceKind { return unused.KindDisk }
+
 // SizeGB returns the size of this AWS EC2 volume in GiB.
 func (d *Disk) SizeGB() int { return int(*d.Size) }
 
 // SizeBytes returns the size of this AWS EC2 volume in bytes.
 func (d *Disk) SizeBytes() float64 { return float64(*d.Size) * unused.GiBbytes }
 
-// LastUsedAt returns a zero [time.Time] value, as AWS does not
-// provide this information.
-func (d *Disk) LastUsedAt() time.Time { return time.Time{} }
+// LastUsedAt returns when the volume was last detached according to
+// CloudTrail, or a zero [time.Time] value if unknown, as AWS does not
+// provide this information directly. See [WithCloudTrail].
+func (d *Disk) LastUsedAt() time.Time { return d.lastUsed }
+
+// DiskType returns the normalized type of this AWS EC2 volume.
+func (d *Disk) DiskType() unused.DiskType { return d.Class().Media }
+
+// Class returns the type of this AWS EC2 volume and its provisioned
+// IOPS and throughput. AWS reports the baseline IOPS of gp2 volumes
+// as well.
+func (d *Disk) Class() unused.DiskClass {
+	c := unused.DiskClass{
+		Type:       string(d.VolumeType),
+		IOPS:       int64(aws.ToInt32(d.Iops)),
+		Throughput: int64(aws.ToInt32(d.Throughput)),
+	}
 
-// DiskType Type returns the type of this AWS EC2 volume.
-func (d *Disk) DiskType() unused.DiskType {
 	switch d.VolumeType {
 	case types.VolumeTypeGp2, types.VolumeTypeGp3, types.VolumeTypeIo1, types.VolumeTypeIo2:
-		return unused.SSD
+		c.Media = unused.SSD
 	case types.VolumeTypeSt1, types.VolumeTypeSc1, types.VolumeTypeStandard:
-		return unused.HDD
+		c.Media = unused.HDD
 	default:
-		return unused.Unknown
+		c.Media = unused.Unknown
+	}
+
+	return c
+}
+
+// Location returns the availability zone and region of this AWS EC2
+// volume. The region falls back to the zone without its letter suffix
+// if it wasn't recorded when listing the volume.
+func (d *Disk) Location() unused.Location {
+	zone := aws.ToString(d.AvailabilityZone)
+
+	region := d.meta[RegionMetaKey]
+	if region == "" {
+		region = strings.TrimRight(zone, "abcdefghijklmnopqrstuvwxyz")
 	}
+
+	return unused.Location{Region: region, Zone: zone}
+}
+
+// Kubernetes returns the Kubernetes objects this AWS EC2 volume was
+// created for, as found in its tags, including the cluster from the
+// kubernetes.io/cluster/<name> or KubernetesCluster tags.
+func (d *Disk) Kubernetes() unused.Kubernetes {
+	k := d.meta.Kubernetes()
+
+	for _, t := range d.Tags {
+		key := aws.ToString(t.Key)
+		if name, ok := strings.CutPrefix(key, "kubernetes.io/cluster/"); ok {
+			k.Cluster = name
+			break
+		}
+		if key == "KubernetesCluster" {
+			k.Cluster = aws.ToString(t.Value)
+		}
+	}
+
+	return k
 }
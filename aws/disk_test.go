@@ -29,6 +29,7 @@ func TestDisk(t *testing.T) {
 				},
 				nil,
 				nil,
+				time.Time{},
 			}
 
 			if exp, got := "my-disk-id", d.ID(); exp != got {
@@ -57,3 +58,73 @@ func TestDisk(t *testing.T) {
 		})
 	}
 }
+
+func TestDiskLocation(t *testing.T) {
+	v := types.Volume{AvailabilityZone: aws.String("us-west-2a")}
+
+	t.Run("from zone", func(t *testing.T) {
+		d := &Disk{v, nil, nil, time.Time{}}
+		if exp, got := (unused.Location{Region: "us-west-2", Zone: "us-west-2a"}), d.Location(); exp != got {
+			t.Errorf("expecting Location() %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("from meta", func(t *testing.T) {
+		v := types.Volume{AvailabilityZone: aws.String("us-west-2-lax-1a")}
+		d := &Disk{v, nil, unused.Meta{RegionMetaKey: "us-west-2"}, time.Time{}}
+		if exp, got := (unused.Location{Region: "us-west-2", Zone: "us-west-2-lax-1a"}), d.Location(); exp != got {
+			t.Errorf("expecting Location() %v, got %v", exp, got)
+		}
+	})
+}
+
+func TestDiskKubernetes(t *testing.T) {
+	meta := unused.Meta{
+		"kubernetes.io/created-for/pvc/namespace": "ns",
+		"kubernetes.io/created-for/pvc/name":      "pvc",
+		"kubernetes.io/created-for/pv/name":       "pv",
+	}
+
+	tests := map[string]struct {
+		tag, value string
+	}{
+		"cluster tag":       {"kubernetes.io/cluster/dev", "owned"},
+		"KubernetesCluster": {"KubernetesCluster", "dev"},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			v := types.Volume{Tags: []types.Tag{{Key: aws.String(tt.tag), Value: aws.String(tt.value)}}}
+			d := &Disk{v, nil, meta, time.Time{}}
+			if exp, got := (unused.Kubernetes{Cluster: "dev", Namespace: "ns", PVC: "pvc", PV: "pv"}), d.Kubernetes(); exp != got {
+				t.Errorf("expecting Kubernetes() %v, got %v", exp, got)
+			}
+		})
+	}
+}
+
+func TestDiskClass(t *testing.T) {
+	tests := map[types.VolumeType]unused.DiskClass{
+		types.VolumeTypeGp3: {Type: "gp3", Media: unused.SSD, IOPS: 3000, Throughput: 125},
+		types.VolumeTypeIo2: {Type: "io2", Media: unused.SSD, IOPS: 64000},
+		types.VolumeTypeSc1: {Type: "sc1", Media: unused.HDD},
+		"foo":               {Type: "foo", Media: unused.Unknown},
+	}
+
+	for typ, exp := range tests {
+		t.Run(string(typ), func(t *testing.T) {
+			v := types.Volume{VolumeType: typ}
+			if exp.IOPS > 0 {
+				v.Iops = aws.Int32(int32(exp.IOPS))
+			}
+			if exp.Throughput > 0 {
+				v.Throughput = aws.Int32(int32(exp.Throughput))
+			}
+
+			d := &Disk{v, nil, nil, time.Time{}}
+			if got := d.Class(); got != exp {
+				t.Errorf("expecting Class() %v, got %v", exp, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/grafana/unused"
+)
+
+// Metadata keys of providers created for the accounts of an
+// organization.
+const (
+	AccountIDMetaKey   = "account-id"
+	AccountNameMetaKey = "account-name"
+)
+
+// DefaultOrganizationRole is the role created by AWS Organizations in
+// the accounts it creates.
+const DefaultOrganizationRole = "OrganizationAccountAccessRole"
+
+// Account is an active account of an AWS organization.
+type Account struct {
+	ID   string
+	Name string
+
+	// Partition is the AWS partition of the account, like aws or
+	// aws-cn.
+	Partition string
+
+	// Management is true for the management account of the
+	// organization, which doesn't have the role assumed in member
+	// accounts and is accessed with its own credentials instead.
+	Management bool
+}
+
+// Meta returns the provider metadata identifying the account.
+func (a Account) Meta() unused.Meta {
+	return unused.Meta{AccountIDMetaKey: a.ID, AccountNameMetaKey: a.Name}
+}
+
+// OrganizationsAPIClient is the AWS Organizations client needed to
+// list the accounts of an organization.
+type OrganizationsAPIClient interface {
+	organizations.ListAccountsAPIClient
+	DescribeOrganization(context.Context, *organizations.DescribeOrganizationInput, ...func(*organizations.Options)) (*organizations.DescribeOrganizationOutput, error)
+}
+
+// ListAccounts returns the active accounts of the organization for
+// which match returns true, using a client of its management account.
+// A nil match returns all of them.
+func ListAccounts(ctx context.Context, client OrganizationsAPIClient, match func(Account) bool) ([]Account, error) {
+	org, err := client.DescribeOrganization(ctx, &organizations.DescribeOrganizationInput{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe AWS organization: %w", err)
+	}
+
+	var (
+		accounts   []Account
+		management string
+	)
+	if org.Organization != nil {
+		management = aws.ToString(org.Organization.MasterAccountId)
+	}
+
+	pager := organizations.NewListAccountsPaginator(client, &organizations.ListAccountsInput{})
+	for pager.HasMorePages() {
+		res, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list AWS accounts: %w", err)
+		}
+
+		for _, a := range res.Accounts {
+			if a.State != orgtypes.AccountStateActive {
+				continue
+			}
+
+			acc := Account{ID: aws.ToString(a.Id), Name: aws.ToString(a.Name), Partition: "aws"}
+			acc.Management = acc.ID == management
+			// arn:aws:organizations::111111111111:account/o-exampleorgid/222222222222
+			if parts := strings.SplitN(aws.ToString(a.Arn), ":", 3); len(parts) == 3 {
+				acc.Partition = parts[1]
+			}
+
+			if match == nil || match(acc) {
+				accounts = append(accounts, acc)
+			}
+		}
+	}
+
+	return accounts, nil
+}
+
+// AccountMatcher returns a function matching the accounts whose ID or
+// name match any of the include patterns, or all of them when there
+// are none, and none of the exclude patterns. Patterns use the
+// [path.Match] syntax, like prod-* or 1234*.
+func AccountMatcher(include, exclude []string) (func(Account) bool, error) {
+	for _, p := range append(include, exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid account pattern %q: %w", p, err)
+		}
+	}
+
+	matches := func(patterns []string, a Account) bool {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, a.ID); ok {
+				return true
+			}
+			if ok, _ := path.Match(p, a.Name); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(a Account) bool {
+		if len(include) > 0 && !matches(include, a) {
+			return false
+		}
+		return !matches(exclude, a)
+	}, nil
+}
+
+// AssumeRoleConfig returns a copy of cfg whose credentials assume the
+// role with the given name in the account, using the credentials of
+// cfg.
+func AssumeRoleConfig(cfg aws.Config, a Account, role string) aws.Config {
+	arn := fmt.Sprintf("arn:%s:iam::%s:role/%s", a.Partition, a.ID, role)
+
+	c := cfg.Copy()
+	c.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), arn, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = "unused"
+	}))
+
+	return c
+}
@@ -0,0 +1,143 @@
+package aws_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+
+	awsutil "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/grafana/unused/aws"
+)
+
+type stubOrganizations struct {
+	pages      [][]orgtypes.Account
+	management string
+	err        error
+}
+
+func (s *stubOrganizations) DescribeOrganization(ctx context.Context, in *organizations.DescribeOrganizationInput, _ ...func(*organizations.Options)) (*organizations.DescribeOrganizationOutput, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &organizations.DescribeOrganizationOutput{
+		Organization: &orgtypes.Organization{MasterAccountId: awsutil.String(s.management)},
+	}, nil
+}
+
+func (s *stubOrganizations) ListAccounts(ctx context.Context, in *organizations.ListAccountsInput, _ ...func(*organizations.Options)) (*organizations.ListAccountsOutput, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var i int
+	if in.NextToken != nil {
+		i = int((*in.NextToken)[0] - '0')
+	}
+
+	out := &organizations.ListAccountsOutput{Accounts: s.pages[i]}
+	if i+1 < len(s.pages) {
+		out.NextToken = awsutil.String(string(rune('0' + i + 1)))
+	}
+	return out, nil
+}
+
+func account(id, name string, state orgtypes.AccountState) orgtypes.Account {
+	return orgtypes.Account{
+		Id:    awsutil.String(id),
+		Name:  awsutil.String(name),
+		Arn:   awsutil.String("arn:aws-cn:organizations::111111111111:account/o-example/" + id),
+		State: state,
+	}
+}
+
+func TestListAccounts(t *testing.T) {
+	ctx := context.Background()
+
+	client := &stubOrganizations{management: "111111111111", pages: [][]orgtypes.Account{
+		{
+			account("111111111111", "management", orgtypes.AccountStateActive),
+			account("222222222222", "prod-eu", orgtypes.AccountStateActive),
+		},
+		{
+			account("333333333333", "prod-us", orgtypes.AccountStateActive),
+			account("444444444444", "dev", orgtypes.AccountStateActive),
+			account("555555555555", "prod-old", orgtypes.AccountStateSuspended),
+		},
+	}}
+
+	match, err := aws.AccountMatcher([]string{"prod-*", "4444*"}, []string{"*-us"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	accounts, err := aws.ListAccounts(ctx, client, match)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []aws.Account{
+		{ID: "222222222222", Name: "prod-eu", Partition: "aws-cn"},
+		{ID: "444444444444", Name: "dev", Partition: "aws-cn"},
+	}
+	if !slices.Equal(accounts, exp) {
+		t.Errorf("expecting accounts %v, got %v", exp, accounts)
+	}
+
+	all, err := aws.ListAccounts(ctx, client, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 4 {
+		t.Errorf("expecting 4 active accounts, got %v", all)
+	}
+	for _, a := range all {
+		if exp := a.ID == "111111111111"; a.Management != exp {
+			t.Errorf("expecting account %s management %v, got %v", a.ID, exp, a.Management)
+		}
+	}
+
+	t.Run("error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		if _, err := aws.ListAccounts(ctx, &stubOrganizations{err: errBoom}, nil); !errors.Is(err, errBoom) {
+			t.Errorf("expecting error %v, got %v", errBoom, err)
+		}
+	})
+}
+
+func TestAccountMatcher(t *testing.T) {
+	if _, err := aws.AccountMatcher([]string{"[prod"}, nil); err == nil {
+		t.Error("expecting error for invalid pattern")
+	}
+
+	match, err := aws.AccountMatcher(nil, []string{"sandbox-*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !match(aws.Account{ID: "1", Name: "prod"}) {
+		t.Error("expecting all accounts to match without include patterns")
+	}
+	if match(aws.Account{ID: "2", Name: "sandbox-1"}) {
+		t.Error("expecting excluded account not to match")
+	}
+}
+
+func TestAccountProvider(t *testing.T) {
+	a := aws.Account{ID: "222222222222", Name: "prod-eu", Partition: "aws"}
+
+	p, err := aws.NewProvider(slog.Default(), ec2.New(ec2.Options{}), a.Meta())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if exp, got := a.ID, p.ID(); exp != got {
+		t.Errorf("expecting ID() %q, got %q", exp, got)
+	}
+	if exp, got := a.Name, p.Meta()[aws.AccountNameMetaKey]; exp != got {
+		t.Errorf("expecting account name %q, got %q", exp, got)
+	}
+}
@@ -4,14 +4,27 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/grafana/unused"
 )
 
-var _ unused.Provider = &Provider{}
+var (
+	_ unused.Provider         = &Provider{}
+	_ unused.Snapshotter      = &Provider{}
+	_ unused.AddressProvider  = &Provider{}
+	_ unused.SnapshotProvider = &Provider{}
+	_ unused.Tagger           = &Provider{}
+)
 
 var ProviderName = "AWS"
 
@@ -20,6 +33,15 @@ type Provider struct {
 	client *ec2.Client
 	meta   unused.Meta
 	logger *slog.Logger
+
+	regions    []string
+	allRegions bool
+	restored   bool
+	cloudTrail func(region string) cloudtrail.LookupEventsAPIClient
+
+	mu       sync.Mutex
+	clients  map[string]*ec2.Client
+	detaches map[string]*detachCache
 }
 
 // Name returns AWS.
@@ -28,29 +50,56 @@ func (p *Provider) Name() string { return ProviderName }
 // Meta returns the provider metadata.
 func (p *Provider) Meta() unused.Meta { return p.meta }
 
-// ID returns the profile of this provider.
-func (p *Provider) ID() string { return p.meta["profile"] }
+// ID returns the profile of this provider or, for providers created
+// for the accounts of an organization, the account ID.
+func (p *Provider) ID() string {
+	if id := p.meta[AccountIDMetaKey]; id != "" {
+		return id
+	}
+	return p.meta["profile"]
+}
+
+// Option configures optional features of the AWS provider.
+type Option func(*Provider)
 
 // NewProvider creates a new AWS [unused.Provider].
 //
 // A valid EC2 client must be supplied in order to list the unused
 // resources. The metadata passed will be used to identify the
 // provider.
-func NewProvider(logger *slog.Logger, client *ec2.Client, meta unused.Meta) (*Provider, error) {
+func NewProvider(logger *slog.Logger, client *ec2.Client, meta unused.Meta, opts ...Option) (*Provider, error) {
 	if meta == nil {
 		meta = make(unused.Meta)
 	}
 
-	return &Provider{
+	p := &Provider{
 		client: client,
 		meta:   meta,
 		logger: logger,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p, nil
 }
 
 // ListUnusedDisks returns all the AWS EC2 volumes that are available,
-// ie. not used by any other resource.
+// ie. not used by any other resource. When configured with
+// [WithRegions] or [WithAllRegions], regions are listed concurrently.
 func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
+	return eachRegion(ctx, p, func(ctx context.Context, client *ec2.Client, region string) ([]unused.Disk, error) {
+		disks, err := p.listUnusedDisks(ctx, client, region)
+		if err != nil {
+			return nil, err
+		}
+		p.addDetaches(ctx, region, disks)
+		return disks, nil
+	})
+}
+
+// listUnusedDisks lists the unused disks in a region with its client.
+func (p *Provider) listUnusedDisks(ctx context.Context, client *ec2.Client, region string) (unused.Disks, error) {
 	params := &ec2.DescribeVolumesInput{
 		Filters: []types.Filter{
 			// only show available (i.e. not "in-use") volumes
@@ -58,17 +107,23 @@ func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
 				Name:   aws.String("status"),
 				Values: []string{string(types.VolumeStateAvailable)},
 			},
-			// exclude snapshots
-			{
-				Name:   aws.String("snapshot-id"),
-				Values: []string{""},
-			},
 		},
 	}
+	if !p.restored {
+		// exclude volumes restored from snapshots
+		params.Filters = append(params.Filters, types.Filter{
+			Name:   aws.String("snapshot-id"),
+			Values: []string{""},
+		})
+	}
 
-	pager := ec2.NewDescribeVolumesPaginator(p.client, params)
+	pager := ec2.NewDescribeVolumesPaginator(client, params)
 
-	var upds unused.Disks
+	var (
+		upds     unused.Disks
+		restored []*Disk
+		snaps    = make(map[string]bool)
+	)
 
 	for pager.HasMorePages() {
 		res, err := pager.NextPage(ctx)
@@ -80,6 +135,12 @@ func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
 			m := unused.Meta{
 				"zone": *v.AvailabilityZone,
 			}
+			if aws.ToBool(v.MultiAttachEnabled) {
+				m[unused.SharingMetaKey] = MultiAttach
+			}
+			if region != "" {
+				m[RegionMetaKey] = region
+			}
 			for _, t := range v.Tags {
 				k := *t.Key
 				if k == "Name" || k == "CSIVolumeName" {
@@ -89,16 +150,39 @@ func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
 				m[k] = *t.Value
 			}
 
-			upds = append(upds, &Disk{v, p, m})
+			d := &Disk{v, p, m, time.Time{}}
+			if id := aws.ToString(v.SnapshotId); id != "" {
+				m[SourceSnapshotMetaKey] = id
+				restored = append(restored, d)
+				snaps[id] = true
+			}
+
+			upds = append(upds, d)
+		}
+	}
+
+	if len(snaps) > 0 {
+		exists, err := existingSnapshots(ctx, client, slices.Sorted(maps.Keys(snaps)))
+		if err != nil {
+			// the disks are still unused, they just lack this information
+			p.logger.Warn("cannot look up source snapshots of restored disks", slog.String("error", err.Error()))
+			return upds, nil
+		}
+		for _, d := range restored {
+			d.meta[SourceSnapshotExistsMetaKey] = strconv.FormatBool(exists[d.meta[SourceSnapshotMetaKey]])
 		}
 	}
 
 	return upds, nil
 }
 
-// Delete deletes the given disk from AWS.
+// MultiAttach is the sharing mode of AWS io1 and io2 volumes with
+// Multi-Attach enabled, which can be attached to several instances.
+const MultiAttach = "multi-attach"
+
+// Delete deletes the given disk from AWS, in its region.
 func (p *Provider) Delete(ctx context.Context, disk unused.Disk) error {
-	_, err := p.client.DeleteVolume(ctx, &ec2.DeleteVolumeInput{
+	_, err := p.resourceClient(disk).DeleteVolume(ctx, &ec2.DeleteVolumeInput{
 		VolumeId: aws.String(disk.ID()),
 	})
 	if err != nil {
@@ -106,3 +190,211 @@ func (p *Provider) Delete(ctx context.Context, disk unused.Disk) error {
 	}
 	return nil
 }
+
+// TagDisk sets the given tags on the disk.
+func (p *Provider) TagDisk(ctx context.Context, disk unused.Disk, tags map[string]string) error {
+	ts := make([]types.Tag, 0, len(tags))
+	for k, v := range tags {
+		ts = append(ts, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+
+	_, err := p.resourceClient(disk).CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: []string{disk.ID()},
+		Tags:      ts,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot tag AWS disk: %w", err)
+	}
+	return nil
+}
+
+// snapshotWaitTimeout is how long to wait for a snapshot to complete
+// when the context doesn't have a deadline.
+const snapshotWaitTimeout = 1 * time.Hour
+
+// Snapshot creates a snapshot of the given disk and waits until it's
+// completed, returning the snapshot ID.
+func (p *Provider) Snapshot(ctx context.Context, disk unused.Disk) (string, error) {
+	client := p.resourceClient(disk)
+
+	res, err := client.CreateSnapshot(ctx, &ec2.CreateSnapshotInput{
+		VolumeId:    aws.String(disk.ID()),
+		Description: aws.String(fmt.Sprintf("Snapshot of %s taken before deleting it", disk.ID())),
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot create AWS snapshot: %w", err)
+	}
+
+	maxWait := snapshotWaitTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = time.Until(deadline)
+	}
+	if maxWait <= 0 {
+		// the waiter rejects non-positive wait times, so report the
+		// deadline instead
+		err := ctx.Err()
+		if err == nil {
+			err = context.DeadlineExceeded
+		}
+		return *res.SnapshotId, fmt.Errorf("waiting for AWS snapshot %s: %w", *res.SnapshotId, err)
+	}
+
+	w := ec2.NewSnapshotCompletedWaiter(client)
+	err = w.Wait(ctx, &ec2.DescribeSnapshotsInput{SnapshotIds: []string{*res.SnapshotId}}, maxWait)
+	if err != nil {
+		return *res.SnapshotId, fmt.Errorf("waiting for AWS snapshot %s: %w", *res.SnapshotId, err)
+	}
+
+	return *res.SnapshotId, nil
+}
+
+// ListUnusedAddresses returns all the AWS Elastic IP addresses that
+// aren't associated to any instance or network interface, in all the
+// regions of the provider.
+func (p *Provider) ListUnusedAddresses(ctx context.Context) (unused.Addresses, error) {
+	return eachRegion(ctx, p, p.listUnusedAddresses)
+}
+
+// listUnusedAddresses lists the unused addresses in a region with its
+// client.
+func (p *Provider) listUnusedAddresses(ctx context.Context, client *ec2.Client, region string) ([]unused.Address, error) {
+	res, err := client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list AWS addresses: %w", err)
+	}
+
+	var addrs unused.Addresses
+
+	for _, a := range res.Addresses {
+		if a.AssociationId != nil || a.InstanceId != nil || a.NetworkInterfaceId != nil {
+			continue
+		}
+
+		m := unused.Meta{
+			"domain": string(a.Domain),
+		}
+		if a.NetworkBorderGroup != nil {
+			m["network-border-group"] = *a.NetworkBorderGroup
+		}
+		if region != "" {
+			m[RegionMetaKey] = region
+		}
+		for _, t := range a.Tags {
+			k := *t.Key
+			if k == "Name" {
+				// already returned in Name()
+				continue
+			}
+			m[k] = *t.Value
+		}
+
+		addrs = append(addrs, &Address{a, p, m})
+	}
+
+	return addrs, nil
+}
+
+// DeleteAddress releases the given Elastic IP address.
+func (p *Provider) DeleteAddress(ctx context.Context, addr unused.Address) error {
+	params := &ec2.ReleaseAddressInput{}
+	if id := addr.ID(); strings.HasPrefix(id, "eipalloc-") {
+		params.AllocationId = aws.String(id)
+	} else {
+		params.PublicIp = aws.String(addr.Address())
+	}
+
+	if _, err := p.resourceClient(addr).ReleaseAddress(ctx, params); err != nil {
+		return fmt.Errorf("cannot release AWS address: %w", err)
+	}
+	return nil
+}
+
+// copiedVolumeID is the arbitrary volume ID AWS reports for snapshots
+// created by copying another snapshot; it should not be used for
+// anything.
+const copiedVolumeID = "vol-ffffffff"
+
+// ListUnusedSnapshots returns the AWS EBS snapshots owned by the
+// account whose source volume no longer exists, or which are older
+// than the given retention, in all the regions of the provider.
+func (p *Provider) ListUnusedSnapshots(ctx context.Context, retention time.Duration) (unused.Snapshots, error) {
+	return eachRegion(ctx, p, func(ctx context.Context, client *ec2.Client, region string) ([]unused.Snapshot, error) {
+		return p.listUnusedSnapshots(ctx, client, region, retention)
+	})
+}
+
+// listUnusedSnapshots lists the unused snapshots in a region with its
+// client.
+func (p *Provider) listUnusedSnapshots(ctx context.Context, client *ec2.Client, region string, retention time.Duration) ([]unused.Snapshot, error) {
+	volumes := make(map[string]struct{})
+
+	vpager := ec2.NewDescribeVolumesPaginator(client, &ec2.DescribeVolumesInput{})
+	for vpager.HasMorePages() {
+		res, err := vpager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list AWS volumes: %w", err)
+		}
+		for _, v := range res.Volumes {
+			volumes[*v.VolumeId] = struct{}{}
+		}
+	}
+
+	params := &ec2.DescribeSnapshotsInput{
+		OwnerIds: []string{"self"},
+	}
+
+	spager := ec2.NewDescribeSnapshotsPaginator(client, params)
+
+	var snaps []unused.Snapshot
+
+	for spager.HasMorePages() {
+		res, err := spager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list AWS snapshots: %w", err)
+		}
+
+		for _, s := range res.Snapshots {
+			snap := &Snapshot{Snapshot: s, provider: p}
+
+			if id := snap.SourceDiskID(); id != "" {
+				_, exists := volumes[id]
+				snap.orphaned = !exists
+			}
+
+			if !unused.IsUnusedSnapshot(snap, retention) {
+				continue
+			}
+
+			m := unused.Meta{
+				"source-volume": snap.SourceDiskID(),
+			}
+			if region != "" {
+				m[RegionMetaKey] = region
+			}
+			for _, t := range s.Tags {
+				k := *t.Key
+				if k == "Name" {
+					// already returned in Name()
+					continue
+				}
+				m[k] = *t.Value
+			}
+			snap.meta = m
+
+			snaps = append(snaps, snap)
+		}
+	}
+
+	return snaps, nil
+}
+
+// DeleteSnapshot deletes the given AWS EBS snapshot.
+func (p *Provider) DeleteSnapshot(ctx context.Context, snap unused.Snapshot) error {
+	_, err := p.resourceClient(snap).DeleteSnapshot(ctx, &ec2.DeleteSnapshotInput{
+		SnapshotId: aws.String(snap.ID()),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot delete AWS snapshot: %w", err)
+	}
+	return nil
+}
@@ -2,14 +2,23 @@ package aws_test
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	awsutil "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	cttypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	endpoints "github.com/aws/smithy-go/endpoints"
 	"github.com/grafana/unused"
@@ -159,3 +168,911 @@ func TestListUnusedDisks(t *testing.T) {
 		t.Fatalf("metadata doesn't match: %v", err)
 	}
 }
+
+type stubCloudTrail struct {
+	pages [][]cttypes.Event
+	calls []*cloudtrail.LookupEventsInput
+	err   error
+}
+
+func (s *stubCloudTrail) LookupEvents(ctx context.Context, in *cloudtrail.LookupEventsInput, _ ...func(*cloudtrail.Options)) (*cloudtrail.LookupEventsOutput, error) {
+	s.calls = append(s.calls, in)
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var page int
+	if in.NextToken != nil {
+		page, _ = strconv.Atoi(*in.NextToken)
+	}
+	if page >= len(s.pages) {
+		return &cloudtrail.LookupEventsOutput{}, nil
+	}
+
+	out := &cloudtrail.LookupEventsOutput{Events: s.pages[page]}
+	if page+1 < len(s.pages) {
+		out.NextToken = awsutil.String(strconv.Itoa(page + 1))
+	}
+
+	return out, nil
+}
+
+func TestListUnusedDisksCloudTrail(t *testing.T) {
+	ctx := context.Background()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, err := w.Write([]byte(`<DescribeVolumesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <volumeSet>
+      <item>
+         <volumeId>vol-1</volumeId>
+         <size>80</size>
+         <availabilityZone>us-east-1a</availabilityZone>
+         <status>available</status>
+         <createTime>2022-03-12T17:25:21.000Z</createTime>
+         <volumeType>standard</volumeType>
+      </item>
+      <item>
+         <volumeId>vol-2</volumeId>
+         <size>80</size>
+         <availabilityZone>us-east-1a</availabilityZone>
+         <status>available</status>
+         <createTime>2022-03-12T17:25:21.000Z</createTime>
+         <volumeType>standard</volumeType>
+      </item>
+      <item>
+         <volumeId>vol-3</volumeId>
+         <size>80</size>
+         <availabilityZone>us-east-1a</availabilityZone>
+         <status>available</status>
+         <createTime>2022-03-12T17:25:21.000Z</createTime>
+         <volumeType>standard</volumeType>
+      </item>
+   </volumeSet>
+</DescribeVolumesResponse>`))
+		if err != nil {
+			t.Fatalf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	tsURL, _ := url.Parse(ts.URL)
+	er := mockEndpointResolver(*tsURL)
+
+	cfg, err := config.LoadDefaultConfig(ctx,
+		config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
+			Value: awsutil.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"},
+		}))
+	if err != nil {
+		t.Fatalf("cannot load AWS config: %v", err)
+	}
+	client := ec2.NewFromConfig(cfg, ec2.WithEndpointResolverV2(ec2.EndpointResolverV2(er)))
+
+	var (
+		older = time.Date(2022, 4, 1, 10, 0, 0, 0, time.UTC)
+		newer = time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	)
+
+	ct := &stubCloudTrail{
+		pages: [][]cttypes.Event{
+			{
+				{
+					EventId:   awsutil.String("event-new"),
+					EventName: awsutil.String("DetachVolume"),
+					EventTime: awsutil.Time(newer),
+					Resources: []cttypes.Resource{
+						{ResourceType: awsutil.String("AWS::EC2::Volume"), ResourceName: awsutil.String("vol-1")},
+						{ResourceType: awsutil.String("AWS::EC2::Instance"), ResourceName: awsutil.String("i-new")},
+					},
+				},
+			},
+			{
+				{
+					EventName: awsutil.String("DetachVolume"),
+					EventTime: awsutil.Time(older),
+					Resources: []cttypes.Resource{
+						{ResourceType: awsutil.String("AWS::EC2::Volume"), ResourceName: awsutil.String("vol-1")},
+						{ResourceType: awsutil.String("AWS::EC2::Instance"), ResourceName: awsutil.String("i-old")},
+					},
+				},
+				{
+					// no resources, only the raw event
+					EventName:       awsutil.String("DetachVolume"),
+					EventTime:       awsutil.Time(older),
+					CloudTrailEvent: awsutil.String(`{"requestParameters":{"volumeId":"vol-2","instanceId":"i-raw"}}`),
+				},
+			},
+		},
+	}
+
+	p, err := aws.NewProvider(nil, client, nil, aws.WithCloudTrail(func(string) cloudtrail.LookupEventsAPIClient { return ct }))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	disks, err := p.ListUnusedDisks(ctx)
+	if err != nil {
+		t.Fatal("unexpected error listing unused disks:", err)
+	}
+
+	if exp, got := 3, len(disks); exp != got {
+		t.Fatalf("expecting %d disks, got %d", exp, got)
+	}
+
+	tests := []struct {
+		lastUsed time.Time
+		instance string
+	}{
+		{newer, "i-new"},
+		{older, "i-raw"},
+		{time.Time{}, ""},
+	}
+	for i, tt := range tests {
+		d := disks[i]
+		if !tt.lastUsed.Equal(d.LastUsedAt()) {
+			t.Errorf("%s: expecting last used at %v, got %v", d.ID(), tt.lastUsed, d.LastUsedAt())
+		}
+		if exp, got := tt.instance, d.Meta()[aws.LastAttachedInstanceMetaKey]; exp != got {
+			t.Errorf("%s: expecting last attached instance %q, got %q", d.ID(), exp, got)
+		}
+	}
+
+	if exp, got := 2, len(ct.calls); exp != got {
+		t.Fatalf("expecting %d lookups, got %d", exp, got)
+	}
+	if attrs := ct.calls[0].LookupAttributes; len(attrs) != 1 || *attrs[0].AttributeValue != "DetachVolume" {
+		t.Errorf("unexpected lookup attributes: %v", attrs)
+	}
+
+	// a second listing only looks up events since the previous one,
+	// overlapping it for late events, and keeps the cached ones
+	late := time.Now().Add(-time.Minute)
+	ct.pages = [][]cttypes.Event{{
+		{
+			EventId:   awsutil.String("event-late"),
+			EventName: awsutil.String("DetachVolume"),
+			EventTime: awsutil.Time(late),
+			Resources: []cttypes.Resource{
+				{ResourceType: awsutil.String("AWS::EC2::Volume"), ResourceName: awsutil.String("vol-3")},
+				{ResourceType: awsutil.String("AWS::EC2::Instance"), ResourceName: awsutil.String("i-late")},
+			},
+		},
+		{
+			// an event for a volume that isn't listed
+			EventId:   awsutil.String("event-other"),
+			EventName: awsutil.String("DetachVolume"),
+			EventTime: awsutil.Time(late),
+			Resources: []cttypes.Resource{
+				{ResourceType: awsutil.String("AWS::EC2::Volume"), ResourceName: awsutil.String("vol-other")},
+			},
+		},
+	}}
+	disks, err = p.ListUnusedDisks(ctx)
+	if err != nil {
+		t.Fatal("unexpected error listing unused disks:", err)
+	}
+
+	if exp, got := 3, len(ct.calls); exp != got {
+		t.Fatalf("expecting %d lookups, got %d", exp, got)
+	}
+	if exp, got := ct.calls[0].EndTime.Add(-15*time.Minute), *ct.calls[2].StartTime; !exp.Equal(got) {
+		t.Errorf("expecting incremental lookup from %v, got %v", exp, got)
+	}
+	if !newer.Equal(disks[0].LastUsedAt()) {
+		t.Errorf("expecting cached last used at %v, got %v", newer, disks[0].LastUsedAt())
+	}
+	if !late.Equal(disks[2].LastUsedAt()) || disks[2].Meta()[aws.LastAttachedInstanceMetaKey] != "i-late" {
+		t.Errorf("expecting late event at %v, got %v", late, disks[2].LastUsedAt())
+	}
+
+	t.Run("lookup error", func(t *testing.T) {
+		ct := &stubCloudTrail{err: errors.New("access denied")}
+
+		p, err := aws.NewProvider(slog.New(slog.DiscardHandler), client, nil, aws.WithCloudTrail(func(string) cloudtrail.LookupEventsAPIClient { return ct }))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		disks, err := p.ListUnusedDisks(ctx)
+		if err != nil {
+			t.Fatal("unexpected error listing unused disks:", err)
+		}
+		if exp, got := 3, len(disks); exp != got {
+			t.Fatalf("expecting %d disks, got %d", exp, got)
+		}
+		if !disks[0].LastUsedAt().IsZero() {
+			t.Errorf("expecting zero last used at, got %v", disks[0].LastUsedAt())
+		}
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	var actions []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			t.Fatalf("unexpected error parsing request: %v", err)
+		}
+
+		action := req.Form.Get("Action")
+		actions = append(actions, action)
+
+		var res string
+		switch action {
+		case "CreateSnapshot":
+			if exp, got := "vol-1234567890abcdef0", req.Form.Get("VolumeId"); exp != got {
+				t.Errorf("expecting volume ID %q, got %q", exp, got)
+			}
+			res = `<CreateSnapshotResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <requestId>59dbff89-35bd-4eac-99ed-be587EXAMPLE</requestId>
+   <snapshotId>snap-1234567890abcdef0</snapshotId>
+   <volumeId>vol-1234567890abcdef0</volumeId>
+   <status>pending</status>
+</CreateSnapshotResponse>`
+		case "DescribeSnapshots":
+			res = `<DescribeSnapshotsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <requestId>59dbff89-35bd-4eac-99ed-be587EXAMPLE</requestId>
+   <snapshotSet>
+      <item>
+         <snapshotId>snap-1234567890abcdef0</snapshotId>
+         <volumeId>vol-1234567890abcdef0</volumeId>
+         <status>completed</status>
+      </item>
+   </snapshotSet>
+</DescribeSnapshotsResponse>`
+		default:
+			t.Fatalf("unexpected action %q", action)
+		}
+
+		if _, err := w.Write([]byte(res)); err != nil {
+			t.Fatalf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(t, ts.URL)
+
+	now := time.Now()
+	id, err := p.Snapshot(ctx, unusedtest.NewDisk("vol-1234567890abcdef0", p, now, now))
+	if err != nil {
+		t.Fatalf("unexpected error taking snapshot: %v", err)
+	}
+
+	if exp, got := "snap-1234567890abcdef0", id; exp != got {
+		t.Errorf("expecting snapshot ID %q, got %q", exp, got)
+	}
+
+	if exp, got := []string{"CreateSnapshot", "DescribeSnapshots"}, actions; !slices.Equal(exp, got) {
+		t.Errorf("expecting actions %q, got %q", exp, got)
+	}
+}
+
+func TestTagDisk(t *testing.T) {
+	ctx := context.Background()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			t.Fatalf("unexpected error parsing request: %v", err)
+		}
+
+		if exp, got := "CreateTags", req.Form.Get("Action"); exp != got {
+			t.Fatalf("expecting action %q, got %q", exp, got)
+		}
+		if exp, got := "vol-1234567890abcdef0", req.Form.Get("ResourceId.1"); exp != got {
+			t.Errorf("expecting resource ID %q, got %q", exp, got)
+		}
+		if k, v := req.Form.Get("Tag.1.Key"), req.Form.Get("Tag.1.Value"); k != unused.QuarantineKey || v != "1700000000" {
+			t.Errorf("unexpected tag %s=%s", k, v)
+		}
+
+		res := `<CreateTagsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <requestId>7a62c49f-347e-4fc4-9331-6e8eEXAMPLE</requestId>
+   <return>true</return>
+</CreateTagsResponse>`
+		if _, err := w.Write([]byte(res)); err != nil {
+			t.Fatalf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(t, ts.URL)
+
+	now := time.Now()
+	err := p.TagDisk(ctx, unusedtest.NewDisk("vol-1234567890abcdef0", p, now, now), map[string]string{unused.QuarantineKey: "1700000000"})
+	if err != nil {
+		t.Fatalf("unexpected error tagging disk: %v", err)
+	}
+}
+
+// newTestProvider returns a provider with an EC2 client sending all
+// requests to the given URL.
+func newTestProvider(t *testing.T, endpoint string, opts ...aws.Option) *aws.Provider {
+	t.Helper()
+
+	u, _ := url.Parse(endpoint)
+	er := mockEndpointResolver(*u)
+
+	cfg, err := config.LoadDefaultConfig(context.Background(),
+		config.WithRegion("us-east-1"),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("AKID", "SECRET", "SESSION")))
+	if err != nil {
+		t.Fatalf("cannot load AWS config: %v", err)
+	}
+
+	p, err := aws.NewProvider(nil, ec2.NewFromConfig(cfg, ec2.WithEndpointResolverV2(ec2.EndpointResolverV2(er))), nil, opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return p
+}
+
+// requestRegion returns the region a request was signed for.
+func requestRegion(req *http.Request) string {
+	// Credential=AKID/20240101/us-east-1/ec2/aws4_request
+	_, scope, _ := strings.Cut(req.Header.Get("Authorization"), "Credential=")
+	parts := strings.Split(scope, "/")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[2]
+}
+
+func TestListUnusedDisksRegions(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		mu      sync.Mutex
+		deleted []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			t.Errorf("unexpected error parsing request: %v", err)
+			return
+		}
+
+		region := requestRegion(req)
+
+		var res string
+		switch action := req.Form.Get("Action"); action {
+		case "DescribeRegions":
+			res = `<DescribeRegionsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <regionInfo>
+      <item><regionName>eu-west-1</regionName></item>
+      <item><regionName>us-east-1</regionName></item>
+      <item><regionName>us-west-2</regionName></item>
+   </regionInfo>
+</DescribeRegionsResponse>`
+
+		case "DescribeVolumes":
+			res = `<DescribeVolumesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <volumeSet>
+      <item>
+         <volumeId>vol-` + region + `</volumeId>
+         <size>10</size>
+         <availabilityZone>` + region + `a</availabilityZone>
+         <status>available</status>
+         <createTime>2022-03-12T17:25:21.000Z</createTime>
+         <volumeType>gp3</volumeType>
+      </item>
+   </volumeSet>
+</DescribeVolumesResponse>`
+
+		case "DeleteVolume":
+			mu.Lock()
+			deleted = append(deleted, region+"/"+req.Form.Get("VolumeId"))
+			mu.Unlock()
+			res = `<DeleteVolumeResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><return>true</return></DeleteVolumeResponse>`
+
+		case "DescribeAddresses":
+			res = `<DescribeAddressesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <addressesSet>
+      <item>
+         <publicIp>198.51.100.1</publicIp>
+         <allocationId>eipalloc-` + region + `</allocationId>
+         <domain>vpc</domain>
+      </item>
+   </addressesSet>
+</DescribeAddressesResponse>`
+
+		case "ReleaseAddress":
+			mu.Lock()
+			deleted = append(deleted, region+"/"+req.Form.Get("AllocationId"))
+			mu.Unlock()
+			res = `<ReleaseAddressResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><return>true</return></ReleaseAddressResponse>`
+
+		case "DescribeSnapshots":
+			res = `<DescribeSnapshotsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <snapshotSet>
+      <item>
+         <snapshotId>snap-` + region + `</snapshotId>
+         <volumeId>vol-gone</volumeId>
+         <status>completed</status>
+         <startTime>2022-03-12T17:25:21.000Z</startTime>
+      </item>
+   </snapshotSet>
+</DescribeSnapshotsResponse>`
+
+		case "DeleteSnapshot":
+			mu.Lock()
+			deleted = append(deleted, region+"/"+req.Form.Get("SnapshotId"))
+			mu.Unlock()
+			res = `<DeleteSnapshotResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><return>true</return></DeleteSnapshotResponse>`
+
+		default:
+			t.Errorf("unexpected action %q", action)
+		}
+
+		if _, err := w.Write([]byte(res)); err != nil {
+			t.Errorf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	tests := map[string]struct {
+		opt   aws.Option
+		exp   []string
+		scope string
+	}{
+		"all regions": {aws.WithAllRegions(), []string{"eu-west-1", "us-east-1", "us-west-2"}, "*"},
+		"explicit":    {aws.WithRegions("us-west-2", "eu-west-1"), []string{"eu-west-1", "us-west-2"}, "eu-west-1,us-west-2"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			deleted = nil
+			p := newTestProvider(t, ts.URL, tt.opt)
+
+			if got := p.Scope(); got != tt.scope {
+				t.Errorf("expecting scope %q, got %q", tt.scope, got)
+			}
+
+			disks, err := p.ListUnusedDisks(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, d := range disks {
+				region := d.Meta()[aws.RegionMetaKey]
+				if exp := "vol-" + region; d.ID() != exp {
+					t.Errorf("expecting disk %s in region %s, got %s", exp, region, d.ID())
+				}
+				if d.Location().Region != region {
+					t.Errorf("expecting location region %s, got %s", region, d.Location().Region)
+				}
+				got = append(got, region)
+			}
+			if !slices.Equal(got, tt.exp) {
+				t.Errorf("expecting disks in regions %v, got %v", tt.exp, got)
+			}
+
+			for _, d := range disks {
+				if err := p.Delete(ctx, d); err != nil {
+					t.Fatalf("unexpected error deleting disk: %v", err)
+				}
+			}
+
+			if len(deleted) != len(tt.exp) {
+				t.Fatalf("expecting %d disks deleted, got %v", len(tt.exp), deleted)
+			}
+			for i, region := range tt.exp {
+				if exp := region + "/vol-" + region; deleted[i] != exp {
+					t.Errorf("expecting disk deleted as %s, got %s", exp, deleted[i])
+				}
+			}
+
+			deleted = nil
+
+			addrs, err := p.ListUnusedAddresses(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error listing addresses: %v", err)
+			}
+			snaps, err := p.ListUnusedSnapshots(ctx, 0)
+			if err != nil {
+				t.Fatalf("unexpected error listing snapshots: %v", err)
+			}
+			if len(addrs) != len(tt.exp) || len(snaps) != len(tt.exp) {
+				t.Fatalf("expecting an address and a snapshot per region, got %v and %v", addrs, snaps)
+			}
+
+			for i, region := range tt.exp {
+				if err := p.DeleteAddress(ctx, addrs[i]); err != nil {
+					t.Fatalf("unexpected error deleting address: %v", err)
+				}
+				if err := p.DeleteSnapshot(ctx, snaps[i]); err != nil {
+					t.Fatalf("unexpected error deleting snapshot: %v", err)
+				}
+
+				exp := []string{region + "/eipalloc-" + region, region + "/snap-" + region}
+				if got := deleted[2*i : 2*i+2]; !slices.Equal(exp, got) {
+					t.Errorf("expecting %v deleted, got %v", exp, got)
+				}
+			}
+		})
+	}
+
+	t.Run("cloudtrail", func(t *testing.T) {
+		var (
+			at      = time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+			regions []string
+		)
+
+		p := newTestProvider(t, ts.URL, aws.WithRegions("us-west-2", "eu-west-1"), aws.WithCloudTrail(func(region string) cloudtrail.LookupEventsAPIClient {
+			mu.Lock()
+			regions = append(regions, region)
+			mu.Unlock()
+
+			return &stubCloudTrail{pages: [][]cttypes.Event{{{
+				EventName: awsutil.String("DetachVolume"),
+				EventTime: awsutil.Time(at),
+				Resources: []cttypes.Resource{
+					{ResourceType: awsutil.String("AWS::EC2::Volume"), ResourceName: awsutil.String("vol-" + region)},
+					{ResourceType: awsutil.String("AWS::EC2::Instance"), ResourceName: awsutil.String("i-" + region)},
+				},
+			}}}}
+		}))
+
+		for range 2 {
+			disks, err := p.ListUnusedDisks(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, d := range disks {
+				region := d.Meta()[aws.RegionMetaKey]
+				if !at.Equal(d.LastUsedAt()) || d.Meta()[aws.LastAttachedInstanceMetaKey] != "i-"+region {
+					t.Errorf("expecting disk %s detached from i-%s at %v, got %v and %v", d.ID(), region, at, d.Meta()[aws.LastAttachedInstanceMetaKey], d.LastUsedAt())
+				}
+			}
+		}
+
+		slices.Sort(regions)
+		if exp := []string{"eu-west-1", "us-west-2"}; !slices.Equal(exp, regions) {
+			t.Errorf("expecting a CloudTrail client per region %v, got %v", exp, regions)
+		}
+	})
+}
+
+func TestListUnusedAddresses(t *testing.T) {
+	ctx := context.Background()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, err := w.Write([]byte(`<DescribeAddressesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <requestId>59dbff89-35bd-4eac-99ed-be587EXAMPLE</requestId>
+   <addressesSet>
+      <item>
+         <publicIp>198.51.100.1</publicIp>
+         <allocationId>eipalloc-11111111</allocationId>
+         <domain>vpc</domain>
+         <associationId>eipassoc-11111111</associationId>
+         <instanceId>i-1234567890abcdef0</instanceId>
+         <networkBorderGroup>us-east-1</networkBorderGroup>
+      </item>
+      <item>
+         <publicIp>198.51.100.2</publicIp>
+         <allocationId>eipalloc-22222222</allocationId>
+         <domain>vpc</domain>
+         <networkBorderGroup>us-east-1</networkBorderGroup>
+         <tagSet>
+            <item>
+               <key>Name</key>
+               <value>ingress</value>
+            </item>
+            <item>
+               <key>team</key>
+               <value>platform</value>
+            </item>
+         </tagSet>
+      </item>
+      <item>
+         <publicIp>198.51.100.3</publicIp>
+         <allocationId>eipalloc-33333333</allocationId>
+         <domain>vpc</domain>
+         <networkInterfaceId>eni-12345678</networkInterfaceId>
+         <networkBorderGroup>us-east-1</networkBorderGroup>
+      </item>
+   </addressesSet>
+</DescribeAddressesResponse>`))
+		if err != nil {
+			t.Fatalf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(t, ts.URL)
+
+	addrs, err := p.ListUnusedAddresses(ctx)
+	if err != nil {
+		t.Fatal("unexpected error listing unused addresses:", err)
+	}
+
+	if exp, got := 1, len(addrs); exp != got {
+		t.Fatalf("expecting %d addresses, got %d", exp, got)
+	}
+
+	a := addrs[0]
+	if exp, got := "eipalloc-22222222", a.ID(); exp != got {
+		t.Errorf("expecting ID() %q, got %q", exp, got)
+	}
+	if exp, got := "ingress", a.Name(); exp != got {
+		t.Errorf("expecting Name() %q, got %q", exp, got)
+	}
+	if exp, got := "198.51.100.2", a.Address(); exp != got {
+		t.Errorf("expecting Address() %q, got %q", exp, got)
+	}
+
+	err = unusedtest.AssertEqualMeta(unused.Meta{
+		"domain":               "vpc",
+		"network-border-group": "us-east-1",
+		"region":               "us-east-1",
+		"team":                 "platform",
+	}, a.Meta())
+	if err != nil {
+		t.Fatalf("metadata doesn't match: %v", err)
+	}
+}
+
+func TestListUnusedSnapshots(t *testing.T) {
+	ctx := context.Background()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			t.Fatalf("unexpected error parsing form: %v", err)
+		}
+
+		var body string
+		switch action := req.Form.Get("Action"); action {
+		case "DescribeVolumes":
+			body = `<DescribeVolumesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <requestId>59dbff89-35bd-4eac-99ed-be587EXAMPLE</requestId>
+   <volumeSet>
+      <item>
+         <volumeId>vol-11111111</volumeId>
+         <size>80</size>
+         <availabilityZone>us-east-1a</availabilityZone>
+         <status>in-use</status>
+      </item>
+   </volumeSet>
+</DescribeVolumesResponse>`
+
+		case "DescribeSnapshots":
+			if exp, got := "self", req.Form.Get("Owner.1"); exp != got {
+				t.Errorf("expecting owner %q, got %q", exp, got)
+			}
+			body = `<DescribeSnapshotsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <requestId>59dbff89-35bd-4eac-99ed-be587EXAMPLE</requestId>
+   <snapshotSet>
+      <item>
+         <snapshotId>snap-11111111</snapshotId>
+         <volumeId>vol-11111111</volumeId>
+         <status>completed</status>
+         <startTime>2022-03-12T17:25:21.000Z</startTime>
+         <volumeSize>80</volumeSize>
+      </item>
+      <item>
+         <snapshotId>snap-22222222</snapshotId>
+         <volumeId>vol-22222222</volumeId>
+         <status>completed</status>
+         <startTime>2022-03-12T17:25:21.000Z</startTime>
+         <volumeSize>20</volumeSize>
+         <tagSet>
+            <item>
+               <key>kubernetes.io/created-for/volumesnapshot/name</key>
+               <value>snap-foo</value>
+            </item>
+            <item>
+               <key>kubernetes.io/created-for/volumesnapshot/namespace</key>
+               <value>ns-bar</value>
+            </item>
+         </tagSet>
+      </item>
+      <item>
+         <snapshotId>snap-33333333</snapshotId>
+         <volumeId>vol-ffffffff</volumeId>
+         <status>completed</status>
+         <startTime>2022-03-12T17:25:21.000Z</startTime>
+         <volumeSize>20</volumeSize>
+      </item>
+   </snapshotSet>
+</DescribeSnapshotsResponse>`
+
+		default:
+			t.Fatalf("unexpected action %q", action)
+		}
+
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(t, ts.URL)
+
+	t.Run("orphaned", func(t *testing.T) {
+		snaps, err := p.ListUnusedSnapshots(ctx, 0)
+		if err != nil {
+			t.Fatal("unexpected error listing unused snapshots:", err)
+		}
+
+		if exp, got := 1, len(snaps); exp != got {
+			t.Fatalf("expecting %d snapshots, got %d", exp, got)
+		}
+
+		s := snaps[0]
+		if exp, got := "snap-22222222", s.ID(); exp != got {
+			t.Errorf("expecting ID() %q, got %q", exp, got)
+		}
+		if !s.Orphaned() {
+			t.Error("expecting snapshot to be orphaned")
+		}
+		if exp, got := "snap-foo", s.Meta().CreatedForVolumeSnapshot(); exp != got {
+			t.Errorf("expecting VolumeSnapshot %q, got %q", exp, got)
+		}
+		if exp, got := "ns-bar", s.Meta().CreatedForNamespace(); exp != got {
+			t.Errorf("expecting namespace %q, got %q", exp, got)
+		}
+	})
+
+	t.Run("retention", func(t *testing.T) {
+		snaps, err := p.ListUnusedSnapshots(ctx, 24*time.Hour)
+		if err != nil {
+			t.Fatal("unexpected error listing unused snapshots:", err)
+		}
+
+		if exp, got := 3, len(snaps); exp != got {
+			t.Fatalf("expecting %d snapshots, got %d", exp, got)
+		}
+	})
+}
+
+func TestListUnusedDisksRestored(t *testing.T) {
+	ctx := context.Background()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			t.Errorf("unexpected error parsing request: %v", err)
+			return
+		}
+
+		var res string
+		switch action := req.Form.Get("Action"); action {
+		case "DescribeVolumes":
+			for k, v := range req.Form {
+				if strings.HasSuffix(k, ".Name") && v[0] == "snapshot-id" {
+					t.Errorf("expecting volumes not to be filtered by snapshot, got %s=%s", k, v[0])
+				}
+			}
+			res = `<DescribeVolumesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <volumeSet>
+      <item>
+         <volumeId>vol-1</volumeId>
+         <size>10</size>
+         <availabilityZone>us-east-1a</availabilityZone>
+         <status>available</status>
+      </item>
+      <item>
+         <volumeId>vol-2</volumeId>
+         <size>500</size>
+         <snapshotId>snap-exists</snapshotId>
+         <availabilityZone>us-east-1a</availabilityZone>
+         <status>available</status>
+      </item>
+      <item>
+         <volumeId>vol-3</volumeId>
+         <size>500</size>
+         <snapshotId>snap-gone</snapshotId>
+         <availabilityZone>us-east-1a</availabilityZone>
+         <status>available</status>
+      </item>
+   </volumeSet>
+</DescribeVolumesResponse>`
+
+		case "DescribeSnapshots":
+			if exp, got := "snapshot-id", req.Form.Get("Filter.1.Name"); exp != got {
+				t.Errorf("expecting filter %q, got %q", exp, got)
+			}
+			if exp, got := []string{"snap-exists", "snap-gone"}, []string{req.Form.Get("Filter.1.Value.1"), req.Form.Get("Filter.1.Value.2")}; !slices.Equal(exp, got) {
+				t.Errorf("expecting snapshots %v, got %v", exp, got)
+			}
+			res = `<DescribeSnapshotsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <snapshotSet>
+      <item><snapshotId>snap-exists</snapshotId><status>completed</status></item>
+   </snapshotSet>
+</DescribeSnapshotsResponse>`
+
+		default:
+			t.Errorf("unexpected action %q", action)
+		}
+
+		if _, err := w.Write([]byte(res)); err != nil {
+			t.Errorf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(t, ts.URL, aws.WithRestoredVolumes())
+
+	disks, err := p.ListUnusedDisks(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(disks) != 3 {
+		t.Fatalf("expecting 3 disks, got %d", len(disks))
+	}
+
+	exp := map[string][2]string{
+		"vol-1": {"", ""},
+		"vol-2": {"snap-exists", "true"},
+		"vol-3": {"snap-gone", "false"},
+	}
+	for _, d := range disks {
+		m := d.Meta()
+		if got := [2]string{m[aws.SourceSnapshotMetaKey], m[aws.SourceSnapshotExistsMetaKey]}; exp[d.ID()] != got {
+			t.Errorf("expecting disk %s source snapshot %v, got %v", d.ID(), exp[d.ID()], got)
+		}
+	}
+}
+
+func TestListUnusedDisksMultiAttach(t *testing.T) {
+	ctx := context.Background()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, err := w.Write([]byte(`<DescribeVolumesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+   <volumeSet>
+      <item>
+         <volumeId>vol-single</volumeId>
+         <size>10</size>
+         <availabilityZone>us-east-1a</availabilityZone>
+         <status>available</status>
+         <volumeType>gp3</volumeType>
+      </item>
+      <item>
+         <volumeId>vol-multi-unused</volumeId>
+         <size>100</size>
+         <availabilityZone>us-east-1a</availabilityZone>
+         <status>available</status>
+         <volumeType>io2</volumeType>
+         <multiAttachEnabled>true</multiAttachEnabled>
+         <attachmentSet>
+            <item>
+               <volumeId>vol-multi-unused</volumeId>
+               <instanceId>i-1</instanceId>
+               <status>detached</status>
+            </item>
+         </attachmentSet>
+      </item>
+   </volumeSet>
+</DescribeVolumesResponse>`))
+		if err != nil {
+			t.Errorf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(t, ts.URL)
+
+	disks, err := p.ListUnusedDisks(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := map[string]unused.Meta{
+		"vol-single": {"zone": "us-east-1a", aws.RegionMetaKey: "us-east-1"},
+		"vol-multi-unused": {
+			"zone":                "us-east-1a",
+			aws.RegionMetaKey:     "us-east-1",
+			unused.SharingMetaKey: aws.MultiAttach,
+		},
+	}
+	if len(disks) != len(exp) {
+		t.Fatalf("expecting %d unused disks, got %d", len(exp), len(disks))
+	}
+	for _, d := range disks {
+		if err := unusedtest.AssertEqualMeta(exp[d.ID()], d.Meta()); err != nil {
+			t.Errorf("disk %s metadata doesn't match: %v", d.ID(), err)
+		}
+	}
+}
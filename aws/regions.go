@@ -0,0 +1,134 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/grafana/unused"
+	"golang.org/x/sync/errgroup"
+)
+
+// RegionMetaKey is the metadata key holding the region of the disks.
+const RegionMetaKey = "region"
+
+// WithRegions makes the provider list unused resources in the given
+// regions instead of only the region of its EC2 client.
+func WithRegions(regions ...string) Option {
+	return func(p *Provider) { p.regions = regions }
+}
+
+// WithAllRegions makes the provider list unused resources in all the
+// regions enabled for the account, as returned by DescribeRegions.
+func WithAllRegions() Option {
+	return func(p *Provider) { p.allRegions = true }
+}
+
+// Scope returns the regions the provider lists resources in: * for
+// all the enabled ones, the sorted list of the regions it was
+// configured with, or the region of its EC2 client.
+func (p *Provider) Scope() string {
+	switch {
+	case p.allRegions:
+		return "*"
+	case len(p.regions) > 0:
+		return strings.Join(slices.Sorted(slices.Values(p.regions)), ",")
+	default:
+		return p.client.Options().Region
+	}
+}
+
+// regionClients returns the EC2 clients to list resources with,
+// indexed by region. Enabled regions are only looked up once.
+func (p *Provider) regionClients(ctx context.Context) (map[string]*ec2.Client, error) {
+	if !p.allRegions && len(p.regions) == 0 {
+		return map[string]*ec2.Client{p.client.Options().Region: p.client}, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.clients != nil {
+		return p.clients, nil
+	}
+
+	regions := slices.Clone(p.regions)
+	if p.allRegions {
+		// only enabled regions are returned unless AllRegions is set
+		res, err := p.client.DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
+		if err != nil {
+			return nil, fmt.Errorf("cannot list AWS regions: %w", err)
+		}
+		for _, r := range res.Regions {
+			regions = append(regions, aws.ToString(r.RegionName))
+		}
+	}
+
+	clients := make(map[string]*ec2.Client, len(regions))
+	for _, r := range regions {
+		clients[r] = p.regionClient(r)
+	}
+	p.clients = clients
+
+	return clients, nil
+}
+
+// regionClient returns an EC2 client for the given region, configured
+// like the provider client.
+func (p *Provider) regionClient(region string) *ec2.Client {
+	if region == "" || region == p.client.Options().Region {
+		return p.client
+	}
+	return ec2.New(p.client.Options(), func(o *ec2.Options) { o.Region = region })
+}
+
+// resourceClient returns the EC2 client for the region of the given
+// resource.
+func (p *Provider) resourceClient(r unused.Resource) *ec2.Client {
+	region := r.Meta()[RegionMetaKey]
+
+	p.mu.Lock()
+	c, ok := p.clients[region]
+	p.mu.Unlock()
+	if ok {
+		return c
+	}
+
+	return p.regionClient(region)
+}
+
+// eachRegion lists resources in all the regions of the provider
+// concurrently, returning them ordered by region.
+func eachRegion[T any](ctx context.Context, p *Provider, list func(context.Context, *ec2.Client, string) ([]T, error)) ([]T, error) {
+	clients, err := p.regionClients(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	regions := slices.Sorted(maps.Keys(clients))
+	res := make([][]T, len(regions))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, region := range regions {
+		g.Go(func() error {
+			rs, err := list(gctx, clients[region], region)
+			if err != nil {
+				if len(regions) > 1 {
+					return fmt.Errorf("region %s: %w", region, err)
+				}
+				return err
+			}
+			res[i] = rs
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return slices.Concat(res...), nil
+}
@@ -0,0 +1,139 @@
+package aws
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/grafana/unused"
+)
+
+func init() {
+	unused.Register(unused.Registration{
+		Name:  ProviderName,
+		Flags: flags,
+		Match: func(p unused.Provider) bool {
+			_, ok := unused.As[*Provider](p)
+			return ok
+		},
+		DiscoveryFlags: []string{"aws.org.profile"},
+	})
+}
+
+func flags(fs *flag.FlagSet) unused.CreateFunc {
+	var (
+		profiles   []string
+		regions    []string
+		allRegions bool
+		cloudTrail bool
+		restored   bool
+
+		orgProfile string
+		orgRole    string
+		orgInclude []string
+		orgExclude []string
+	)
+
+	fs.Func("aws.profile", "AWS profile (can be specified multiple times)", func(v string) error {
+		profiles = append(profiles, v)
+		return nil
+	})
+	fs.StringVar(&ProviderName, "aws.providername", ProviderName, `AWS provider name to use, default: "AWS" (e.g. "EKS")`)
+	fs.Func("aws.region", "AWS region to list disks in instead of the profile region (can be specified multiple times)", func(v string) error {
+		regions = append(regions, v)
+		return nil
+	})
+	fs.BoolVar(&allRegions, "aws.all-regions", false, "List AWS disks in all the regions enabled for the account")
+	fs.BoolVar(&cloudTrail, "aws.cloudtrail", false, "Look up when AWS volumes were last detached in CloudTrail")
+	fs.BoolVar(&restored, "aws.include-restored", false, "Also list available AWS volumes restored from snapshots, recording whether their source snapshot still exists")
+	fs.StringVar(&orgProfile, "aws.org.profile", "", "AWS profile of an organization management account, to list disks in all its accounts")
+	fs.StringVar(&orgRole, "aws.org.role", DefaultOrganizationRole, "Name of the role to assume in each AWS organization account")
+	fs.Func("aws.org.include", "Only list disks in the AWS organization accounts whose ID or name match this pattern (can be specified multiple times)", func(v string) error {
+		orgInclude = append(orgInclude, v)
+		return nil
+	})
+	fs.Func("aws.org.exclude", "Skip the AWS organization accounts whose ID or name match this pattern (can be specified multiple times)", func(v string) error {
+		orgExclude = append(orgExclude, v)
+		return nil
+	})
+
+	newProvider := func(logger *slog.Logger, cfg aws.Config, meta unused.Meta) (*Provider, error) {
+		var opts []Option
+		if allRegions {
+			opts = append(opts, WithAllRegions())
+		} else if len(regions) > 0 {
+			opts = append(opts, WithRegions(regions...))
+		}
+		if cloudTrail {
+			opts = append(opts, WithCloudTrail(func(region string) cloudtrail.LookupEventsAPIClient {
+				return cloudtrail.NewFromConfig(cfg, func(o *cloudtrail.Options) {
+					if region != "" {
+						o.Region = region
+					}
+				})
+			}))
+		}
+		if restored {
+			opts = append(opts, WithRestoredVolumes())
+		}
+
+		return NewProvider(logger, ec2.NewFromConfig(cfg), meta, opts...)
+	}
+
+	return func(ctx context.Context, logger *slog.Logger) ([]unused.Provider, error) {
+		providers := make([]unused.Provider, 0, len(profiles))
+
+		for _, profile := range profiles {
+			cfg, err := config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(profile))
+			if err != nil {
+				return nil, fmt.Errorf("cannot load AWS config for profile %s: %w", profile, err)
+			}
+
+			p, err := newProvider(logger, cfg, unused.Meta{"profile": profile})
+			if err != nil {
+				return nil, fmt.Errorf("creating AWS provider for profile %s: %w", profile, err)
+			}
+			providers = append(providers, p)
+		}
+
+		if orgProfile == "" {
+			return providers, nil
+		}
+
+		match, err := AccountMatcher(orgInclude, orgExclude)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(orgProfile))
+		if err != nil {
+			return nil, fmt.Errorf("cannot load AWS config for profile %s: %w", orgProfile, err)
+		}
+
+		accounts, err := ListAccounts(ctx, organizations.NewFromConfig(cfg), match)
+		if err != nil {
+			return nil, fmt.Errorf("discovering AWS accounts with profile %s: %w", orgProfile, err)
+		}
+
+		for _, a := range accounts {
+			acfg := cfg
+			if !a.Management {
+				acfg = AssumeRoleConfig(cfg, a, orgRole)
+			}
+
+			p, err := newProvider(logger, acfg, a.Meta())
+			if err != nil {
+				return nil, fmt.Errorf("creating AWS provider for account %s: %w", a.ID, err)
+			}
+			providers = append(providers, p)
+		}
+
+		return providers, nil
+	}
+}
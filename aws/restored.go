@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Metadata keys of volumes restored from snapshots.
+const (
+	SourceSnapshotMetaKey       = "aws:source-snapshot"
+	SourceSnapshotExistsMetaKey = "aws:source-snapshot-exists"
+)
+
+// maxFilterValues is the maximum number of values of an EC2 filter.
+const maxFilterValues = 200
+
+// WithRestoredVolumes makes the provider also list the unused volumes
+// restored from snapshots, which are skipped by default. Their source
+// snapshot and whether it still exists are recorded in their
+// metadata.
+func WithRestoredVolumes() Option {
+	return func(p *Provider) { p.restored = true }
+}
+
+// existingSnapshots returns which of the given snapshots still exist.
+// Snapshots are looked up with a filter, as looking them up by ID
+// fails if any of them is missing.
+func existingSnapshots(ctx context.Context, client *ec2.Client, ids []string) (map[string]bool, error) {
+	exists := make(map[string]bool, len(ids))
+
+	for chunk := range slices.Chunk(ids, maxFilterValues) {
+		params := &ec2.DescribeSnapshotsInput{
+			Filters: []types.Filter{{Name: aws.String("snapshot-id"), Values: chunk}},
+		}
+
+		pager := ec2.NewDescribeSnapshotsPaginator(client, params)
+		for pager.HasMorePages() {
+			res, err := pager.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("cannot look up AWS source snapshots: %w", err)
+			}
+			for _, s := range res.Snapshots {
+				exists[aws.ToString(s.SnapshotId)] = true
+			}
+		}
+	}
+
+	return exists, nil
+}
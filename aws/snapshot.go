@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/grafana/unused"
+)
+
+var _ unused.Snapshot = &Snapshot{}
+
+// Snapshot holds information about an AWS EBS snapshot.
+type Snapshot struct {
+	types.Snapshot
+	provider *Provider
+	meta     unused.Meta
+	orphaned bool
+}
+
+// ID returns the AWS EBS snapshot ID.
+func (s *Snapshot) ID() string { return *s.SnapshotId }
+
+// Provider returns a reference to the provider used to instantiate
+// this snapshot.
+func (s *Snapshot) Provider() unused.Provider { return s.provider }
+
+// Name returns the name of this AWS EBS snapshot as stored in its
+// Name tag, or its ID if it doesn't have one.
+func (s *Snapshot) Name() string {
+	for _, t := range s.Tags {
+		if *t.Key == "Name" {
+			return *t.Value
+		}
+	}
+	return s.ID()
+}
+
+// CreatedAt returns the time when the AWS EBS snapshot was started.
+func (s *Snapshot) CreatedAt() time.Time {
+	if s.StartTime == nil {
+		return time.Time{}
+	}
+	return *s.StartTime
+}
+
+// Meta returns the snapshot metadata.
+func (s *Snapshot) Meta() unused.Meta { return s.meta }
+
+// Kind returns [unused.KindSnapshot].
+func (s *Snapshot) Kind() unused.ResourceKind { return unused.KindSnapshot }
+
+// SourceDiskID returns the ID of the volume this snapshot was taken
+// from.
+func (s *Snapshot) SourceDiskID() string {
+	if s.VolumeId == nil || *s.VolumeId == copiedVolumeID {
+		return ""
+	}
+	return *s.VolumeId
+}
+
+// Orphaned returns true when the source volume no longer exists.
+func (s *Snapshot) Orphaned() bool { return s.orphaned }
+
+// SizeGB returns the size of the source volume in binary GB (aka GiB).
+func (s *Snapshot) SizeGB() int {
+	if s.VolumeSize == nil {
+		return 0
+	}
+	return int(*s.VolumeSize)
+}
@@ -0,0 +1,46 @@
+package azure
+
+import (
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v9"
+	"github.com/grafana/unused"
+)
+
+var _ unused.Address = &Address{}
+
+// Address holds information about an Azure public IP address.
+type Address struct {
+	*armnetwork.PublicIPAddress
+	provider *Provider
+	meta     unused.Meta
+}
+
+// ID returns the Azure public IP address ID.
+func (a *Address) ID() string { return *a.PublicIPAddress.ID }
+
+// Provider returns a reference to the provider used to instantiate
+// this address.
+func (a *Address) Provider() unused.Provider { return a.provider }
+
+// Name returns the name of this Azure public IP address.
+func (a *Address) Name() string { return *a.PublicIPAddress.Name }
+
+// Address returns the IP of this Azure public IP address, which
+// might be empty for dynamically allocated addresses.
+func (a *Address) Address() string {
+	if a.Properties == nil || a.Properties.IPAddress == nil {
+		return ""
+	}
+	return *a.Properties.IPAddress
+}
+
+// CreatedAt returns a zero [time.Time] value, as Azure does not
+// provide this information.
+func (a *Address) CreatedAt() time.Time { return time.Time{} }
+
+// Meta returns the address metadata.
+func (a *Address) Meta() unused.Meta { return a.meta }
+
+// Kind returns [unused.KindAddress].
+func (a *Address) Kind() unused.ResourceKind { return unused.KindAddress }
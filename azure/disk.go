@@ -41,6 +41,9 @@ func (d *Disk) CreatedAt() time.Time { return *d.Properties.TimeCreated }
 // Meta returns the disk metadata.
 func (d *Disk) Meta() unused.Meta { return d.meta }
 
+// Kind returns [unused.KindDisk].
+func (d *Disk) Kind() unused.ResourceKind { return unused.KindDisk }
+
 // LastUsedAt returns the time when the Azure compute disk was last
 // detached.
 func (d *Disk) LastUsedAt() time.Time {
@@ -52,16 +55,58 @@ func (d *Disk) LastUsedAt() time.Time {
 	return *d.Properties.LastOwnershipUpdateTime
 }
 
-// DiskType Type returns the type of this Azure compute disk.
-func (d *Disk) DiskType() unused.DiskType {
-	switch *d.SKU.Name {
+// DiskType returns the normalized type of this Azure compute disk.
+func (d *Disk) DiskType() unused.DiskType { return d.Class().Media }
+
+// Class returns the SKU of this Azure compute disk and its provisioned
+// IOPS and throughput, if any.
+func (d *Disk) Class() unused.DiskClass {
+	var c unused.DiskClass
+
+	if d.SKU != nil && d.SKU.Name != nil {
+		c.Type = string(*d.SKU.Name)
+	}
+
+	switch compute.DiskStorageAccountTypes(c.Type) {
 	case compute.DiskStorageAccountTypesStandardLRS:
-		return unused.HDD
+		c.Media = unused.HDD
 	case compute.DiskStorageAccountTypesPremiumLRS,
+		compute.DiskStorageAccountTypesPremiumV2LRS,
+		compute.DiskStorageAccountTypesPremiumZRS,
 		compute.DiskStorageAccountTypesStandardSSDLRS,
+		compute.DiskStorageAccountTypesStandardSSDZRS,
 		compute.DiskStorageAccountTypesUltraSSDLRS:
-		return unused.SSD
+		c.Media = unused.SSD
 	default:
-		return unused.Unknown
+		c.Media = unused.Unknown
+	}
+
+	if d.Properties != nil {
+		if d.Properties.DiskIOPSReadWrite != nil {
+			c.IOPS = *d.Properties.DiskIOPSReadWrite
+		}
+		if d.Properties.DiskMBpsReadWrite != nil {
+			c.Throughput = *d.Properties.DiskMBpsReadWrite
+		}
 	}
+
+	return c
+}
+
+// Location returns the region of this Azure compute disk and, for
+// zonal disks, its zone in the <region>-<zone> form.
+func (d *Disk) Location() unused.Location {
+	var l unused.Location
+	if d.Disk.Location != nil {
+		l.Region = *d.Disk.Location
+	}
+	if len(d.Zones) > 0 && d.Zones[0] != nil {
+		l.Zone = l.Region + "-" + *d.Zones[0]
+	}
+	return l
 }
+
+// Kubernetes returns the Kubernetes objects this Azure compute disk
+// was created for, as found in its tags. AKS doesn't tag disks with
+// their cluster name.
+func (d *Disk) Kubernetes() unused.Kubernetes { return d.meta.Kubernetes() }
@@ -77,3 +77,82 @@ func TestDisk(t *testing.T) {
 		}
 	})
 }
+
+func TestDiskLocation(t *testing.T) {
+	location, zone := "westeurope", "2"
+
+	tests := map[string]struct {
+		zones []*string
+		exp   unused.Location
+	}{
+		"regional": {nil, unused.Location{Region: "westeurope"}},
+		"zonal":    {[]*string{&zone}, unused.Location{Region: "westeurope", Zone: "westeurope-2"}},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			d := &Disk{&compute.Disk{Location: &location, Zones: tt.zones}, nil, nil}
+			if got := d.Location(); got != tt.exp {
+				t.Errorf("expecting Location() %v, got %v", tt.exp, got)
+			}
+		})
+	}
+}
+
+func TestDiskKubernetes(t *testing.T) {
+	d := &Disk{&compute.Disk{}, nil, unused.Meta{
+		"kubernetes.io-created-for-pvc-namespace": "ns",
+		"kubernetes.io-created-for-pvc-name":      "pvc",
+		"kubernetes.io-created-for-pv-name":       "pv",
+	}}
+
+	if exp, got := (unused.Kubernetes{Namespace: "ns", PVC: "pvc", PV: "pv"}), d.Kubernetes(); exp != got {
+		t.Errorf("expecting Kubernetes() %v, got %v", exp, got)
+	}
+}
+
+func TestDiskClass(t *testing.T) {
+	tests := map[compute.DiskStorageAccountTypes]unused.DiskType{
+		compute.DiskStorageAccountTypesStandardLRS:    unused.HDD,
+		compute.DiskStorageAccountTypesPremiumV2LRS:   unused.SSD,
+		compute.DiskStorageAccountTypesPremiumZRS:     unused.SSD,
+		compute.DiskStorageAccountTypesStandardSSDZRS: unused.SSD,
+		"Foo_LRS": unused.Unknown,
+	}
+
+	iops, mbps := int64(5000), int64(200)
+
+	for sku, media := range tests {
+		t.Run(string(sku), func(t *testing.T) {
+			d := &Disk{&compute.Disk{
+				SKU:        &compute.DiskSKU{Name: &sku},
+				Properties: &compute.DiskProperties{DiskIOPSReadWrite: &iops, DiskMBpsReadWrite: &mbps},
+			}, nil, nil}
+
+			exp := unused.DiskClass{Type: string(sku), Media: media, IOPS: iops, Throughput: mbps}
+			if got := d.Class(); got != exp {
+				t.Errorf("expecting Class() %v, got %v", exp, got)
+			}
+		})
+	}
+}
+
+func TestSnapshotName(t *testing.T) {
+	ts := time.Date(2021, 7, 16, 5, 55, 0, 0, time.UTC)
+
+	tests := map[string]string{
+		"my-disk": "my-disk-20210716055500",
+		"pvc-c898536e-1601-4357-af13-01bbe82f3055-with-a-very-long-name-x-disk":  "pvc-c898536e-1601-4357-af13-01bbe82f3055-with-a-very-long-name-x-20210716055500",
+		"pvc-c898536e-1601-4357-af13-01bbe82f3055-with-a-very-long-name-xb-disk": "pvc-c898536e-1601-4357-af13-01bbe82f3055-with-a-very-long-name-xb-20210716055500",
+	}
+
+	for disk, exp := range tests {
+		got := snapshotName(disk, ts)
+		if exp != got {
+			t.Errorf("expecting snapshotName(%q) = %q, got %q", disk, exp, got)
+		}
+		if len(got) > 80 {
+			t.Errorf("snapshot name %q is longer than 80 characters", got)
+		}
+	}
+}
@@ -5,21 +5,32 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v8"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v9"
 	"github.com/grafana/unused"
 )
 
 var ProviderName = "Azure"
 
-var _ unused.Provider = &Provider{}
+var (
+	_ unused.Provider         = &Provider{}
+	_ unused.Snapshotter      = &Provider{}
+	_ unused.AddressProvider  = &Provider{}
+	_ unused.SnapshotProvider = &Provider{}
+	_ unused.Tagger           = &Provider{}
+)
 
 const ResourceGroupMetaKey = "resource-group"
 
 // Provider implements [unused.Provider] for Azure.
 type Provider struct {
-	client *compute.DisksClient
-	meta   unused.Meta
+	client    *compute.DisksClient
+	snapshots *compute.SnapshotsClient
+	addresses *armnetwork.PublicIPAddressesClient
+	meta      unused.Meta
 }
 
 // Name returns Azure.
@@ -33,11 +44,34 @@ func (p *Provider) ID() string { return p.meta["SubscriptionID"] }
 
 var ErrInvalidSubscriptionID = errors.New("invalid subscription ID in metadata")
 
+// ErrMissingSnapshotsClient is returned when trying to snapshot a disk
+// or list snapshots from a provider created without a snapshots client.
+var ErrMissingSnapshotsClient = errors.New("missing Azure snapshots client")
+
+// ErrMissingPublicIPAddressesClient is returned when trying to list or
+// delete public IP addresses from a provider created without a public
+// IP addresses client.
+var ErrMissingPublicIPAddressesClient = errors.New("missing Azure public IP addresses client")
+
+// Option configures optional features of the Azure provider.
+type Option func(*Provider)
+
+// WithSnapshotsClient sets the client used to take snapshots of disks.
+func WithSnapshotsClient(c *compute.SnapshotsClient) Option {
+	return func(p *Provider) { p.snapshots = c }
+}
+
+// WithPublicIPAddressesClient sets the client used to list and delete
+// public IP addresses.
+func WithPublicIPAddressesClient(c *armnetwork.PublicIPAddressesClient) Option {
+	return func(p *Provider) { p.addresses = c }
+}
+
 // NewProvider creates a new Azure [unused.Provider].
 //
 // A valid Azure compute disks client must be supplied in order to
 // list the unused resources.
-func NewProvider(client *compute.DisksClient, meta unused.Meta) (*Provider, error) {
+func NewProvider(client *compute.DisksClient, meta unused.Meta, opts ...Option) (*Provider, error) {
 	if meta == nil {
 		meta = make(unused.Meta)
 	}
@@ -45,7 +79,12 @@ func NewProvider(client *compute.DisksClient, meta unused.Meta) (*Provider, erro
 		return nil, ErrInvalidSubscriptionID
 	}
 
-	return &Provider{client: client, meta: meta}, nil
+	p := &Provider{client: client, meta: meta}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p, nil
 }
 
 // ListUnusedDisks returns all the Azure compute disks that are not
@@ -55,15 +94,13 @@ func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
 
 	pages := p.client.NewListPager(&compute.DisksClientListOptions{})
 
-	prefix := fmt.Sprintf("/subscriptions/%s/resourceGroups/", p.meta["SubscriptionID"])
-
 	for pages.More() {
 		page, err := pages.NextPage(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("listing Azure disks: %w", err)
 		}
 		for _, d := range page.Value {
-			if d.ManagedBy != nil {
+			if attachments(d) > 0 {
 				continue
 			}
 
@@ -73,10 +110,11 @@ func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
 				m[k] = *v
 			}
 
-			// Azure doesn't return the resource group directly
-			// "/subscriptions/$subscription-id/resourceGroups/$resource-group-name/providers/Microsoft.Compute/disks/$disk-name"
-			rg := strings.TrimPrefix(*d.ID, prefix)
-			m[ResourceGroupMetaKey] = rg[:strings.IndexRune(rg, '/')]
+			if d.Properties != nil && d.Properties.MaxShares != nil && *d.Properties.MaxShares > 1 {
+				m[unused.SharingMetaKey] = SharedDisk
+			}
+
+			m[ResourceGroupMetaKey] = p.resourceGroup(*d.ID)
 
 			upds = append(upds, &Disk{d, p, m})
 		}
@@ -85,6 +123,62 @@ func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
 	return upds, nil
 }
 
+// SharedDisk is the sharing mode of Azure shared disks, which can be
+// attached to as many VMs as their maxShares property.
+const SharedDisk = "shared"
+
+// attachments returns the number of VMs the disk is attached to. Shared
+// disks report all of them in ManagedByExtended, while ManagedBy only
+// holds one of them.
+func attachments(d *compute.Disk) int {
+	n := len(d.ManagedByExtended)
+	if n == 0 && d.ManagedBy != nil && *d.ManagedBy != "" {
+		n = 1
+	}
+	return n
+}
+
+// resourceGroup returns the resource group from the given resource ID,
+// as Azure doesn't return the resource group directly:
+// "/subscriptions/$subscription-id/resourceGroups/$resource-group-name/providers/Microsoft.Compute/disks/$disk-name"
+func (p *Provider) resourceGroup(id string) string {
+	prefix := fmt.Sprintf("/subscriptions/%s/resourceGroups/", p.meta["SubscriptionID"])
+	rg := strings.TrimPrefix(id, prefix)
+	return rg[:strings.IndexRune(rg, '/')]
+}
+
+// TagDisk sets the given tags on the disk, keeping any other existing
+// tags.
+func (p *Provider) TagDisk(ctx context.Context, disk unused.Disk, tags map[string]string) error {
+	rg := disk.Meta()[ResourceGroupMetaKey]
+
+	// updating tags replaces them as a whole, so fetch the current
+	// ones to keep them
+	res, err := p.client.Get(ctx, rg, disk.Name(), nil)
+	if err != nil {
+		return fmt.Errorf("cannot get Azure disk tags: %w", err)
+	}
+
+	ts := make(map[string]*string, len(res.Tags)+len(tags))
+	for k, v := range res.Tags {
+		ts[k] = v
+	}
+	for k, v := range tags {
+		ts[k] = to.Ptr(v)
+	}
+
+	poller, err := p.client.BeginUpdate(ctx, rg, disk.Name(), compute.DiskUpdate{Tags: ts}, nil)
+	if err != nil {
+		return fmt.Errorf("cannot tag Azure disk: failed to finish request: %w", err)
+	}
+
+	if _, err := poller.PollUntilDone(ctx, nil); err != nil {
+		return fmt.Errorf("cannot tag Azure disk: %w", err)
+	}
+
+	return nil
+}
+
 // Delete deletes the given disk from Azure.
 func (p *Provider) Delete(ctx context.Context, disk unused.Disk) error {
 	poller, err := p.client.BeginDelete(ctx, disk.Meta()[ResourceGroupMetaKey], disk.Name(), nil)
@@ -98,3 +192,182 @@ func (p *Provider) Delete(ctx context.Context, disk unused.Disk) error {
 
 	return nil
 }
+
+// Snapshot creates a full snapshot of the given disk in the same
+// resource group and waits until it's created, returning the
+// snapshot ID.
+func (p *Provider) Snapshot(ctx context.Context, disk unused.Disk) (string, error) {
+	if p.snapshots == nil {
+		return "", ErrMissingSnapshotsClient
+	}
+
+	// the location metadata can be overridden by a disk tag, so the
+	// location of the disk itself is used instead
+	snap := compute.Snapshot{
+		Location: to.Ptr(disk.Location().Region),
+		Properties: &compute.SnapshotProperties{
+			CreationData: &compute.CreationData{
+				CreateOption:     to.Ptr(compute.DiskCreateOptionCopy),
+				SourceResourceID: to.Ptr(disk.ID()),
+			},
+		},
+	}
+
+	name := snapshotName(disk.Name(), time.Now())
+
+	poller, err := p.snapshots.BeginCreateOrUpdate(ctx, disk.Meta()[ResourceGroupMetaKey], name, snap, nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot create Azure snapshot: failed to finish request: %w", err)
+	}
+
+	res, err := poller.PollUntilDone(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot create Azure snapshot: %w", err)
+	}
+
+	return *res.ID, nil
+}
+
+// snapshotName returns a valid Azure resource name for a snapshot of
+// the given disk, by appending a timestamp to the disk name and
+// making sure it doesn't exceed 80 characters.
+func snapshotName(disk string, t time.Time) string {
+	const maxLen = 80
+
+	suffix := "-" + t.UTC().Format("20060102150405")
+	if len(disk)+len(suffix) > maxLen {
+		disk = strings.TrimRight(disk[:maxLen-len(suffix)], "-")
+	}
+
+	return disk + suffix
+}
+
+// ListUnusedAddresses returns all the Azure public IP addresses that
+// aren't associated to any IP configuration or NAT gateway.
+func (p *Provider) ListUnusedAddresses(ctx context.Context) (unused.Addresses, error) {
+	if p.addresses == nil {
+		return nil, ErrMissingPublicIPAddressesClient
+	}
+
+	var addrs unused.Addresses
+
+	pages := p.addresses.NewListAllPager(nil)
+
+	for pages.More() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing Azure public IP addresses: %w", err)
+		}
+		for _, a := range page.Value {
+			if a.Properties != nil && (a.Properties.IPConfiguration != nil || a.Properties.NatGateway != nil) {
+				continue
+			}
+
+			m := make(unused.Meta, len(a.Tags)+3)
+			m["location"] = *a.Location
+			for k, v := range a.Tags {
+				m[k] = *v
+			}
+			if a.SKU != nil && a.SKU.Name != nil {
+				m["sku"] = string(*a.SKU.Name)
+			}
+			m[ResourceGroupMetaKey] = p.resourceGroup(*a.ID)
+
+			addrs = append(addrs, &Address{a, p, m})
+		}
+	}
+
+	return addrs, nil
+}
+
+// DeleteAddress deletes the given public IP address from Azure.
+func (p *Provider) DeleteAddress(ctx context.Context, addr unused.Address) error {
+	if p.addresses == nil {
+		return ErrMissingPublicIPAddressesClient
+	}
+
+	poller, err := p.addresses.BeginDelete(ctx, addr.Meta()[ResourceGroupMetaKey], addr.Name(), nil)
+	if err != nil {
+		return fmt.Errorf("cannot delete Azure public IP address: failed to finish request: %w", err)
+	}
+
+	if _, err := poller.PollUntilDone(ctx, nil); err != nil {
+		return fmt.Errorf("cannot delete Azure public IP address: %w", err)
+	}
+
+	return nil
+}
+
+// ListUnusedSnapshots returns the Azure compute snapshots whose source
+// disk no longer exists, or which are older than the given retention.
+func (p *Provider) ListUnusedSnapshots(ctx context.Context, retention time.Duration) (unused.Snapshots, error) {
+	if p.snapshots == nil {
+		return nil, ErrMissingSnapshotsClient
+	}
+
+	// Azure resource IDs are case insensitive
+	disks := make(map[string]struct{})
+
+	dpages := p.client.NewListPager(&compute.DisksClientListOptions{})
+	for dpages.More() {
+		page, err := dpages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing Azure disks: %w", err)
+		}
+		for _, d := range page.Value {
+			disks[strings.ToLower(*d.ID)] = struct{}{}
+		}
+	}
+
+	var snaps unused.Snapshots
+
+	spages := p.snapshots.NewListPager(nil)
+	for spages.More() {
+		page, err := spages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing Azure snapshots: %w", err)
+		}
+		for _, s := range page.Value {
+			snap := &Snapshot{Snapshot: s, provider: p}
+
+			if id := snap.SourceDiskID(); id != "" {
+				_, exists := disks[strings.ToLower(id)]
+				snap.orphaned = !exists
+			}
+
+			if !unused.IsUnusedSnapshot(snap, retention) {
+				continue
+			}
+
+			m := make(unused.Meta, len(s.Tags)+2)
+			m["location"] = *s.Location
+			for k, v := range s.Tags {
+				m[k] = *v
+			}
+			m[ResourceGroupMetaKey] = p.resourceGroup(*s.ID)
+			snap.meta = m
+
+			snaps = append(snaps, snap)
+		}
+	}
+
+	return snaps, nil
+}
+
+// DeleteSnapshot deletes the given snapshot from Azure.
+func (p *Provider) DeleteSnapshot(ctx context.Context, snap unused.Snapshot) error {
+	if p.snapshots == nil {
+		return ErrMissingSnapshotsClient
+	}
+
+	poller, err := p.snapshots.BeginDelete(ctx, snap.Meta()[ResourceGroupMetaKey], snap.Name(), nil)
+	if err != nil {
+		return fmt.Errorf("cannot delete Azure snapshot: failed to finish request: %w", err)
+	}
+
+	if _, err := poller.PollUntilDone(ctx, nil); err != nil {
+		return fmt.Errorf("cannot delete Azure snapshot: %w", err)
+	}
+
+	return nil
+}
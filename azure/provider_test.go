@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
 	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v8"
 	"github.com/google/uuid"
 	"github.com/grafana/unused"
@@ -107,3 +108,51 @@ func TestListUnusedDisks(t *testing.T) {
 		t.Fatalf("metadata doesn't match: %v", err)
 	}
 }
+
+func TestListUnusedDisksShared(t *testing.T) {
+	ctx := context.Background()
+
+	disk := func(name, extra string) string {
+		return `{"name": "` + name + `", "location": "westeurope", "id": "/subscriptions/my-subscription/resourceGroups/my-rg/providers/Microsoft.Compute/disks/` + name + `"` + extra + `}`
+	}
+
+	opts := clientOptions(transport{
+		"/subscriptions/my-subscription/providers/Microsoft.Compute/disks": `{"value": [` +
+			disk("unused", `, "properties": {}`) + `,` +
+			disk("attached", `, "managedBy": "/subscriptions/my-subscription/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/vm-1", "properties": {}`) + `,` +
+			disk("shared-attached", `, "managedByExtended": ["/subscriptions/my-subscription/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/vm-1", "/subscriptions/my-subscription/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/vm-2"], "properties": {"maxShares": 2}`) + `,` +
+			disk("shared-unused", `, "properties": {"maxShares": 3}`) +
+			`]}`,
+	})
+
+	c, err := compute.NewDisksClient("my-subscription", &azfake.TokenCredential{}, opts)
+	if err != nil {
+		t.Fatalf("cannot create disks client: %v", err)
+	}
+	p, err := azure.NewProvider(c, unused.Meta{"SubscriptionID": "my-subscription"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	disks, err := p.ListUnusedDisks(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := map[string]unused.Meta{
+		"unused": {"location": "westeurope", azure.ResourceGroupMetaKey: "my-rg"},
+		"shared-unused": {
+			"location":                 "westeurope",
+			azure.ResourceGroupMetaKey: "my-rg",
+			unused.SharingMetaKey:      azure.SharedDisk,
+		},
+	}
+	if len(disks) != len(exp) {
+		t.Fatalf("expecting %d unused disks, got %d", len(exp), len(disks))
+	}
+	for _, d := range disks {
+		if err := unusedtest.AssertEqualMeta(exp[d.Name()], d.Meta()); err != nil {
+			t.Errorf("disk %s metadata doesn't match: %v", d.Name(), err)
+		}
+	}
+}
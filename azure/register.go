@@ -0,0 +1,137 @@
+package azure
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v8"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/managementgroups/armmanagementgroups"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v9"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/grafana/unused"
+)
+
+func init() {
+	unused.Register(unused.Registration{
+		Name:  ProviderName,
+		Flags: flags,
+		Match: func(p unused.Provider) bool {
+			_, ok := unused.As[*Provider](p)
+			return ok
+		},
+		DiscoveryFlags: []string{"azure.all-subs", "azure.tenant", "azure.management-group"},
+	})
+}
+
+func flags(fs *flag.FlagSet) unused.CreateFunc {
+	var (
+		subs    []string
+		allSubs bool
+		tenants []string
+		groups  []string
+	)
+
+	fs.Func("azure.sub", "Azure subscription (can be specified multiple times)", func(v string) error {
+		subs = append(subs, v)
+		return nil
+	})
+	fs.StringVar(&ProviderName, "azure.providername", ProviderName, `Azure provider name to use, default: "Azure" (e.g. "AKS")`)
+	fs.BoolVar(&allSubs, "azure.all-subs", false, "List disks in all the Azure subscriptions visible to the credential")
+	fs.Func("azure.tenant", "Only list disks in the discovered Azure subscriptions of this tenant; implies -azure.all-subs (can be specified multiple times)", func(v string) error {
+		tenants = append(tenants, v)
+		return nil
+	})
+	fs.Func("azure.management-group", "Only list disks in the discovered Azure subscriptions under this management group ID; implies -azure.all-subs (can be specified multiple times)", func(v string) error {
+		groups = append(groups, v)
+		return nil
+	})
+
+	return func(ctx context.Context, logger *slog.Logger) ([]unused.Provider, error) {
+		discover := allSubs || len(tenants) > 0 || len(groups) > 0
+		if len(subs) == 0 && !discover {
+			return nil, nil
+		}
+
+		tc, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, fmt.Errorf("fetching default Azure credential: %w", err)
+		}
+
+		providers := make([]unused.Provider, 0, len(subs))
+		seen := make(map[string]bool)
+
+		newProvider := func(sub string, meta unused.Meta) error {
+			c, err := compute.NewDisksClient(sub, tc, nil)
+			if err != nil {
+				return fmt.Errorf("creating Azure disks client: %w", err)
+			}
+
+			sc, err := compute.NewSnapshotsClient(sub, tc, nil)
+			if err != nil {
+				return fmt.Errorf("creating Azure snapshots client: %w", err)
+			}
+
+			ac, err := armnetwork.NewPublicIPAddressesClient(sub, tc, nil)
+			if err != nil {
+				return fmt.Errorf("creating Azure public IP addresses client: %w", err)
+			}
+
+			p, err := NewProvider(c, meta, WithSnapshotsClient(sc), WithPublicIPAddressesClient(ac))
+			if err != nil {
+				return fmt.Errorf("creating Azure provider for subscription %s: %w", sub, err)
+			}
+			providers = append(providers, p)
+			seen[sub] = true
+			return nil
+		}
+
+		for _, sub := range subs {
+			if err := newProvider(sub, map[string]string{"SubscriptionID": sub}); err != nil {
+				return nil, err
+			}
+		}
+
+		if !discover {
+			return providers, nil
+		}
+
+		var groupSubs []map[string]bool
+		if len(groups) > 0 {
+			mc, err := armmanagementgroups.NewClient(tc, nil)
+			if err != nil {
+				return nil, fmt.Errorf("creating Azure management groups client: %w", err)
+			}
+			for _, g := range groups {
+				ids, err := ManagementGroupSubscriptions(ctx, mc, g)
+				if err != nil {
+					return nil, err
+				}
+				groupSubs = append(groupSubs, ids)
+			}
+		}
+
+		sc, err := armsubscriptions.NewClient(tc, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating Azure subscriptions client: %w", err)
+		}
+
+		discovered, err := ListSubscriptions(ctx, sc, SubscriptionMatcher(tenants, groupSubs))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range discovered {
+			if seen[s.ID] {
+				continue
+			}
+			if err := newProvider(s.ID, s.Meta()); err != nil {
+				return nil, err
+			}
+		}
+
+		return providers, nil
+	}
+}
@@ -0,0 +1,64 @@
+package azure
+
+import (
+	"time"
+
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v8"
+	"github.com/grafana/unused"
+)
+
+var _ unused.Snapshot = &Snapshot{}
+
+// Snapshot holds information about an Azure compute snapshot.
+type Snapshot struct {
+	*compute.Snapshot
+	provider *Provider
+	meta     unused.Meta
+	orphaned bool
+}
+
+// ID returns the Azure compute snapshot ID.
+func (s *Snapshot) ID() string { return *s.Snapshot.ID }
+
+// Provider returns a reference to the provider used to instantiate
+// this snapshot.
+func (s *Snapshot) Provider() unused.Provider { return s.provider }
+
+// Name returns the name of this Azure compute snapshot.
+func (s *Snapshot) Name() string { return *s.Snapshot.Name }
+
+// CreatedAt returns the time when this Azure compute snapshot was
+// created.
+func (s *Snapshot) CreatedAt() time.Time {
+	if s.Properties == nil || s.Properties.TimeCreated == nil {
+		return time.Time{}
+	}
+	return *s.Properties.TimeCreated
+}
+
+// Meta returns the snapshot metadata.
+func (s *Snapshot) Meta() unused.Meta { return s.meta }
+
+// Kind returns [unused.KindSnapshot].
+func (s *Snapshot) Kind() unused.ResourceKind { return unused.KindSnapshot }
+
+// SourceDiskID returns the ID of the resource this snapshot was
+// created from.
+func (s *Snapshot) SourceDiskID() string {
+	if s.Properties == nil || s.Properties.CreationData == nil || s.Properties.CreationData.SourceResourceID == nil {
+		return ""
+	}
+	return *s.Properties.CreationData.SourceResourceID
+}
+
+// Orphaned returns true when the source disk no longer exists.
+func (s *Snapshot) Orphaned() bool { return s.orphaned }
+
+// SizeGB returns the size of this Azure compute snapshot in binary GB
+// (aka GiB).
+func (s *Snapshot) SizeGB() int {
+	if s.Properties == nil || s.Properties.DiskSizeGB == nil {
+		return 0
+	}
+	return int(*s.Properties.DiskSizeGB)
+}
@@ -0,0 +1,115 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/managementgroups/armmanagementgroups"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/grafana/unused"
+)
+
+// Metadata keys of providers created for discovered subscriptions.
+const (
+	SubscriptionNameMetaKey = "subscription-name"
+	TenantIDMetaKey         = "tenant-id"
+)
+
+// Subscription is an Azure subscription visible to a credential.
+type Subscription struct {
+	ID       string
+	Name     string
+	TenantID string
+}
+
+// Meta returns the provider metadata identifying the subscription.
+func (s Subscription) Meta() unused.Meta {
+	return unused.Meta{
+		"SubscriptionID":        s.ID,
+		SubscriptionNameMetaKey: s.Name,
+		TenantIDMetaKey:         s.TenantID,
+	}
+}
+
+// ListSubscriptions returns the subscriptions visible to the credential
+// of the client for which match returns true, skipping the disabled
+// and deleted ones. A nil match returns all of them.
+func ListSubscriptions(ctx context.Context, client *armsubscriptions.Client, match func(Subscription) bool) ([]Subscription, error) {
+	var subs []Subscription
+
+	pager := client.NewListPager(nil)
+	for pager.More() {
+		res, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list Azure subscriptions: %w", err)
+		}
+
+		for _, s := range res.Value {
+			if state := value(s.State); state == armsubscriptions.SubscriptionStateDisabled || state == armsubscriptions.SubscriptionStateDeleted {
+				continue
+			}
+
+			sub := Subscription{ID: value(s.SubscriptionID), Name: value(s.DisplayName), TenantID: value(s.TenantID)}
+			if match == nil || match(sub) {
+				subs = append(subs, sub)
+			}
+		}
+	}
+
+	slices.SortFunc(subs, func(a, b Subscription) int { return strings.Compare(a.ID, b.ID) })
+
+	return subs, nil
+}
+
+// ManagementGroupSubscriptions returns the IDs of the subscriptions in
+// the management group with the given ID and all its descendant
+// groups.
+func ManagementGroupSubscriptions(ctx context.Context, client *armmanagementgroups.Client, group string) (map[string]bool, error) {
+	ids := make(map[string]bool)
+
+	pager := client.NewGetDescendantsPager(group, nil)
+	for pager.More() {
+		res, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list descendants of Azure management group %s: %w", group, err)
+		}
+
+		for _, d := range res.Value {
+			// subscriptions have the /subscriptions type and are named
+			// by their ID
+			if strings.HasSuffix(value(d.Type), "/subscriptions") {
+				ids[value(d.Name)] = true
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// SubscriptionMatcher returns a function matching the subscriptions in
+// any of the given tenants and any of the given subscription ID sets,
+// like the ones returned by [ManagementGroupSubscriptions]. Empty
+// tenants or sets match every subscription.
+func SubscriptionMatcher(tenants []string, groups []map[string]bool) func(Subscription) bool {
+	return func(s Subscription) bool {
+		if len(tenants) > 0 && !slices.ContainsFunc(tenants, func(t string) bool { return strings.EqualFold(t, s.TenantID) }) {
+			return false
+		}
+		if len(groups) == 0 {
+			return true
+		}
+		return slices.ContainsFunc(groups, func(ids map[string]bool) bool { return ids[s.ID] })
+	}
+}
+
+// value returns the value pointed to by p, or the zero value if p is
+// nil.
+func value[T any](p *T) T {
+	var v T
+	if p != nil {
+		v = *p
+	}
+	return v
+}
@@ -0,0 +1,106 @@
+package azure_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/managementgroups/armmanagementgroups"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/grafana/unused/azure"
+)
+
+// transport answers Azure requests with the JSON body for their path.
+type transport map[string]string
+
+func (t transport) Do(req *http.Request) (*http.Response, error) {
+	body, ok := t[req.URL.Path]
+	status := http.StatusOK
+	if !ok {
+		status, body = http.StatusNotFound, `{"error":{"code":"NotFound"}}`
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func clientOptions(t transport) *arm.ClientOptions {
+	return &arm.ClientOptions{ClientOptions: policy.ClientOptions{Transport: t}}
+}
+
+func TestListSubscriptions(t *testing.T) {
+	ctx := context.Background()
+
+	opts := clientOptions(transport{
+		"/subscriptions": `{"value": [
+  {"subscriptionId": "sub-2", "displayName": "Production", "tenantId": "tenant-a", "state": "Enabled"},
+  {"subscriptionId": "sub-1", "displayName": "Development", "tenantId": "tenant-a", "state": "Warned"},
+  {"subscriptionId": "sub-3", "displayName": "Old", "tenantId": "tenant-a", "state": "Disabled"},
+  {"subscriptionId": "sub-4", "displayName": "Other", "tenantId": "tenant-b", "state": "Enabled"}
+]}`,
+		"/providers/Microsoft.Management/managementGroups/platform/descendants": `{"value": [
+  {"id": "/providers/Microsoft.Management/managementGroups/child", "name": "child", "type": "Microsoft.Management/managementGroups"},
+  {"id": "/subscriptions/sub-2", "name": "sub-2", "type": "/subscriptions"},
+  {"id": "/subscriptions/sub-4", "name": "sub-4", "type": "/subscriptions"}
+]}`,
+	})
+
+	sc, err := armsubscriptions.NewClient(&azfake.TokenCredential{}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	subs, err := azure.ListSubscriptions(ctx, sc, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := []azure.Subscription{
+		{ID: "sub-1", Name: "Development", TenantID: "tenant-a"},
+		{ID: "sub-2", Name: "Production", TenantID: "tenant-a"},
+		{ID: "sub-4", Name: "Other", TenantID: "tenant-b"},
+	}
+	if !slices.Equal(exp, subs) {
+		t.Errorf("expecting subscriptions %v, got %v", exp, subs)
+	}
+
+	mc, err := armmanagementgroups.NewClient(&azfake.TokenCredential{}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ids, err := azure.ManagementGroupSubscriptions(ctx, mc, "platform")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 2 || !ids["sub-2"] || !ids["sub-4"] {
+		t.Errorf("expecting subscriptions sub-2 and sub-4 in management group, got %v", ids)
+	}
+
+	subs, err = azure.ListSubscriptions(ctx, sc, azure.SubscriptionMatcher([]string{"TENANT-A"}, []map[string]bool{ids}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp := exp[1:2]; !slices.Equal(exp, subs) {
+		t.Errorf("expecting subscriptions %v, got %v", exp, subs)
+	}
+
+	if exp, got := "Production", subs[0].Meta()[azure.SubscriptionNameMetaKey]; exp != got {
+		t.Errorf("expecting subscription name %q, got %q", exp, got)
+	}
+
+	t.Run("error", func(t *testing.T) {
+		if _, err := azure.ManagementGroupSubscriptions(ctx, mc, "missing"); err == nil {
+			t.Error("expecting error for missing management group")
+		}
+	})
+}
@@ -3,9 +3,9 @@ package internal
 import (
 	"errors"
 	"fmt"
-	"strconv"
-	"strings"
 	"time"
+
+	"github.com/grafana/unused/filter"
 )
 
 func Age(date time.Time) string {
@@ -30,39 +30,12 @@ func Age(date time.Time) string {
 
 var ErrInvalidAge = errors.New("invalid age")
 
+// ParseAge parses an age like 1y30d or 36h, with the same syntax as
+// the durations in filter expressions.
 func ParseAge(s string) (time.Duration, error) {
-	if s == "" {
-		return 0, ErrInvalidAge
-	}
-
-	var age time.Duration
-
-	for _, ud := range []struct {
-		u string
-		d time.Duration
-	}{
-		{"y", 365 * 24 * time.Hour},
-		{"d", 24 * time.Hour},
-	} {
-		before, after, found := strings.Cut(s, ud.u)
-		if found {
-			n, err := strconv.Atoi(before)
-			if err != nil {
-				return 0, fmt.Errorf("%w: parsing %s: %w", ErrInvalidAge, ud.u, err)
-			}
-			age += time.Duration(n) * ud.d
-			s = after
-		}
+	age, err := filter.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidAge, err)
 	}
-
-	if s != "" {
-		dur, err := time.ParseDuration(s)
-		if err != nil {
-			return 0, fmt.Errorf("%w: %w", ErrInvalidAge, err)
-		}
-
-		age += dur
-	}
-
 	return age, nil
 }
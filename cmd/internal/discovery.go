@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/grafana/unused"
+)
+
+// Discoverer creates the configured providers repeatedly, wrapping all
+// of them with the same middlewares, Kubernetes resolver and state
+// store. This allows refreshing the providers discovered in cloud
+// organizations without registering their metrics again.
+type Discoverer struct {
+	logger *slog.Logger
+	create []unused.CreateFunc
+	mws    []unused.Middleware
+}
+
+// NewDiscoverer returns a [Discoverer] of the providers created by the
+// given functions, wrapped like [CreateProviders], [WrapKubernetes]
+// and [WrapState] would.
+func NewDiscoverer(logger *slog.Logger, create []unused.CreateFunc, mw MiddlewareConfig, kubeconfig string, contexts []string, statePath string) (*Discoverer, error) {
+	var mws []unused.Middleware
+
+	sm, err := stateMiddleware(statePath)
+	if err != nil {
+		return nil, err
+	}
+	if sm != nil {
+		mws = append(mws, sm)
+	}
+
+	km, err := kubernetesMiddleware(kubeconfig, contexts)
+	if err != nil {
+		return nil, err
+	}
+	if km != nil {
+		mws = append(mws, km)
+	}
+
+	ms, err := mw.middlewares()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Discoverer{logger, create, append(mws, ms...)}, nil
+}
+
+// Discover creates the configured providers and wraps them.
+func (d *Discoverer) Discover(ctx context.Context) ([]unused.Provider, error) {
+	var providers []unused.Provider
+
+	for _, fn := range d.create {
+		ps, err := fn(ctx, d.logger)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, ps...)
+	}
+
+	if len(providers) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	for i, p := range providers {
+		providers[i] = unused.Chain(p, d.mws...)
+	}
+
+	return providers, nil
+}
@@ -0,0 +1,53 @@
+package internal_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/cmd/internal"
+	"github.com/grafana/unused/state"
+	"github.com/grafana/unused/unusedtest"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestDiscoverer(t *testing.T) {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var calls int
+	create := func(context.Context, *slog.Logger) ([]unused.Provider, error) {
+		calls++
+		return []unused.Provider{unusedtest.NewProvider("my-provider", nil)}, nil
+	}
+
+	mw := internal.MiddlewareConfig{CacheTTL: time.Minute, Registerer: prometheus.NewRegistry()}
+	d, err := internal.NewDiscoverer(l, []unused.CreateFunc{create}, mw, "", nil, filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// discovering again must not register the provider metrics again
+	for i := 0; i < 2; i++ {
+		ps, err := d.Discover(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(ps) != 1 {
+			t.Fatalf("expecting 1 provider, got %d", len(ps))
+		}
+		if _, ok := ps[0].(*state.Provider); !ok {
+			t.Errorf("expecting the state provider to be outermost, got %T", ps[0])
+		}
+		if _, ok := unused.As[*unused.InstrumentedProvider](ps[0]); !ok {
+			t.Error("expecting an instrumented provider to be wrapped")
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("expecting providers to be created twice, got %d", calls)
+	}
+}
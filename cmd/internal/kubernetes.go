@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/k8s"
+)
+
+// KubernetesFlags adds the flags to cross-check disks against live
+// Kubernetes clusters to the given flag set.
+func KubernetesFlags(fs *flag.FlagSet, contexts *StringSliceFlag, kubeconfig *string) {
+	fs.Var(contexts, "k8s.context", "Kubernetes context to cross-check disks PV and PVC state against (can be specified multiple times)")
+	fs.StringVar(kubeconfig, "k8s.kubeconfig", "", "Path to the kubeconfig file; defaults to KUBECONFIG or ~/.kube/config")
+}
+
+// WrapKubernetes wraps the given providers so that the disks they
+// list are annotated with the state of their PV and PVC in the given
+// Kubernetes contexts. Providers are returned unchanged when no
+// contexts are given.
+func WrapKubernetes(providers []unused.Provider, kubeconfig string, contexts []string) ([]unused.Provider, error) {
+	mw, err := kubernetesMiddleware(kubeconfig, contexts)
+	if err != nil || mw == nil {
+		return providers, err
+	}
+
+	ps := make([]unused.Provider, len(providers))
+	for i, p := range providers {
+		ps[i] = mw(p)
+	}
+
+	return ps, nil
+}
+
+// kubernetesMiddleware returns the middleware applied by
+// [WrapKubernetes], or nil when no contexts are given.
+func kubernetesMiddleware(kubeconfig string, contexts []string) (unused.Middleware, error) {
+	if len(contexts) == 0 {
+		return nil, nil
+	}
+
+	r, err := k8s.NewResolverFromKubeconfig(kubeconfig, contexts)
+	if err != nil {
+		return nil, fmt.Errorf("creating Kubernetes resolver: %w", err)
+	}
+
+	return func(p unused.Provider) unused.Provider { return k8s.WrapProvider(p, r) }, nil
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/deleter"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MiddlewareConfig configures the middlewares applied to each provider
+// by [CreateProviders]. The zero value applies none.
+type MiddlewareConfig struct {
+	// Retries is how many times failed listings and deletions are
+	// retried.
+	Retries int
+
+	// CacheTTL is how long listed disks are kept.
+	CacheTTL time.Duration
+
+	// Registerer enables recording provider calls as Prometheus
+	// metrics when set.
+	Registerer prometheus.Registerer
+}
+
+// MiddlewareFlags adds the provider middleware flags to the given flag
+// set.
+func MiddlewareFlags(fs *flag.FlagSet, cfg *MiddlewareConfig) {
+	fs.IntVar(&cfg.Retries, "provider.retries", 0, "Number of times throttled or failed provider API calls to list and delete disks are retried")
+	fs.DurationVar(&cfg.CacheTTL, "provider.cache-ttl", 0, "How long to keep the unused disks listed by each provider; 0 disables caching")
+}
+
+// middlewares returns the middlewares for the configuration, with
+// caching outermost so that cache hits are neither retried nor
+// recorded.
+func (c MiddlewareConfig) middlewares() ([]unused.Middleware, error) {
+	var mws []unused.Middleware
+
+	if c.CacheTTL > 0 {
+		mws = append(mws, unused.Caching(c.CacheTTL))
+	}
+
+	if c.Retries > 0 {
+		mws = append(mws, unused.Retrying(c.Retries, deleter.DefaultMinBackoff, deleter.DefaultMaxBackoff, deleter.Retryable))
+	}
+
+	if c.Registerer != nil {
+		m, err := unused.NewProviderMetrics(c.Registerer)
+		if err != nil {
+			return nil, fmt.Errorf("registering provider metrics: %w", err)
+		}
+		mws = append(mws, unused.Instrumented(m))
+	}
+
+	return mws, nil
+}
+
+// wrap applies the configured middlewares to each provider.
+func (c MiddlewareConfig) wrap(providers []unused.Provider) ([]unused.Provider, error) {
+	mws, err := c.middlewares()
+	if err != nil || len(mws) == 0 {
+		return providers, err
+	}
+
+	for i, p := range providers {
+		providers[i] = unused.Chain(p, mws...)
+	}
+
+	return providers, nil
+}
@@ -0,0 +1,10 @@
+//go:build !fake
+
+package internal
+
+// Cloud providers register themselves when imported.
+import (
+	_ "github.com/grafana/unused/aws"
+	_ "github.com/grafana/unused/azure"
+	_ "github.com/grafana/unused/gcp"
+)
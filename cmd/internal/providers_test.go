@@ -10,18 +10,37 @@ import (
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/grafana/unused"
 	"github.com/grafana/unused/aws"
 	"github.com/grafana/unused/azure"
 	"github.com/grafana/unused/cmd/internal"
 	"github.com/grafana/unused/gcp"
+	"github.com/grafana/unused/unusedtest"
 )
 
+func parseProviderFlags(t *testing.T, args ...string) []unused.CreateFunc {
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+
+	create := internal.ProviderFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return create
+}
+
 func TestCreateProviders(t *testing.T) {
 	l := slog.New(slog.NewTextHandler(io.Discard, nil))
 
 	t.Run("fail when no provider is given", func(t *testing.T) {
-		ps, err := internal.CreateProviders(context.Background(), l, nil, nil, nil)
+		ps, err := internal.CreateProviders(context.Background(), l, parseProviderFlags(t), internal.MiddlewareConfig{})
 
 		if !errors.Is(err, internal.ErrNoProviders) {
 			t.Fatalf("expecting error %v, got %v", internal.ErrNoProviders, err)
@@ -31,12 +50,36 @@ func TestCreateProviders(t *testing.T) {
 		}
 	})
 
+	t.Run("middlewares", func(t *testing.T) {
+		p := unusedtest.NewProvider("my-provider", nil)
+		create := func(context.Context, *slog.Logger) ([]unused.Provider, error) {
+			return []unused.Provider{p}, nil
+		}
+
+		ps, err := internal.CreateProviders(context.Background(), l, []unused.CreateFunc{create}, internal.MiddlewareConfig{Retries: 1, CacheTTL: time.Minute})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(ps) != 1 {
+			t.Fatalf("expecting 1 provider, got %d", len(ps))
+		}
+		if _, ok := ps[0].(*unused.CachingProvider); !ok {
+			t.Errorf("expecting *unused.CachingProvider, got %T", ps[0])
+		}
+		if _, ok := unused.As[*unused.RetryingProvider](ps[0]); !ok {
+			t.Errorf("expecting a retrying provider to be wrapped")
+		}
+		if got, ok := unused.As[*unusedtest.Provider](ps[0]); !ok || got != p {
+			t.Errorf("expecting the created provider to be wrapped, got %v", got)
+		}
+	})
+
 	if os.Getenv("CI") == "true" {
 		t.Skip("the following tests need authentication") // TODO
 	}
 
 	t.Run("GCP", func(t *testing.T) {
-		ps, err := internal.CreateProviders(context.Background(), l, []string{"foo", "bar"}, nil, nil)
+		ps, err := internal.CreateProviders(context.Background(), l, parseProviderFlags(t, "-gcp.project=foo", "-gcp.project=bar"), internal.MiddlewareConfig{})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -53,7 +96,7 @@ func TestCreateProviders(t *testing.T) {
 
 	t.Run("AWS", func(t *testing.T) {
 		t.Skip("AWS now fails when it cannot find the profile in the configuration")
-		ps, err := internal.CreateProviders(context.Background(), l, nil, []string{"foo", "bar"}, nil)
+		ps, err := internal.CreateProviders(context.Background(), l, parseProviderFlags(t, "-aws.profile=foo", "-aws.profile=bar"), internal.MiddlewareConfig{})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -69,7 +112,7 @@ func TestCreateProviders(t *testing.T) {
 	})
 
 	t.Run("Azure", func(t *testing.T) {
-		ps, err := internal.CreateProviders(context.Background(), l, nil, nil, []string{"foo", "bar"})
+		ps, err := internal.CreateProviders(context.Background(), l, parseProviderFlags(t, "-azure.sub=foo", "-azure.sub=bar"), internal.MiddlewareConfig{})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -86,13 +129,15 @@ func TestCreateProviders(t *testing.T) {
 }
 
 func TestProviderFlags(t *testing.T) {
-	var gcpProject, awsProfile, azureSub internal.StringSliceFlag
+	t.Cleanup(func() {
+		gcp.ProviderName, aws.ProviderName, azure.ProviderName = "GCP", "AWS", "Azure"
+	})
 
 	fs := flag.NewFlagSet("test", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 	fs.Usage = func() {}
 
-	internal.ProviderFlags(fs, &gcpProject, &awsProfile, &azureSub)
+	internal.ProviderFlags(fs)
 
 	args := []string{
 		"-gcp.project=my-project",
@@ -107,25 +152,49 @@ func TestProviderFlags(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	testSlices := map[*internal.StringSliceFlag]string{
-		&gcpProject: "my-project",
-		&awsProfile: "my-profile",
-		&azureSub:   "my-subscription",
+	for _, name := range []string{"gcp.project", "aws.profile", "azure.sub", "aws.cloudtrail"} {
+		if fs.Lookup(name) == nil {
+			t.Errorf("expecting flag %s to be registered", name)
+		}
 	}
+
 	testStrings := map[*string]string{
 		&gcp.ProviderName:   "GKE",
 		&aws.ProviderName:   "EKS",
 		&azure.ProviderName: "AKS",
 	}
-
-	for v, exp := range testSlices {
-		if len(*v) != 1 || (*v)[0] != exp {
-			t.Errorf("expecting one value (%q), got %v", exp, v)
-		}
-	}
 	for v, exp := range testStrings {
 		if *v != exp {
 			t.Errorf("expecting %q, got %v", exp, v)
 		}
 	}
 }
+
+func TestDiscoveryConfigured(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		exp  bool
+	}{
+		"none":           {nil, false},
+		"projects":       {[]string{"-gcp.project=my-project", "-aws.profile=my-profile"}, false},
+		"gcp parent":     {[]string{"-gcp.parent=organizations/1"}, true},
+		"azure tenant":   {[]string{"-azure.tenant=my-tenant"}, true},
+		"azure all-subs": {[]string{"-azure.all-subs"}, true},
+		"disabled":       {[]string{"-azure.all-subs=false"}, false},
+		"aws org":        {[]string{"-aws.org.profile=management"}, true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			internal.ProviderFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := internal.DiscoveryConfigured(fs); got != tt.exp {
+				t.Errorf("expecting %v, got %v", tt.exp, got)
+			}
+		})
+	}
+}
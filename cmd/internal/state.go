@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/state"
+)
+
+// StateFlags adds the flag to record unused disks across runs to the
+// given flag set.
+func StateFlags(fs *flag.FlagSet, path *string) {
+	fs.StringVar(path, "state.file", "", "File recording when disks were first seen unused across runs, used to estimate when disks were last used when the provider doesn't report it")
+}
+
+// WrapState wraps the given providers so that the disks they list are
+// recorded in the state file in the given path. Providers are returned
+// unchanged when the path is empty.
+func WrapState(providers []unused.Provider, path string) ([]unused.Provider, error) {
+	mw, err := stateMiddleware(path)
+	if err != nil || mw == nil {
+		return providers, err
+	}
+
+	ps := make([]unused.Provider, len(providers))
+	for i, p := range providers {
+		ps[i] = mw(p)
+	}
+
+	return ps, nil
+}
+
+// stateMiddleware returns the middleware applied by [WrapState], or nil
+// when the path is empty.
+func stateMiddleware(path string) (unused.Middleware, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	s, err := state.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening state store: %w", err)
+	}
+
+	return func(p unused.Provider) unused.Provider { return state.WrapProvider(p, s) }, nil
+}
@@ -4,14 +4,22 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/grafana/unused"
 	"github.com/grafana/unused/cmd/internal"
 )
 
 type config struct {
-	Providers struct {
-		GCP   internal.StringSliceFlag
-		AWS   internal.StringSliceFlag
-		Azure internal.StringSliceFlag
+	Providers []unused.CreateFunc
+
+	Middleware internal.MiddlewareConfig
+
+	State struct {
+		File string
+	}
+
+	Kubernetes struct {
+		Contexts   internal.StringSliceFlag
+		Kubeconfig string
 	}
 
 	Web struct {
@@ -23,6 +31,21 @@ type config struct {
 	Collector struct {
 		Timeout      time.Duration
 		PollInterval time.Duration
+
+		// Discovery is set when providers are discovered, so that
+		// they're refreshed every DiscoveryInterval.
+		Discovery         bool
+		DiscoveryInterval time.Duration
+
+		SnapshotRetention time.Duration
+
+		Filters internal.StringSliceFlag
+
+		Policy string
+	}
+
+	Pricing struct {
+		File string
 	}
 
 	Logger         *slog.Logger
@@ -4,14 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/grafana/unused"
-	"github.com/grafana/unused/aws"
-	"github.com/grafana/unused/azure"
-	"github.com/grafana/unused/gcp"
+	"github.com/grafana/unused/filter"
+	"github.com/grafana/unused/k8s"
+	"github.com/grafana/unused/policy"
+	"github.com/grafana/unused/pricing"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -28,10 +30,16 @@ type exporter struct {
 	logger  *slog.Logger
 	verbose bool
 
-	timeout      time.Duration
-	pollInterval time.Duration
+	timeout           time.Duration
+	pollInterval      time.Duration
+	snapshotRetention time.Duration
 
-	providers []unused.Provider
+	// polls holds the function stopping the polling of each provider,
+	// indexed by providerKey; it's only used by setProviders.
+	polls  map[string]context.CancelFunc
+	prices *pricing.Estimator
+	filter unused.ResourceFilterFunc
+	policy *policy.Policy
 
 	info  *prometheus.Desc
 	count *prometheus.Desc
@@ -40,22 +48,55 @@ type exporter struct {
 	dur   *prometheus.Desc
 	suc   *prometheus.Desc
 	dlu   *prometheus.Desc
+	cost  *prometheus.Desc
+	addrs *prometheus.Desc
+	snaps *prometheus.Desc
+	vrdct *prometheus.Desc
 
 	mu    sync.RWMutex
 	cache map[unused.Provider][]metric
 }
 
-func registerExporter(ctx context.Context, providers []unused.Provider, cfg config) error {
+// registerExporter registers an exporter polling the given providers.
+// When discover isn't nil, providers are refreshed with it at the
+// configured discovery interval.
+func registerExporter(ctx context.Context, providers []unused.Provider, discover func(context.Context) ([]unused.Provider, error), cfg config) error {
 	labels := []string{"provider", "provider_id"}
 
+	prices, err := pricing.Load(cfg.Pricing.File)
+	if err != nil {
+		return fmt.Errorf("loading prices: %w", err)
+	}
+
+	filters := make([]unused.ResourceFilterFunc, len(cfg.Collector.Filters))
+	for i, expr := range cfg.Collector.Filters {
+		filters[i], err = filter.CompileResource(expr)
+		if err != nil {
+			return fmt.Errorf("parsing filter: %w", err)
+		}
+	}
+
+	var pol *policy.Policy
+	if cfg.Collector.Policy != "" {
+		pol, err = policy.Load(cfg.Collector.Policy)
+		if err != nil {
+			return err
+		}
+	}
+
 	e := &exporter{
 		ctx:          ctx,
 		logger:       cfg.Logger,
 		verbose:      cfg.VerboseLogging,
-		providers:    providers,
+		polls:        make(map[string]context.CancelFunc),
+		prices:       prices,
+		filter:       unused.And(filters...),
+		policy:       pol,
 		timeout:      cfg.Collector.Timeout,
 		pollInterval: cfg.Collector.PollInterval,
 
+		snapshotRetention: cfg.Collector.SnapshotRetention,
+
 		info: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "provider", "info"),
 			"CSP information",
@@ -70,13 +111,13 @@ func registerExporter(ctx context.Context, providers []unused.Provider, cfg conf
 		ds: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "disk", "size_bytes"),
 			"Disk size in bytes",
-			append(labels, []string{"disk", "created_for_pv", "k8s_namespace", "type", "region", "zone"}...),
+			append(labels, []string{"disk", "created_for_pv", "k8s_namespace", "k8s_pv_state", "type", "class", "region", "zone"}...),
 			nil),
 
 		size: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "disks", "total_size_bytes"),
 			"Total size of unused disks in this provider in bytes",
-			append(labels, "k8s_namespace", "type"),
+			append(labels, "k8s_namespace", "type", "class"),
 			nil),
 
 		dur: prometheus.NewDesc(
@@ -94,46 +135,145 @@ func registerExporter(ctx context.Context, providers []unused.Provider, cfg conf
 		dlu: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "disks", "last_used_timestamp_seconds"),
 			"Kubernetes metadata associated with each unused disk, with the value as the last time the disk was used (if available)",
-			append(labels, []string{"disk", "created_for_pv", "created_for_pvc", "zone"}...),
+			append(labels, []string{"disk", "created_for_pv", "created_for_pvc", "k8s_pv_state", "zone"}...),
+			nil),
+
+		cost: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "disks", "estimated_monthly_cost"),
+			"Estimated monthly cost in USD of unused disks in this provider",
+			append(labels, "k8s_namespace", "type", "class"),
+			nil),
+
+		addrs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "addresses", "count"),
+			"How many unused IP addresses are in this provider",
+			labels,
+			nil),
+
+		snaps: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "snapshots", "count"),
+			"How many orphaned or expired snapshots are in this provider",
+			append(labels, "k8s_namespace", "orphaned"),
+			nil),
+
+		vrdct: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "disks", "verdict_count"),
+			"How many unused disks in this provider got each policy verdict",
+			append(labels, "verdict", "rule"),
 			nil),
 
 		cache: make(map[unused.Provider][]metric, len(providers)),
 	}
 
 	e.logger.Info("start background polling of providers",
-		slog.Int("providers", len(e.providers)),
+		slog.Int("providers", len(providers)),
 		slog.Duration("interval", e.pollInterval),
 		slog.Duration("timeout", e.timeout),
 	)
 
-	for _, p := range providers {
-		p := p
-		go e.pollProvider(p)
+	e.setProviders(providers)
+
+	if discover != nil && cfg.Collector.DiscoveryInterval > 0 {
+		go e.refreshProviders(discover, cfg.Collector.DiscoveryInterval)
 	}
 
 	return prometheus.Register(e)
 }
 
+// providerKey identifies a provider across discoveries.
+func providerKey(p unused.Provider) string {
+	return p.Name() + "/" + p.ID()
+}
+
+// setProviders starts polling the given providers which aren't polled
+// yet and stops polling the ones which are no longer present.
+func (e *exporter) setProviders(providers []unused.Provider) {
+	current := make(map[string]bool, len(providers))
+
+	for _, p := range providers {
+		k := providerKey(p)
+		current[k] = true
+		if _, ok := e.polls[k]; ok {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(e.ctx)
+		e.polls[k] = cancel
+		go e.pollProvider(ctx, p)
+	}
+
+	for k, cancel := range e.polls {
+		if !current[k] {
+			e.logger.Info("stop polling removed provider", slog.String("provider", k))
+			cancel()
+			delete(e.polls, k)
+		}
+	}
+}
+
+// refreshProviders discovers the providers at the given interval,
+// keeping the current ones when discovery fails.
+func (e *exporter) refreshProviders(discover func(context.Context) ([]unused.Provider, error), interval time.Duration) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-tick.C:
+		}
+
+		providers, err := discover(e.ctx)
+		if err != nil {
+			e.logger.Error("failed to discover providers", slog.String("error", err.Error()))
+			continue
+		}
+
+		e.logger.Info("providers discovered", slog.Int("providers", len(providers)))
+		e.setProviders(providers)
+	}
+}
+
 func (e *exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.info
 	ch <- e.count
 	ch <- e.size
 	ch <- e.dur
 	ch <- e.dlu
+	ch <- e.cost
+	ch <- e.addrs
+	ch <- e.snaps
+	ch <- e.vrdct
 }
 
 type namespaceInfo struct {
-	Count      int
-	SizeByType map[unused.DiskType]float64
+	Count       int
+	SizeByClass map[diskClass]float64
+	CostByClass map[diskClass]float64
 }
 
-func (e *exporter) pollProvider(p unused.Provider) {
+// diskClass aggregates disks by their normalized and native types.
+type diskClass struct {
+	typ   unused.DiskType
+	class string
+}
+
+// pollProvider collects the metrics of the provider until ctx is
+// cancelled, removing them from the cache then.
+func (e *exporter) pollProvider(ctx context.Context, p unused.Provider) {
 	tick := time.NewTicker(e.pollInterval)
 	defer tick.Stop()
 
+	defer func() {
+		e.mu.Lock()
+		delete(e.cache, p)
+		e.mu.Unlock()
+	}()
+
 	for {
 		select {
-		case <-e.ctx.Done(): // parent context was cancelled
+		case <-ctx.Done(): // provider was removed or parent context was cancelled
 			return
 
 		default:
@@ -150,53 +290,115 @@ func (e *exporter) pollProvider(p unused.Provider) {
 			)
 
 			logger.Info("collecting metrics")
-			ctx, cancel := context.WithTimeout(e.ctx, e.timeout)
+			cctx, cancel := context.WithTimeout(ctx, e.timeout)
 			start := time.Now()
-			disks, err := p.ListUnusedDisks(ctx)
-			cancel() // release resources early
-			dur := time.Since(start)
+			disks, err := p.ListUnusedDisks(cctx)
 			if err != nil {
 				logger.Error("failed to collect metrics", slog.String("error", err.Error()))
 				success = 0
 			}
+			var addrs unused.Addresses
+			ap, listAddrs := unused.As[unused.AddressProvider](p)
+			if listAddrs {
+				addrs, err = ap.ListUnusedAddresses(cctx)
+				if err != nil {
+					logger.Error("failed to collect addresses metrics", slog.String("error", err.Error()))
+					success = 0
+				}
+			}
+			var snaps unused.Snapshots
+			sp, listSnaps := unused.As[unused.SnapshotProvider](p)
+			if listSnaps {
+				snaps, err = sp.ListUnusedSnapshots(cctx, e.snapshotRetention)
+				if err != nil {
+					logger.Error("failed to collect snapshots metrics", slog.String("error", err.Error()))
+					success = 0
+				}
+			}
+			cancel() // release resources early
+			dur := time.Since(start)
+
+			disks = disks.Filter(e.filter.Disks())
 
 			diskInfoByNamespace := make(map[string]*namespaceInfo)
+			verdicts := make(map[policy.Verdict]int)
 			var ms []metric
 
 			for _, d := range disks {
 				diskLabels := getDiskLabels(d, e.verbose)
 				e.logger.Info("unused disk found", diskLabels...)
 
-				ns := getNamespace(d, p)
+				k, loc, class := d.Kubernetes(), d.Location(), d.Class()
+				ns := k.Namespace
 				di := diskInfoByNamespace[ns]
 				if di == nil {
 					di = &namespaceInfo{
-						SizeByType: make(map[unused.DiskType]float64),
+						SizeByClass: make(map[diskClass]float64),
+						CostByClass: make(map[diskClass]float64),
 					}
 					diskInfoByNamespace[ns] = di
 				}
 				di.Count += 1
-				di.SizeByType[d.DiskType()] += float64(d.SizeBytes())
+				dc := diskClass{class.Media, class.Type}
+				di.SizeByClass[dc] += float64(d.SizeBytes())
+				if c, ok := e.prices.MonthlyCost(d); ok {
+					di.CostByClass[dc] += c
+				}
 
 				e.logger.Info(fmt.Sprintf("Disk %s last used at %v", d.Name(), d.LastUsedAt()))
 
-				m := d.Meta()
-				if m.CreatedForPV() == "" {
+				if e.policy != nil {
+					verdicts[e.policy.Evaluate(d)]++
+				}
+
+				if k.PV == "" {
 					continue
 				}
 
-				addMetric(&ms, p, e.dlu, lastUsedTS(d), d.ID(), m.CreatedForPV(), m.CreatedForPVC(), m.Zone())
-				addMetric(&ms, p, e.ds, d.SizeBytes(), d.ID(), m.CreatedForPV(), ns, string(d.DiskType()), getRegionFromZone(p, m.Zone()), m.Zone())
+				state := d.Meta()[k8s.MetaPVState]
+				addMetric(&ms, p, e.dlu, lastUsedTS(d), d.ID(), k.PV, k.PVC, state, loc.Zone)
+				addMetric(&ms, p, e.ds, d.SizeBytes(), d.ID(), k.PV, ns, state, string(class.Media), class.Type, loc.Region, loc.Zone)
 			}
 
 			addMetric(&ms, p, e.info, 1)
 			addMetric(&ms, p, e.dur, float64(dur.Seconds()))
 			addMetric(&ms, p, e.suc, float64(success))
+			if listAddrs {
+				var n int
+				for _, a := range addrs {
+					if e.filter(a) {
+						n++
+					}
+				}
+				addMetric(&ms, p, e.addrs, float64(n))
+			}
+
+			type snapshotKey struct {
+				ns       string
+				orphaned bool
+			}
+			snapshotCount := make(map[snapshotKey]int)
+			for _, s := range snaps {
+				if !e.filter(s) {
+					continue
+				}
+				snapshotCount[snapshotKey{s.Meta().CreatedForNamespace(), s.Orphaned()}]++
+			}
+			for k, n := range snapshotCount {
+				addMetric(&ms, p, e.snaps, float64(n), k.ns, strconv.FormatBool(k.orphaned))
+			}
+
+			for v, n := range verdicts {
+				addMetric(&ms, p, e.vrdct, float64(n), string(v.Action), v.Rule)
+			}
 
 			for ns, di := range diskInfoByNamespace {
 				addMetric(&ms, p, e.count, float64(di.Count), ns)
-				for diskType, diskSize := range di.SizeByType {
-					addMetric(&ms, p, e.size, diskSize, ns, string(diskType))
+				for dc, diskSize := range di.SizeByClass {
+					addMetric(&ms, p, e.size, diskSize, ns, string(dc.typ), dc.class)
+				}
+				for dc, diskCost := range di.CostByClass {
+					addMetric(&ms, p, e.cost, diskCost, ns, string(dc.typ), dc.class)
 				}
 			}
 
@@ -210,7 +412,10 @@ func (e *exporter) pollProvider(p unused.Provider) {
 				slog.Duration("dur", dur),
 			)
 
-			<-tick.C
+			select {
+			case <-ctx.Done():
+			case <-tick.C:
+			}
 		}
 
 	}
@@ -263,19 +468,6 @@ func getDiskLabels(d unused.Disk, v bool) []any {
 	return diskLabels
 }
 
-func getNamespace(d unused.Disk, p unused.Provider) string {
-	switch p.Name() {
-	case gcp.ProviderName:
-		return d.Meta()["kubernetes.io/created-for/pvc/namespace"]
-	case aws.ProviderName:
-		return d.Meta()["kubernetes.io/created-for/pvc/namespace"]
-	case azure.ProviderName:
-		return d.Meta()["kubernetes.io-created-for-pvc-namespace"]
-	default:
-		panic("getNamespace(): unrecognized provider name:" + p.Name())
-	}
-}
-
 func addMetric(ms *[]metric, p unused.Provider, d *prometheus.Desc, v float64, lbls ...string) {
 	*ms = append(*ms, metric{
 		desc:   d,
@@ -292,16 +484,3 @@ func lastUsedTS(d unused.Disk) float64 {
 
 	return float64(lastUsed.Unix())
 }
-
-func getRegionFromZone(p unused.Provider, z string) string {
-	switch p.Name() {
-	case gcp.ProviderName:
-		return z[:strings.LastIndex(z, "-")]
-	case aws.ProviderName:
-		return z[:len(z)-1]
-	case azure.ProviderName:
-		return z
-	default:
-		panic("getRegionFromZone(): unrecognized provider name:" + p.Name())
-	}
-}
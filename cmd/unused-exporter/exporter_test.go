@@ -1,25 +1,19 @@
 package main
 
 import (
+	"context"
+	"io"
 	"log/slog"
 	"reflect"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/grafana/unused"
-	"github.com/grafana/unused/aws"
-	"github.com/grafana/unused/azure"
-	"github.com/grafana/unused/gcp"
+	"github.com/grafana/unused/pricing"
+	"github.com/grafana/unused/unusedtest"
 )
 
-type MockProvider struct {
-	unused.Provider
-
-	name string
-}
-
-func (m MockProvider) Name() string { return m.name }
-
 type MockDisk struct {
 	unused.Disk
 	name      string
@@ -44,73 +38,6 @@ func (d *MockDisk) SizeGB() int {
 	return d.sizeGB
 }
 
-func TestGetRegionFromZone(t *testing.T) {
-	type testCase struct {
-		provider string
-		zone     string
-		expected string
-	}
-
-	testCases := map[string]testCase{
-		"Azure": {azure.ProviderName, "eastus1", "eastus1"},
-		"GCP":   {gcp.ProviderName, "us-central1-a", "us-central1"},
-		"AWS":   {aws.ProviderName, "us-west-2a", "us-west-2"},
-	}
-
-	for n, tc := range testCases {
-		t.Run(n, func(t *testing.T) {
-			p := &MockProvider{name: tc.provider}
-			result := getRegionFromZone(p, tc.zone)
-			if result != tc.expected {
-				t.Errorf("getRegionFromZone(%s, %s) = %s, expected %s", tc.provider, tc.zone, result, tc.expected)
-			}
-		})
-	}
-}
-
-func TestGetNamespace(t *testing.T) {
-	type testCase struct {
-		provider string
-		diskMeta map[string]string
-		expected string
-	}
-
-	testCases := map[string]testCase{
-		"Azure": {
-			provider: azure.ProviderName,
-			diskMeta: map[string]string{
-				"kubernetes.io-created-for-pvc-namespace": "azure-namespace",
-			},
-			expected: "azure-namespace",
-		},
-		"GCP": {
-			provider: gcp.ProviderName,
-			diskMeta: map[string]string{
-				"kubernetes.io/created-for/pvc/namespace": "gcp-namespace",
-			},
-			expected: "gcp-namespace",
-		},
-		"AWS": {
-			provider: aws.ProviderName,
-			diskMeta: map[string]string{
-				"kubernetes.io/created-for/pvc/namespace": "aws-namespace",
-			},
-			expected: "aws-namespace",
-		},
-	}
-
-	for n, tc := range testCases {
-		t.Run(n, func(t *testing.T) {
-			p := &MockProvider{name: tc.provider}
-			d := &MockDisk{meta: tc.diskMeta}
-			result := getNamespace(d, p)
-			if result != tc.expected {
-				t.Errorf("getNamespace(%v, %v) = %s, expected %s", d, p, result, tc.expected)
-			}
-		})
-	}
-}
-
 func TestGetDiskLabels(t *testing.T) {
 	type testCase struct {
 		verbose  bool
@@ -165,3 +92,61 @@ func TestGetDiskLabels(t *testing.T) {
 		})
 	}
 }
+
+func TestSetProviders(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := &exporter{
+		ctx:          ctx,
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		timeout:      time.Second,
+		pollInterval: time.Hour,
+		polls:        make(map[string]context.CancelFunc),
+		prices:       pricing.Default(),
+		filter:       unused.And[unused.ResourceFilterFunc](),
+		cache:        make(map[unused.Provider][]metric),
+	}
+
+	cached := func() []string {
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+
+		var names []string
+		for p := range e.cache {
+			names = append(names, p.Name())
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	waitFor := func(exp ...string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !slices.Equal(exp, cached()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("expecting cached providers %v, got %v", exp, cached())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	a, b := unusedtest.NewProvider("a", nil), unusedtest.NewProvider("b", nil)
+
+	e.setProviders([]unused.Provider{a, b})
+	waitFor("a", "b")
+
+	// providers already polled aren't polled again
+	e.setProviders([]unused.Provider{unusedtest.NewProvider("b", nil)})
+	waitFor("b")
+	if len(e.polls) != 1 {
+		t.Fatalf("expecting 1 polled provider, got %d", len(e.polls))
+	}
+
+	e.mu.RLock()
+	_, ok := e.cache[b]
+	e.mu.RUnlock()
+	if !ok {
+		t.Error("expecting the original provider to still be polled")
+	}
+}
@@ -3,9 +3,11 @@
 //
 // Provider selection is opinionated, currently accepting the
 // following authentication method for each provider:
-//   - GCP: pass gcp.project with a valid GCP project ID.
+//   - GCP: pass gcp.project with a valid GCP project ID, or gcp.parent
+//     with an organization or folder to discover its projects.
 //   - AWS: pass aws.profile with a valid AWS shared profile.
-//   - Azure: pass azure.sub with a valid Azure subscription ID.
+//   - Azure: pass azure.sub with a valid Azure subscription ID, or
+//     azure.all-subs to discover the subscriptions of the credential.
 package main
 
 import (
@@ -18,6 +20,7 @@ import (
 	"time"
 
 	"github.com/grafana/unused/cmd/internal"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func main() {
@@ -25,7 +28,10 @@ func main() {
 		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	internal.ProviderFlags(flag.CommandLine, &cfg.Providers.GCP, &cfg.Providers.AWS, &cfg.Providers.Azure)
+	cfg.Providers = internal.ProviderFlags(flag.CommandLine)
+	internal.KubernetesFlags(flag.CommandLine, &cfg.Kubernetes.Contexts, &cfg.Kubernetes.Kubeconfig)
+	internal.StateFlags(flag.CommandLine, &cfg.State.File)
+	internal.MiddlewareFlags(flag.CommandLine, &cfg.Middleware)
 
 	flag.BoolVar(&cfg.VerboseLogging, "verbose", false, "add verbose logging information")
 	flag.DurationVar(&cfg.Collector.Timeout, "collect.timeout", 30*time.Second, "timeout for collecting metrics from each provider")
@@ -33,9 +39,16 @@ func main() {
 	flag.StringVar(&cfg.Web.Address, "web.address", ":8080", "address to expose metrics and web interface")
 	flag.DurationVar(&cfg.Web.Timeout, "web.timeout", 5*time.Second, "timeout for shutting down the server")
 	flag.DurationVar(&cfg.Collector.PollInterval, "collect.interval", 5*time.Minute, "interval to poll the cloud provider API for unused disks")
+	flag.DurationVar(&cfg.Collector.DiscoveryInterval, "collect.discovery-interval", time.Hour, "interval to refresh the providers discovered in cloud organizations, like GCP projects or Azure subscriptions; 0 disables refreshing")
+	flag.DurationVar(&cfg.Collector.SnapshotRetention, "collect.snapshot-retention", 0, "count snapshots older than this as unused even if their source disk exists")
+	flag.Var(&cfg.Collector.Filters, "collect.filter", `only collect resources matching this filter expression, ex: k8s:ns=~"loki-.*" && !has(keep); can be repeated and all must match`)
+	flag.StringVar(&cfg.Collector.Policy, "collect.policy", "", "YAML policy file used to count unused disks by verdict")
+	flag.StringVar(&cfg.Pricing.File, "pricing.file", "", "JSON file with disk prices overriding the default ones")
 
 	flag.Parse()
 
+	cfg.Collector.Discovery = internal.DiscoveryConfigured(flag.CommandLine)
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
@@ -47,12 +60,26 @@ func main() {
 }
 
 func realMain(ctx context.Context, cfg config) error {
-	providers, err := internal.CreateProviders(ctx, cfg.Logger, cfg.Providers.GCP, cfg.Providers.AWS, cfg.Providers.Azure)
+	cfg.Middleware.Registerer = prometheus.DefaultRegisterer
+
+	d, err := internal.NewDiscoverer(cfg.Logger, cfg.Providers, cfg.Middleware, cfg.Kubernetes.Kubeconfig, cfg.Kubernetes.Contexts, cfg.State.File)
+	if err != nil {
+		return err
+	}
+
+	providers, err := d.Discover(ctx)
 	if err != nil {
 		return err
 	}
 
-	if err := registerExporter(ctx, providers, cfg); err != nil {
+	// only discovered providers can change, so there's nothing to
+	// refresh otherwise
+	discover := d.Discover
+	if !cfg.Collector.Discovery {
+		discover = nil
+	}
+
+	if err := registerExporter(ctx, providers, discover, cfg); err != nil {
 		return fmt.Errorf("registering exporter: %w", err)
 	}
 
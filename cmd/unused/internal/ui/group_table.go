@@ -8,6 +8,8 @@ import (
 	"strconv"
 	"strings"
 	"text/tabwriter"
+
+	"github.com/grafana/unused"
 )
 
 // Disks are aggregated by the key composed by these 3 strings:
@@ -19,7 +21,11 @@ import (
 type groupKey [3]string
 
 func GroupTable(ctx context.Context, ui UI) error {
-	disks, err := ui.listUnusedDisks(ctx)
+	if ui.Kind != unused.KindDisk {
+		return fmt.Errorf("grouping %s is not supported", ui.Kind.Plural())
+	}
+
+	res, err := ui.listUnusedResources(ctx)
 	if err != nil {
 		return err
 	}
@@ -28,38 +34,58 @@ func GroupTable(ctx context.Context, ui UI) error {
 
 	var groupByHeader string
 	switch ui.Group {
-	case "k8s:ns", "k8s:pvc", "k8s:pv":
+	case "class", "k8s:cluster", "k8s:ns", "k8s:pvc", "k8s:pv", "k8s:storageclass", "region", "zone":
 		groupByHeader = strings.ToUpper(ui.Group)
 	default:
 		groupByHeader = ui.Group
 	}
 
-	headers := []string{"PROVIDER", groupByHeader, "TYPE", "DISKS_COUNT", "TOTAL_SIZE_GB"}
+	headers := []string{"PROVIDER", groupByHeader, "TYPE", "DISKS_COUNT", "TOTAL_SIZE_GB", "TOTAL_MONTHLY_COST"}
 	totalSize := make(map[groupKey]int)
 	totalCount := make(map[groupKey]int)
+	totalCost := make(map[groupKey]float64)
+	pricedCount := make(map[groupKey]int)
 
 	fmt.Fprintln(w, strings.Join(headers, "\t")) // nolint:errcheck
 
 	var aggrValue string
-	for _, d := range disks {
+	for _, r := range res {
+		d := r.(unused.Disk)
+
 		var (
 			value string
 			ok    bool
-			meta  = d.Meta()
+			k     = d.Kubernetes()
+			loc   = d.Location()
 		)
 
 		switch ui.Group {
+		case "class":
+			value = d.Class().Type
+			ok = value != ""
+		case "k8s:cluster":
+			value = k.Cluster
+			ok = value != ""
 		case "k8s:ns":
-			value = meta.CreatedForNamespace()
+			value = k.Namespace
 			ok = value != ""
 		case "k8s:pvc":
-			value = meta.CreatedForPVC()
+			value = k.PVC
 			ok = value != ""
 		case "k8s:pv":
-			value = meta.CreatedForPV()
+			value = k.PV
+			ok = value != ""
+		case "k8s:storageclass":
+			value = k.StorageClass
+			ok = value != ""
+		case "region":
+			value = loc.Region
+			ok = value != ""
+		case "zone":
+			value = loc.Zone
 			ok = value != ""
 		default:
-			value, ok = meta[ui.Group]
+			value, ok = d.Meta()[ui.Group]
 		}
 
 		if ok {
@@ -71,6 +97,10 @@ func GroupTable(ctx context.Context, ui UI) error {
 		aggrKey := groupKey{d.Provider().Name(), aggrValue, string(d.DiskType())}
 		totalSize[aggrKey] += d.SizeGB()
 		totalCount[aggrKey] += 1
+		if c, ok := ui.Prices.MonthlyCost(d); ok {
+			totalCost[aggrKey] += c
+			pricedCount[aggrKey] += 1
+		}
 	}
 
 	keys := slices.SortedFunc(maps.Keys(totalSize), func(a, b groupKey) int {
@@ -84,7 +114,7 @@ func GroupTable(ctx context.Context, ui UI) error {
 
 	for _, aggrKey := range keys {
 		row := aggrKey[:]
-		row = append(row, strconv.Itoa(totalCount[aggrKey]), strconv.Itoa(totalSize[aggrKey]))
+		row = append(row, strconv.Itoa(totalCount[aggrKey]), strconv.Itoa(totalSize[aggrKey]), groupCost(totalCost[aggrKey], pricedCount[aggrKey], totalCount[aggrKey]))
 		fmt.Fprintln(w, strings.Join(row, "\t")) // nolint:errcheck
 	}
 
@@ -94,3 +124,17 @@ func GroupTable(ctx context.Context, ui UI) error {
 
 	return nil
 }
+
+// groupCost formats the total monthly cost of a group of disks,
+// showing "-" when none of them has a known price, and suffixing it
+// with "+" when only some of them do, as it's then a lower bound.
+func groupCost(cost float64, priced, count int) string {
+	switch {
+	case priced == 0:
+		return "-"
+	case priced < count:
+		return fmt.Sprintf("%.2f+", cost)
+	default:
+		return fmt.Sprintf("%.2f", cost)
+	}
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/pricing"
+	"github.com/grafana/unused/unusedtest"
+)
+
+func TestGroupTableCosts(t *testing.T) {
+	var (
+		now = time.Now()
+		p   = unusedtest.NewProvider("AWS", nil)
+	)
+
+	disk := func(name, typ, team string) unused.Disk {
+		d := unusedtest.NewDisk(name, p, now, now)
+		d.SetClass(unused.DiskClass{Type: typ, Media: unused.SSD})
+		d.SetMeta(unused.Meta{"team": team})
+		d.SetSize(100)
+		return d
+	}
+
+	p.SetDisks(
+		disk("a", "gp3", "priced"),
+		disk("b", "gp3", "partial"),
+		disk("c", "foo", "partial"),
+		disk("d", "foo", "unpriced"),
+	)
+
+	var out bytes.Buffer
+	ui := UI{Kind: unused.KindDisk, Providers: []unused.Provider{p}, Out: &out, Group: "team", Prices: pricing.Default()}
+	if err := GroupTable(context.Background(), ui); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := map[string]string{
+		"priced":   "8.00",
+		"partial":  "8.00+",
+		"unpriced": "-",
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")[1:]
+	if len(lines) != len(exp) {
+		t.Fatalf("expecting %d groups, got %q", len(exp), lines)
+	}
+	for _, l := range lines {
+		fields := strings.Fields(l)
+		team, cost := fields[1], fields[len(fields)-1]
+		if exp[team] != cost {
+			t.Errorf("expecting %q group cost %q, got %q", team, exp[team], cost)
+		}
+	}
+}
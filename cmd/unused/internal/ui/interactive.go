@@ -5,11 +5,12 @@ import (
 	"fmt"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/grafana/unused"
 	"github.com/grafana/unused/cmd/unused/internal/ui/interactive"
 )
 
 func Interactive(ctx context.Context, ui UI) error {
-	m := interactive.New(ui.Providers, ui.ExtraColumns, ui.Filter, ui.DryRun)
+	m := interactive.New(ui.Providers, ui.Kind, ui.ExtraColumns, ui.FilterResource, ui.DryRun, ui.SnapshotBeforeDelete, ui.Auditor, ui.DeleteOptions, unused.WithSnapshotRetention(ui.SnapshotRetention))
 
 	if _, err := tea.NewProgram(m).Run(); err != nil {
 		return fmt.Errorf("cannot start interactive UI: %w", err)
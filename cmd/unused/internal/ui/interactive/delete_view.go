@@ -2,7 +2,9 @@ package interactive
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,21 +16,30 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/evertras/bubble-table/table"
 	"github.com/grafana/unused"
+	"github.com/grafana/unused/audit"
+	"github.com/grafana/unused/deleter"
 )
 
 type deleteViewModel struct {
-	help     help.Model
-	start    time.Time
-	provider unused.Provider
-	confirm  key.Binding
-	toggle   key.Binding
-	disks    []diskToDelete
-	spinner  spinner.Model
-	table    table.Model
-	progress progress.Model
-	cur      int
-	delete   bool
-	dryRun   bool
+	help       help.Model
+	start      time.Time
+	provider   unused.Provider
+	confirm    key.Binding
+	toggle     key.Binding
+	kind       unused.ResourceKind
+	res        []resourceToDelete
+	spinner    spinner.Model
+	table      table.Model
+	progress   progress.Model
+	started    int
+	done       int
+	delete     bool
+	dryRun     bool
+	snapshot   bool
+	auditor    *audit.Logger
+	deleteOpts []deleter.Option
+	events     <-chan tea.Msg
+	cancel     context.CancelFunc
 }
 
 const (
@@ -36,13 +47,17 @@ const (
 	columnStatus = "status"
 )
 
-func newDeleteViewModel(dryRun bool) deleteViewModel {
+func newDeleteViewModel(kind unused.ResourceKind, dryRun, snapshot bool, auditor *audit.Logger, deleteOpts []deleter.Option) deleteViewModel {
 	return deleteViewModel{
-		help:    newHelp(),
-		confirm: key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "confirm delete")),
-		toggle:  key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "toggle dry-run")),
-		spinner: spinner.New(),
-		dryRun:  dryRun,
+		help:       newHelp(),
+		confirm:    key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "confirm delete")),
+		toggle:     key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "toggle dry-run")),
+		spinner:    spinner.New(),
+		kind:       kind,
+		dryRun:     dryRun,
+		snapshot:   snapshot,
+		auditor:    auditor,
+		deleteOpts: deleteOpts,
 		progress: progress.New(
 			progress.WithDefaultBlend(),
 		),
@@ -58,16 +73,16 @@ func newDeleteViewModel(dryRun bool) deleteViewModel {
 	}
 }
 
-func (m deleteViewModel) WithDisks(provider unused.Provider, disks unused.Disks) deleteViewModel {
+func (m deleteViewModel) WithResources(provider unused.Provider, res unused.Resources) deleteViewModel {
 	m.provider = provider
-	m.cur = 0
+	m.started, m.done = 0, 0
 
-	m.disks = make([]diskToDelete, len(disks))
-	rows := make([]table.Row, len(disks))
-	for i, d := range disks {
-		m.disks[i] = diskToDelete{disk: d}
+	m.res = make([]resourceToDelete, len(res))
+	rows := make([]table.Row, len(res))
+	for i, r := range res {
+		m.res[i] = resourceToDelete{provider: provider, res: r, status: &deleteStatus{}}
 		rows[i] = table.NewRow(table.RowData{
-			columnName: d.Name(),
+			columnName: r.Name(),
 		})
 	}
 
@@ -76,7 +91,32 @@ func (m deleteViewModel) WithDisks(provider unused.Provider, disks unused.Disks)
 	return m
 }
 
-type deleteNextMsg struct{}
+// Cancel stops deleting resources, if they are being deleted.
+func (m deleteViewModel) Cancel() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+type (
+	deleteStartMsg struct{ i int }
+	deleteRetryMsg struct {
+		i, attempt int
+		err        error
+	}
+	deleteDoneMsg struct {
+		i   int
+		err error
+	}
+	deleteFinishedMsg struct{}
+
+	// deleteEventMsg wraps the progress messages of a deletion run, so
+	// that messages from a canceled run are ignored
+	deleteEventMsg struct {
+		events <-chan tea.Msg
+		msg    tea.Msg
+	}
+)
 
 func (m deleteViewModel) Update(msg tea.Msg) (deleteViewModel, tea.Cmd) {
 	var cmd tea.Cmd
@@ -85,79 +125,235 @@ func (m deleteViewModel) Update(msg tea.Msg) (deleteViewModel, tea.Cmd) {
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.confirm):
+			if m.delete || m.done == len(m.res) {
+				return m, nil
+			}
 			m.delete = true
 			m.start = time.Now()
-			cmd = tea.Batch(m.spinner.Tick, sendMsg(deleteNextMsg{}))
+			m = m.startDeleting()
+			cmd = tea.Batch(m.spinner.Tick, waitForDelete(m.events))
 
 		case key.Matches(msg, m.toggle):
-			m.dryRun = !m.dryRun
+			if !m.delete {
+				m.dryRun = !m.dryRun
+			}
 
 		default:
 			m.table, cmd = m.table.Update(msg)
 		}
 
-	case deleteNextMsg:
-		if m.cur == len(m.disks) {
-			m.delete = false
+	case deleteEventMsg:
+		if msg.events != m.events {
 			return m, nil
 		}
+		return m.updateDelete(msg.msg)
+
+	case spinner.TickMsg:
+		if m.delete {
+			m.spinner, cmd = m.spinner.Update(msg)
+		}
+	}
 
-		rows := m.table.GetVisibleRows()
-
-		ds := m.disks[m.cur].status
-		if ds == nil {
-			m.disks[m.cur].status = &deleteStatus{}
-			rows[m.cur] = rows[m.cur].Selected(true)
-
-			return m, deleteDisk(&m.disks[m.cur], m.dryRun)
-		} else if ds.done {
-			data := rows[m.cur].Data
-			if ds.err == nil {
-				data[columnMark] = "✔"
-			} else {
-				data[columnMark] = "❌"
-				data[columnStatus] = errorStyle.Render(ds.err.Error())
+	return m, cmd
+}
+
+func (m deleteViewModel) updateDelete(msg tea.Msg) (deleteViewModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case deleteStartMsg:
+		m.setRow(msg.i, "", "")
+		m.started++
+		cmd = waitForDelete(m.events)
+
+	case deleteRetryMsg:
+		m.setRow(msg.i, "", fmt.Sprintf("retrying after attempt %d: %v", msg.attempt, msg.err))
+		cmd = waitForDelete(m.events)
+
+	case deleteDoneMsg:
+		status := m.res[msg.i].status
+		var info string
+		if status.snapshotID != "" {
+			info = "snapshot " + status.snapshotID
+		}
+		if status.auditErr != nil {
+			if info != "" {
+				info += ", "
 			}
-			rows[m.cur].Data = data
-			rows[m.cur] = rows[m.cur].Selected(false)
+			info += "audit event not recorded: " + status.auditErr.Error()
+		}
+		if msg.err == nil {
+			m.setRow(msg.i, "✔", info)
+		} else {
+			var s string
+			if info != "" {
+				s = info + ": "
+			}
+			m.setRow(msg.i, "❌", errorStyle.Render(s+msg.err.Error()))
+		}
+		m.done++
+		cmd = waitForDelete(m.events)
+
+	case deleteFinishedMsg:
+		m.delete = false
+		m.cancel()
+	}
+
+	return m, cmd
+}
 
-			m.table = m.table.WithCurrentPage((m.cur / m.table.PageSize()) + 1)
+func (m *deleteViewModel) setRow(i int, mark, status string) {
+	rows := m.table.GetVisibleRows()
 
-			m.cur++
+	data := rows[i].Data
+	data[columnMark] = mark
+	data[columnStatus] = status
+	rows[i].Data = data
+	rows[i] = rows[i].Selected(mark == "")
+
+	m.table = m.table.WithCurrentPage((i / m.table.PageSize()) + 1)
+}
+
+// startDeleting deletes all resources in the background, sending the
+// progress to the events channel, which is closed once done.
+func (m deleteViewModel) startDeleting() deleteViewModel {
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan tea.Msg)
+	m.cancel, m.events = cancel, events
+
+	send := func(msg tea.Msg) {
+		select {
+		case events <- msg:
+		case <-ctx.Done():
 		}
+	}
 
-		cmd = tea.Batch(m.spinner.Tick, sendMsg(deleteNextMsg{}))
+	var (
+		res      = m.res
+		dryRun   = m.dryRun
+		snapshot = m.snapshot
+		auditor  = m.auditor
+	)
 
-	case spinner.TickMsg:
-		m.spinner, cmd = m.spinner.Update(msg)
+	items := make([]deleter.Item, len(res))
+	for i, r := range res {
+		items[i] = deleter.Item{Provider: r.provider, Resource: indexedResource{r.res, i}}
 	}
 
-	return m, cmd
+	opts := append(slices.Clone(m.deleteOpts),
+		// timeouts are handled when deleting each resource, as
+		// snapshots take longer than deletions
+		deleter.WithTimeout(0),
+		deleter.WithRetryable(retryable),
+		deleter.WithDeleteFunc(func(ctx context.Context, _ unused.Provider, r unused.Resource) error {
+			return res[r.(indexedResource).i].delete(ctx, dryRun, snapshot, auditor)
+		}),
+		deleter.OnStart(func(i int, _ deleter.Item) { send(deleteStartMsg{i}) }),
+		deleter.OnRetry(func(i int, _ deleter.Item, attempt int, err error) { send(deleteRetryMsg{i, attempt, err}) }),
+		deleter.OnDone(func(r deleter.Result) { send(deleteDoneMsg{r.Index, r.Err}) }),
+	)
+
+	go func() {
+		deleter.New(opts...).Delete(ctx, items)
+		close(events)
+	}()
+
+	return m
 }
 
-type diskToDelete struct {
-	disk   unused.Disk
-	status *deleteStatus
+func waitForDelete(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-events
+		if !ok {
+			msg = deleteFinishedMsg{}
+		}
+		return deleteEventMsg{events, msg}
+	}
+}
+
+// indexedResource is a resource passed to the deleter along with its
+// index, so that deleting it doesn't need to look it up.
+type indexedResource struct {
+	unused.Resource
+	i int
+}
+
+// snapshotError is an error taking a snapshot which might have been
+// taken anyway, like a timeout waiting for it to complete.
+type snapshotError struct{ err error }
+
+func (e snapshotError) Error() string { return e.err.Error() }
+func (e snapshotError) Unwrap() error { return e.err }
+
+// retryable reports whether a deletion is retried after the given
+// error, which is never the case for snapshot errors as retrying would
+// take another snapshot.
+func retryable(err error) bool {
+	var se snapshotError
+	return !errors.As(err, &se) && deleter.Retryable(err)
+}
+
+type resourceToDelete struct {
+	provider unused.Provider
+	res      unused.Resource
+	status   *deleteStatus
 }
 
 type deleteStatus struct {
-	err  error
-	done bool
+	snapshotID string
+	auditErr   error
 }
 
-func deleteDisk(d *diskToDelete, dryRun bool) tea.Cmd {
-	return func() tea.Msg {
-		if !dryRun {
-			ctx, cancel := context.WithTimeout(context.TODO(), timeout)
-			defer cancel()
+// delete deletes the resource, taking a snapshot of it first if
+// requested and it's a disk. The disk is not deleted if the snapshot
+// fails, and the snapshot is not taken again when retrying: errors
+// after the snapshot might have been taken are not retried. Disk
+// deletions, including dry-run ones, are recorded with the auditor.
+func (r *resourceToDelete) delete(ctx context.Context, dryRun, snapshot bool, auditor *audit.Logger) error {
+	d, isDisk := r.res.(unused.Disk)
+	if dryRun && !isDisk {
+		return nil
+	}
 
-			d.status.err = d.disk.Provider().Delete(ctx, d.disk)
+	if isDisk && snapshot && !dryRun && r.status.snapshotID == "" {
+		s, ok := unused.As[unused.Snapshotter](r.provider)
+		if !ok {
+			return fmt.Errorf("%s provider doesn't support snapshots", r.provider.Name())
 		}
 
-		d.status.done = true
+		ctx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+		defer cancel()
 
-		return deleteNextMsg{}
+		// providers return the snapshot ID when the snapshot was
+		// taken but waiting for it failed
+		id, err := s.Snapshot(ctx, d)
+		if id != "" {
+			r.status.snapshotID = id
+		}
+		if err != nil {
+			err = fmt.Errorf("not deleting disk: %w", err)
+			if id != "" || errors.Is(err, context.DeadlineExceeded) {
+				err = snapshotError{err}
+			}
+			return err
+		}
 	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if isDisk {
+		if auditor != nil {
+			// errors recording the event are shown along with the
+			// deletion result instead of being logged over the UI
+			a := *auditor
+			a.OnError = func(_ audit.Event, err error) { r.status.auditErr = err }
+			auditor = &a
+		}
+		return auditor.Delete(ctx, r.provider, d, dryRun)
+	}
+
+	return unused.DeleteResource(ctx, r.provider, r.res)
 }
 
 var bold = lipgloss.NewStyle().Bold(true)
@@ -167,38 +363,35 @@ func (m deleteViewModel) View() string {
 
 	switch {
 	case m.delete:
-		fmt.Fprintf(sb, "Deleting %d/%d disks from %s %s\n", m.cur+1, len(m.disks), m.provider.Name(), m.provider.Meta().String())
+		fmt.Fprintf(sb, "Deleted %d/%d %s from %s %s\n", m.done, len(m.res), m.kind.Plural(), m.provider.Name(), m.provider.Meta().String())
 
-		sb.WriteString(m.progress.ViewAs(float64(m.cur) / float64(len(m.disks))))
+		sb.WriteString(m.progress.ViewAs(float64(m.done) / float64(len(m.res))))
 		eta := "N/A"
-		if m.cur > 0 {
-			eta = (time.Since(m.start) * time.Duration(len(m.disks)) / time.Duration(m.cur)).Truncate(time.Second).String()
+		if m.done > 0 {
+			eta = (time.Since(m.start) * time.Duration(len(m.res)) / time.Duration(m.done)).Truncate(time.Second).String()
 		}
 
 		sb.WriteString(" ETA " + eta)
 		sb.WriteString("\n")
 
-		if m.cur < len(m.disks) {
-			if s := m.disks[m.cur].status; s != nil {
-				sb.WriteString("➤ ")
-				sb.WriteString(m.disks[m.cur].disk.Name())
-				sb.WriteString(" ")
-				sb.WriteString(m.spinner.View())
-			}
+		if n := m.started - m.done; n > 0 {
+			fmt.Fprintf(sb, "➤ %d in progress %s", n, m.spinner.View())
 		}
 
 		sb.WriteString("\n")
 
-	case m.cur == len(m.disks):
-		fmt.Fprintf(sb, "Deleted %d disks from %s %s\n\n\n", len(m.disks), m.provider.Name(), m.provider.Meta().String())
+	case m.done == len(m.res):
+		fmt.Fprintf(sb, "Deleted %d %s from %s %s\n\n\n", len(m.res), m.kind.Plural(), m.provider.Name(), m.provider.Meta().String())
 
 	default:
-		fmt.Fprintf(sb, "You're about to delete %d disks from %s %s\n\n", len(m.disks), m.provider.Name(), m.provider.Meta())
+		fmt.Fprintf(sb, "You're about to delete %d %s from %s %s\n\n", len(m.res), m.kind.Plural(), m.provider.Name(), m.provider.Meta())
 
 		if m.dryRun {
-			fmt.Fprintln(sb, bold.Render("DISKS WON'T BE DELETED BECAUSE DRY-RUN MODE IS ENABLED"))
+			fmt.Fprintln(sb, bold.Render(strings.ToUpper(m.kind.Plural())+" WON'T BE DELETED BECAUSE DRY-RUN MODE IS ENABLED"))
+		} else if m.snapshot && m.kind == unused.KindDisk {
+			fmt.Fprintln(sb, bold.Render("Press `x` to start snapshotting and deleting the following disks:"))
 		} else {
-			fmt.Fprintln(sb, bold.Render("Press `x` to start deleting the following disks:"))
+			fmt.Fprintln(sb, bold.Render("Press `x` to start deleting the following "+m.kind.Plural()+":"))
 		}
 
 	}
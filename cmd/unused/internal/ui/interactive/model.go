@@ -12,6 +12,8 @@ import (
 	"charm.land/lipgloss/v2"
 	"charm.land/lipgloss/v2/compat"
 	"github.com/grafana/unused"
+	"github.com/grafana/unused/audit"
+	"github.com/grafana/unused/deleter"
 )
 
 const (
@@ -19,6 +21,10 @@ const (
 	minHeight = 30
 
 	timeout = 1 * time.Minute
+
+	// snapshotTimeout is the maximum time to wait for a snapshot to
+	// complete before deleting its disk.
+	snapshotTimeout = 1 * time.Hour
 )
 
 type state int
@@ -26,8 +32,8 @@ type state int
 const (
 	stateProviderList state = iota
 	stateProviderView
-	stateFetchingDisks
-	stateDeletingDisks
+	stateFetchingResources
+	stateDeletingResources
 )
 
 var _ tea.Model = Model{}
@@ -35,9 +41,11 @@ var _ tea.Model = Model{}
 type Model struct {
 	help         help.Model
 	provider     unused.Provider
+	kind         unused.ResourceKind
 	err          error
-	cache        map[unused.Provider]unused.Disks
-	filter       unused.FilterFunc
+	cache        map[unused.Provider]unused.Resources
+	filter       unused.ResourceFilterFunc
+	listOpts     []unused.ListOption
 	extraCols    []string
 	spinner      spinner.Model
 	providerList providerListModel
@@ -47,16 +55,18 @@ type Model struct {
 	w, h         int
 }
 
-func New(providers []unused.Provider, extraColumns []string, filter unused.FilterFunc, dryRun bool) Model {
+func New(providers []unused.Provider, kind unused.ResourceKind, extraColumns []string, filter unused.ResourceFilterFunc, dryRun, snapshot bool, auditor *audit.Logger, deleteOpts []deleter.Option, opts ...unused.ListOption) Model {
 	m := Model{
-		providerList: newProviderListModel(providers),
-		providerView: newProviderViewModel(extraColumns),
-		deleteView:   newDeleteViewModel(dryRun),
-		cache:        make(map[unused.Provider]unused.Disks),
+		providerList: newProviderListModel(providers, kind),
+		providerView: newProviderViewModel(kind, extraColumns),
+		deleteView:   newDeleteViewModel(kind, dryRun, snapshot, auditor, deleteOpts),
+		cache:        make(map[unused.Provider]unused.Resources),
+		kind:         kind,
 		state:        stateProviderList,
 		spinner:      spinner.New(),
 		extraCols:    extraColumns,
 		filter:       filter,
+		listOpts:     opts,
 		help:         newHelp(),
 	}
 
@@ -87,11 +97,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.state = stateProviderList
 				return m, nil
 
-			case stateDeletingDisks:
+			case stateDeletingResources:
+				m.deleteView.Cancel()
 				delete(m.cache, m.provider)
-				m.state = stateFetchingDisks
+				m.state = stateFetchingResources
 				m.providerView = m.providerView.Empty()
-				return m, tea.Batch(m.spinner.Tick, m.loadDisks())
+				return m, tea.Batch(m.spinner.Tick, m.loadResources())
 			}
 
 			return m, nil
@@ -101,30 +112,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.state == stateProviderList {
 			m.provider = msg
 			m.providerView = m.providerView.Empty()
-			m.state = stateFetchingDisks
+			m.state = stateFetchingResources
 
-			return m, tea.Batch(m.spinner.Tick, m.loadDisks())
+			return m, tea.Batch(m.spinner.Tick, m.loadResources())
 		}
 
-	case unused.Disks:
+	case unused.Resources:
 		switch m.state {
-		case stateFetchingDisks:
+		case stateFetchingResources:
 			m.cache[m.provider] = msg
-			m.providerView = m.providerView.WithDisks(msg)
+			m.providerView = m.providerView.WithResources(msg)
 			m.state = stateProviderView
 
 		case stateProviderView:
-			m.deleteView = m.deleteView.WithDisks(m.provider, msg)
-			m.state = stateDeletingDisks
+			m.deleteView = m.deleteView.WithResources(m.provider, msg)
+			m.state = stateDeletingResources
 		}
 
 	case refreshMsg:
 		delete(m.cache, m.provider)
-		m.state = stateFetchingDisks
-		return m, tea.Batch(m.spinner.Tick, m.loadDisks())
+		// the provider might be caching its disks too
+		if c, ok := unused.As[*unused.CachingProvider](m.provider); ok {
+			c.Invalidate()
+		}
+		m.state = stateFetchingResources
+		return m, tea.Batch(m.spinner.Tick, m.loadResources())
 
 	case spinner.TickMsg:
-		if m.state == stateFetchingDisks {
+		if m.state == stateFetchingResources {
 			var cmd tea.Cmd
 			m.spinner, cmd = m.spinner.Update(msg)
 			return m, cmd
@@ -150,7 +165,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case stateProviderView:
 		m.providerView, cmd = m.providerView.Update(msg)
 
-	case stateDeletingDisks:
+	case stateDeletingResources:
 		m.deleteView, cmd = m.deleteView.Update(msg)
 	}
 
@@ -182,10 +197,10 @@ func (m Model) View() tea.View {
 	case stateProviderView:
 		v.Content = m.providerView.View()
 
-	case stateFetchingDisks:
-		v.Content = fmt.Sprintf("Fetching disks for %s %s %s\n", m.provider.Name(), m.provider.Meta().String(), m.spinner.View())
+	case stateFetchingResources:
+		v.Content = fmt.Sprintf("Fetching %s for %s %s %s\n", m.kind.Plural(), m.provider.Name(), m.provider.Meta().String(), m.spinner.View())
 
-	case stateDeletingDisks:
+	case stateDeletingResources:
 		v.Content = m.deleteView.View()
 
 	default:
@@ -195,21 +210,21 @@ func (m Model) View() tea.View {
 	return v
 }
 
-func (m Model) loadDisks() tea.Cmd {
+func (m Model) loadResources() tea.Cmd {
 	return func() tea.Msg {
-		if disks, ok := m.cache[m.provider]; ok {
-			return disks
+		if res, ok := m.cache[m.provider]; ok {
+			return res
 		}
 
 		ctx, cancel := context.WithTimeout(context.TODO(), timeout)
 		defer cancel()
 
-		disks, err := m.provider.ListUnusedDisks(ctx)
+		res, err := unused.ListUnusedResources(ctx, m.provider, m.kind, m.listOpts...)
 		if err != nil {
-			return fmt.Errorf("listing unused disks for %s %s: %w", m.provider.Name(), m.provider.Meta(), err)
+			return fmt.Errorf("listing unused %s for %s %s: %w", m.kind.Plural(), m.provider.Name(), m.provider.Meta(), err)
 		}
 
-		return disks.Filter(m.filter)
+		return res.Filter(m.filter)
 	}
 }
 
@@ -248,5 +263,6 @@ func newHelp() help.Model {
 }
 
 // refreshMsg is a message used to mark that we need to clear the
-// cache for the current provider and reload its unused disks.
+// cache for the current provider, and the provider's own cache if
+// any, and reload its unused resources.
 type refreshMsg struct{}
@@ -0,0 +1,44 @@
+package interactive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/unusedtest"
+)
+
+// countingProvider counts the calls to list disks.
+type countingProvider struct {
+	*unusedtest.Provider
+	lists int
+}
+
+func (p *countingProvider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
+	p.lists++
+	return p.Provider.ListUnusedDisks(ctx)
+}
+
+func TestModelRefreshInvalidatesCachingProvider(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	cp := &countingProvider{Provider: unusedtest.NewProvider("foo", nil, unusedtest.NewDisk("disk-1", nil, now, now))}
+	p := unused.Chain(cp, unused.Caching(time.Hour))
+
+	m := New([]unused.Provider{p}, unused.KindDisk, nil, nil, false, false, nil, nil)
+
+	if _, err := p.ListUnusedDisks(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.Update(refreshMsg{})
+
+	if _, err := p.ListUnusedDisks(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp.lists != 2 {
+		t.Errorf("expecting disks listed again after refreshing, got %d lists", cp.lists)
+	}
+}
@@ -25,14 +25,14 @@ func (i providerItem) Description() string {
 	return i.Provider.Meta().String()
 }
 
-func newProviderList(providers []unused.Provider) list.Model {
+func newProviderList(providers []unused.Provider, kind unused.ResourceKind) list.Model {
 	items := make([]list.Item, len(providers))
 	for i, p := range providers {
 		items[i] = providerItem{p}
 	}
 
 	m := list.New(items, list.NewDefaultDelegate(), 0, 0)
-	m.Title = "Please select which provider to use for checking unused disks"
+	m.Title = "Please select which provider to use for checking unused " + kind.Plural()
 	m.SetFilteringEnabled(false)
 	m.SetShowHelp(false)
 	m.DisableQuitKeybindings()
@@ -48,9 +48,9 @@ type providerListModel struct {
 	w, h int
 }
 
-func newProviderListModel(providers []unused.Provider) providerListModel {
+func newProviderListModel(providers []unused.Provider, kind unused.ResourceKind) providerListModel {
 	return providerListModel{
-		list: newProviderList(providers),
+		list: newProviderList(providers, kind),
 		help: newHelp(),
 		sel:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "select provider")),
 	}
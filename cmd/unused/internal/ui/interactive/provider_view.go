@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"image/color"
 	"slices"
+	"strconv"
 	"strings"
 
 	"charm.land/bubbles/v2/help"
@@ -13,29 +14,50 @@ import (
 	"github.com/evertras/bubble-table/table"
 	"github.com/grafana/unused"
 	"github.com/grafana/unused/cmd/internal"
+	"github.com/grafana/unused/k8s"
 )
 
 const (
-	columnDisk   = "disk"
-	columnName   = "name"
-	columnAge    = "age"
-	columnUnused = "ageUnused"
-	columnSize   = "size"
-	columnType   = "type"
+	columnResource = "resource"
+	columnName     = "name"
+	columnAge      = "age"
+	columnUnused   = "ageUnused"
+	columnSize     = "size"
+	columnType     = "type"
+	columnClass    = "class"
+	columnAddress  = "address"
+	columnSource   = "source"
+	columnOrphaned = "orphaned"
 )
 
 // Custom Kubernetes columns.
 // These constants are copied from the ui package.
 const (
-	KubernetesNS  = "__k8s:ns__"
-	KubernetesPV  = "__k8s:pv__"
-	KubernetesPVC = "__k8s:pvc__"
+	KubernetesCluster      = "__k8s:cluster__"
+	KubernetesNS           = "__k8s:ns__"
+	KubernetesPV           = "__k8s:pv__"
+	KubernetesPVC          = "__k8s:pvc__"
+	KubernetesStorageClass = "__k8s:storageclass__"
+
+	KubernetesVolumeSnapshot = "__k8s:volumesnapshot__"
+	KubernetesPVState        = "__k8s:pvstate__"
+
+	DiskIOPS       = "__disk:iops__"
+	DiskThroughput = "__disk:throughput__"
 )
 
 var k8sHeaders = map[string]string{
-	KubernetesNS:  "Namespace",
-	KubernetesPVC: "PVC",
-	KubernetesPV:  "PV",
+	KubernetesCluster:      "Cluster",
+	KubernetesNS:           "Namespace",
+	KubernetesPVC:          "PVC",
+	KubernetesPV:           "PV",
+	KubernetesStorageClass: "Storage Class",
+
+	KubernetesVolumeSnapshot: "VolumeSnapshot",
+	KubernetesPVState:        "PV State",
+
+	DiskIOPS:       "IOPS",
+	DiskThroughput: "MB/s",
 }
 
 var (
@@ -54,20 +76,39 @@ type providerViewModel struct {
 	selAll    key.Binding
 	unselAll  key.Binding
 	refresh   key.Binding
+	kind      unused.ResourceKind
 	extraCols []string
 	table     table.Model
 	w         int
 	h         int
 }
 
-func newProviderViewModel(extraColumns []string) providerViewModel {
-	// nolint:prealloc
-	cols := []table.Column{
-		table.NewFlexColumn(columnName, "Name", 2).WithStyle(nameStyle),
-		table.NewColumn(columnAge, "Age", 6).WithStyle(ageStyle),
-		table.NewColumn(columnUnused, "Unused", 6).WithStyle(ageStyle),
-		table.NewColumn(columnType, "Type", 6).WithStyle(ageStyle),
-		table.NewColumn(columnSize, "Size (GB)", 10).WithStyle(ageStyle),
+func newProviderViewModel(kind unused.ResourceKind, extraColumns []string) providerViewModel {
+	var cols []table.Column
+	switch kind {
+	case unused.KindAddress:
+		cols = []table.Column{
+			table.NewFlexColumn(columnName, "Name", 2).WithStyle(nameStyle),
+			table.NewColumn(columnAddress, "Address", 16).WithStyle(ageStyle),
+			table.NewColumn(columnAge, "Age", 6).WithStyle(ageStyle),
+		}
+	case unused.KindSnapshot:
+		cols = []table.Column{
+			table.NewFlexColumn(columnName, "Name", 2).WithStyle(nameStyle),
+			table.NewFlexColumn(columnSource, "Source", 2).WithStyle(nameStyle),
+			table.NewColumn(columnOrphaned, "Orphaned", 10).WithStyle(ageStyle),
+			table.NewColumn(columnAge, "Age", 6).WithStyle(ageStyle),
+			table.NewColumn(columnSize, "Size (GB)", 10).WithStyle(ageStyle),
+		}
+	default:
+		cols = []table.Column{
+			table.NewFlexColumn(columnName, "Name", 2).WithStyle(nameStyle),
+			table.NewColumn(columnAge, "Age", 6).WithStyle(ageStyle),
+			table.NewColumn(columnUnused, "Unused", 6).WithStyle(ageStyle),
+			table.NewColumn(columnType, "Type", 6).WithStyle(ageStyle),
+			table.NewColumn(columnClass, "Class", 14).WithStyle(ageStyle),
+			table.NewColumn(columnSize, "Size (GB)", 10).WithStyle(ageStyle),
+		}
 	}
 
 	for _, c := range extraColumns {
@@ -95,8 +136,9 @@ func newProviderViewModel(extraColumns []string) providerViewModel {
 		toggleCur: key.NewBinding(key.WithKeys("*"), key.WithHelp("*", "toggle current page")),
 		selAll:    key.NewBinding(key.WithKeys("A"), key.WithHelp("A", "select all")),
 		unselAll:  key.NewBinding(key.WithKeys("N"), key.WithHelp("N", "deselect all")),
-		refresh:   key.NewBinding(key.WithKeys("g"), key.WithHelp("g", "refresh disks")),
+		refresh:   key.NewBinding(key.WithKeys("g"), key.WithHelp("g", "refresh "+kind.Plural())),
 
+		kind:      kind,
 		extraCols: extraColumns,
 	}
 }
@@ -109,11 +151,11 @@ func (m providerViewModel) Update(msg tea.Msg) (providerViewModel, tea.Cmd) {
 		switch {
 		case key.Matches(msg, m.delete):
 			if rows := m.table.SelectedRows(); len(rows) > 0 {
-				disks := make(unused.Disks, len(rows))
+				res := make(unused.Resources, len(rows))
 				for i, r := range rows {
-					disks[i] = r.Data[columnDisk].(unused.Disk)
+					res[i] = r.Data[columnResource].(unused.Resource)
 				}
-				cmd = sendMsg(disks)
+				cmd = sendMsg(res)
 			}
 
 		case key.Matches(msg, m.toggleCur):
@@ -123,8 +165,8 @@ func (m providerViewModel) Update(msg tea.Msg) (providerViewModel, tea.Cmd) {
 			e := min(s+m.table.PageSize(), len(rows))
 			for i := s; i < e; i++ {
 				rows[i] = rows[i].Selected(!slices.ContainsFunc(sel, func(r table.Row) bool {
-					a := r.Data[columnDisk].(unused.Disk)
-					b := rows[i].Data[columnDisk].(unused.Disk)
+					a := r.Data[columnResource].(unused.Resource)
+					b := rows[i].Data[columnResource].(unused.Resource)
 					return a.ID() == b.ID()
 				}))
 			}
@@ -176,7 +218,7 @@ func (m providerViewModel) FullHelp() [][]key.Binding {
 func (m *providerViewModel) updateTableFooter() {
 	var (
 		t    = m.table
-		sel  = fmt.Sprintf(" %d of %d disks selected", len(t.SelectedRows()), t.TotalRows())
+		sel  = fmt.Sprintf(" %d of %d %s selected", len(t.SelectedRows()), t.TotalRows(), m.kind.Plural())
 		page = fmt.Sprintf("Page %d of %d ", t.CurrentPage(), t.MaxPages())
 		f    = sel + strings.Repeat(" ", m.w-2-len(sel)-len(page)) + page
 	)
@@ -201,29 +243,58 @@ func (m providerViewModel) Empty() providerViewModel {
 	return m
 }
 
-func (m providerViewModel) WithDisks(disks unused.Disks) providerViewModel {
-	rows := make([]table.Row, len(disks))
+func (m providerViewModel) WithResources(res unused.Resources) providerViewModel {
+	rows := make([]table.Row, len(res))
 
-	for i, d := range disks {
+	for i, r := range res {
 		row := table.RowData{
-			columnDisk:   d,
-			columnName:   d.Name(),
-			columnAge:    internal.Age(d.CreatedAt()),
-			columnUnused: internal.Age(d.LastUsedAt()),
-			columnType:   d.DiskType(),
-			columnSize:   d.SizeGB(),
+			columnResource: r,
+			columnName:     r.Name(),
+			columnAge:      internal.Age(r.CreatedAt()),
 		}
 
-		meta := d.Meta()
+		switch r := r.(type) {
+		case unused.Disk:
+			row[columnUnused] = internal.Age(r.LastUsedAt())
+			row[columnType] = r.DiskType()
+			row[columnClass] = r.Class().Type
+			row[columnSize] = r.SizeGB()
+
+		case unused.Address:
+			row[columnAddress] = r.Address()
+
+		case unused.Snapshot:
+			row[columnSource] = r.SourceDiskID()
+			row[columnOrphaned] = r.Orphaned()
+			row[columnSize] = r.SizeGB()
+		}
+
+		meta, k := r.Meta(), unused.KubernetesOf(r)
 		for _, c := range m.extraCols {
 			var v string
 			switch c {
+			case KubernetesCluster:
+				v = k.Cluster
 			case KubernetesNS:
-				v = meta.CreatedForNamespace()
+				v = k.Namespace
 			case KubernetesPV:
-				v = meta.CreatedForPV()
+				v = k.PV
 			case KubernetesPVC:
-				v = meta.CreatedForPVC()
+				v = k.PVC
+			case KubernetesStorageClass:
+				v = k.StorageClass
+			case KubernetesVolumeSnapshot:
+				v = meta.CreatedForVolumeSnapshot()
+			case KubernetesPVState:
+				v = meta[k8s.MetaPVState]
+			case DiskIOPS:
+				if d, ok := r.(unused.Disk); ok && d.Class().IOPS > 0 {
+					v = strconv.FormatInt(d.Class().IOPS, 10)
+				}
+			case DiskThroughput:
+				if d, ok := r.(unused.Disk); ok && d.Class().Throughput > 0 {
+					v = strconv.FormatInt(d.Class().Throughput, 10)
+				}
 			default:
 				v = meta[c]
 			}
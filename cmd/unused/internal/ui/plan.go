@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/plan"
+	"github.com/grafana/unused/policy"
+)
+
+// ErrApplyFailed is returned when deleting any of the disks in a plan
+// failed.
+var ErrApplyFailed = errors.New("some disks failed to be deleted")
+
+// Plan writes a plan to delete the unused disks matching the filters,
+// which are recorded in the plan. When a policy is set only the disks
+// with a delete verdict are planned.
+func Plan(ctx context.Context, ui UI, filters []string) error {
+	if ui.Kind != unused.KindDisk {
+		return fmt.Errorf("planning %s is not supported", ui.Kind.Plural())
+	}
+
+	disks, err := ui.listDeletableDisks(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := plan.New(filters, disks).Write(ui.Out); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Planned %d disks for deletion\n", len(disks)) // nolint:errcheck
+
+	return nil
+}
+
+// listDeletableDisks returns the unused disks matching the filters
+// and, when a policy is set, with a delete verdict.
+func (ui UI) listDeletableDisks(ctx context.Context) (unused.Disks, error) {
+	res, err := ui.listUnusedResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	disks := make(unused.Disks, 0, len(res))
+	for _, r := range res {
+		d := r.(unused.Disk)
+		if ui.Policy != nil && ui.Policy.Evaluate(d).Action != policy.Delete {
+			continue
+		}
+		disks = append(disks, d)
+	}
+
+	return disks, nil
+}
+
+// Apply deletes the disks in the given plan and writes a report with
+// the result for each of them.
+func Apply(ctx context.Context, ui UI, p *plan.Plan) error {
+	r, err := plan.Apply(ctx, p, ui.Providers, ui.DryRun, plan.WithDeleteFunc(ui.Auditor.Delete), plan.WithDeleterOptions(ui.DeleteOptions...))
+	if err != nil {
+		return err
+	}
+
+	if err := r.Write(ui.Out); err != nil {
+		return err
+	}
+
+	if r.Failed() {
+		return ErrApplyFailed
+	}
+
+	return nil
+}
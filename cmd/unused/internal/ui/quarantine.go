@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/cmd/internal"
+	"github.com/grafana/unused/deleter"
+)
+
+// ErrSweepFailed is returned when quarantining or deleting any of the
+// disks failed.
+var ErrSweepFailed = errors.New("some disks failed to be processed")
+
+// Quarantine tags the unused disks matching the filters as quarantined
+// instead of deleting them. When a policy is set only the disks with a
+// delete verdict are quarantined. Disks already quarantined are left
+// untouched.
+func Quarantine(ctx context.Context, ui UI) error {
+	if ui.Kind != unused.KindDisk {
+		return fmt.Errorf("quarantining %s is not supported", ui.Kind.Plural())
+	}
+
+	disks, err := ui.listDeletableDisks(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	return ui.processDisks(disks, func(_ int, d unused.Disk) (string, error) {
+		if _, ok := d.Meta().QuarantinedAt(); ok {
+			return "already quarantined", nil
+		}
+		if ui.DryRun {
+			return "would quarantine", nil
+		}
+		if err := unused.QuarantineDisk(ctx, ui.provider(d), d, now); err != nil {
+			return "", err
+		}
+		return "quarantined", nil
+	})
+}
+
+// Sweep deletes the unused disks matching the filters that were
+// quarantined longer than the grace period. Disks whose quarantine
+// tag was removed, or that are no longer unused, are not deleted.
+func Sweep(ctx context.Context, ui UI, grace time.Duration) error {
+	if ui.Kind != unused.KindDisk {
+		return fmt.Errorf("sweeping %s is not supported", ui.Kind.Plural())
+	}
+
+	res, err := ui.listUnusedResources(ctx)
+	if err != nil {
+		return err
+	}
+
+	var disks unused.Disks
+	for _, r := range res {
+		if at, ok := r.Meta().QuarantinedAt(); ok && time.Since(at) >= grace {
+			disks = append(disks, r.(unused.Disk))
+		}
+	}
+
+	items := make([]deleter.Item, len(disks))
+	for i, d := range disks {
+		items[i] = deleter.Item{Provider: ui.provider(d), Resource: d}
+	}
+
+	opts := append(slices.Clone(ui.DeleteOptions), deleter.WithDeleteFunc(func(ctx context.Context, p unused.Provider, r unused.Resource) error {
+		return ui.Auditor.Delete(ctx, p, r.(unused.Disk), ui.DryRun)
+	}))
+	results := deleter.New(opts...).Delete(ctx, items)
+
+	return ui.processDisks(disks, func(i int, _ unused.Disk) (string, error) {
+		if err := results[i].Err; err != nil {
+			return "", err
+		}
+		if ui.DryRun {
+			return "would delete", nil
+		}
+		return "deleted", nil
+	})
+}
+
+// provider returns the provider the resource was listed with, as
+// wrapped with the configured middlewares, instead of the one returned
+// by its Provider method, which isn't.
+func (ui UI) provider(r unused.Resource) unused.Provider {
+	p := r.Provider()
+	for _, wp := range ui.Providers {
+		if wp.Name() == p.Name() && wp.ID() == p.ID() {
+			return wp
+		}
+	}
+	return p
+}
+
+// processDisks calls fn for each disk and its index, writing a table
+// with the returned status or error.
+func (ui UI) processDisks(disks unused.Disks, fn func(i int, d unused.Disk) (string, error)) error {
+	if len(disks) == 0 {
+		fmt.Fprintln(ui.Out, "No disks found") // nolint:errcheck
+		return nil
+	}
+
+	w := tabwriter.NewWriter(ui.Out, 8, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tDISK\tQUARANTINED\tSTATUS") // nolint:errcheck
+
+	var failed bool
+	for i, d := range disks {
+		quarantined := "-"
+		if at, ok := d.Meta().QuarantinedAt(); ok {
+			quarantined = internal.Age(at)
+		}
+
+		status, err := fn(i, d)
+		if err != nil {
+			status, failed = "failed: "+err.Error(), true
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Provider().Name(), d.Name(), quarantined, status) // nolint:errcheck
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flushing contents: %w", err)
+	}
+
+	if failed {
+		return ErrSweepFailed
+	}
+
+	return nil
+}
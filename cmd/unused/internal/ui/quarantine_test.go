@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/unusedtest"
+)
+
+// recordingProvider records the disks deleted through it.
+type recordingProvider struct {
+	unused.Provider
+	deleted []string
+}
+
+func (p *recordingProvider) Delete(ctx context.Context, d unused.Disk) error {
+	p.deleted = append(p.deleted, d.Name())
+	return p.Provider.Delete(ctx, d)
+}
+
+func (p *recordingProvider) Unwrap() unused.Provider { return p.Provider }
+
+func TestQuarantineAndSweep(t *testing.T) {
+	var (
+		ctx = context.Background()
+		now = time.Now()
+		p   = unusedtest.NewProvider("foo", nil)
+	)
+
+	quarantined := func(name string, ago time.Duration) unused.Disk {
+		d := unusedtest.NewDisk(name, p, now, now)
+		d.SetMeta(unused.Meta{unused.QuarantineKey: strconv.FormatInt(now.Add(-ago).Unix(), 10)})
+		return d
+	}
+
+	p.SetDisks(
+		quarantined("expired", 10*24*time.Hour),
+		quarantined("recent", 24*time.Hour),
+		unusedtest.NewDisk("fresh", p, now, now),
+	)
+
+	names := func() []string {
+		disks, _ := p.ListUnusedDisks(ctx)
+		var ns []string
+		for _, d := range disks {
+			if _, ok := d.Meta().QuarantinedAt(); ok {
+				ns = append(ns, d.Name())
+			}
+		}
+		return ns
+	}
+
+	ui := UI{Kind: unused.KindDisk, Providers: []unused.Provider{p}, Out: &bytes.Buffer{}}
+
+	t.Run("quarantine dry run", func(t *testing.T) {
+		ui := ui
+		ui.DryRun = true
+		if err := Quarantine(ctx, ui); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := names(); len(got) != 2 {
+			t.Errorf("expecting 2 quarantined disks, got %v", got)
+		}
+	})
+
+	t.Run("quarantine", func(t *testing.T) {
+		if err := Quarantine(ctx, ui); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := names(); len(got) != 3 {
+			t.Errorf("expecting 3 quarantined disks, got %v", got)
+		}
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		// disks are deleted with the providers as wrapped by the
+		// middlewares
+		rp := &recordingProvider{Provider: p}
+		ui := ui
+		ui.Providers = []unused.Provider{rp}
+
+		if err := Sweep(ctx, ui, 7*24*time.Hour); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(rp.deleted) != 1 || rp.deleted[0] != "expired" {
+			t.Errorf("expecting expired disk deleted with the wrapped provider, got %v", rp.deleted)
+		}
+
+		disks, _ := p.ListUnusedDisks(ctx)
+		if len(disks) != 2 {
+			t.Fatalf("expecting 2 remaining disks, got %d", len(disks))
+		}
+		for _, d := range disks {
+			if d.Name() == "expired" {
+				t.Errorf("expecting expired disk to be deleted")
+			}
+		}
+	})
+}
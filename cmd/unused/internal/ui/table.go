@@ -4,16 +4,27 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 
+	"github.com/grafana/unused"
 	"github.com/grafana/unused/cmd/internal"
+	"github.com/grafana/unused/k8s"
 )
 
 var k8sHeaders = map[string]string{
-	KubernetesNS:  "K8S_NS",
-	KubernetesPVC: "K8S_PVC",
-	KubernetesPV:  "K8S_PV",
+	KubernetesCluster:      "K8S_CLUSTER",
+	KubernetesNS:           "K8S_NS",
+	KubernetesPVC:          "K8S_PVC",
+	KubernetesPV:           "K8S_PV",
+	KubernetesStorageClass: "K8S_STORAGECLASS",
+
+	KubernetesVolumeSnapshot: "K8S_VOLUMESNAPSHOT",
+	KubernetesPVState:        "K8S_PV_STATE",
+
+	DiskIOPS:       "IOPS",
+	DiskThroughput: "THROUGHPUT_MBPS",
 }
 
 type textWriter interface {
@@ -23,17 +34,28 @@ type textWriter interface {
 }
 
 func text(ctx context.Context, ui UI, w textWriter) error {
-	disks, err := ui.listUnusedDisks(ctx)
+	res, err := ui.listUnusedResources(ctx)
 	if err != nil {
 		return err
 	}
 
-	if len(disks) == 0 {
-		fmt.Println("No disks found")
+	if len(res) == 0 {
+		fmt.Printf("No %s found\n", ui.Kind.Plural())
 		return nil
 	}
 
-	headers := []string{"PROVIDER", "DISK", "AGE", "UNUSED", "TYPE", "SIZE_GB"}
+	var headers []string
+	switch ui.Kind {
+	case unused.KindAddress:
+		headers = []string{"PROVIDER", "ADDRESS", "IP", "AGE"}
+	case unused.KindSnapshot:
+		headers = []string{"PROVIDER", "SNAPSHOT", "SOURCE", "ORPHANED", "AGE", "SIZE_GB"}
+	default:
+		headers = []string{"PROVIDER", "DISK", "AGE", "UNUSED", "TYPE", "CLASS", "SIZE_GB", "MONTHLY_COST"}
+		if ui.Policy != nil {
+			headers = append(headers, "VERDICT", "RULE")
+		}
+	}
 	for _, c := range ui.ExtraColumns {
 		h, ok := k8sHeaders[c]
 		if !ok {
@@ -42,33 +64,92 @@ func text(ctx context.Context, ui UI, w textWriter) error {
 		headers = append(headers, h)
 	}
 	if ui.Verbose {
-		headers = append(headers, "PROVIDER_META", "DISK_META")
+		headers = append(headers, "PROVIDER_META", strings.ToUpper(string(ui.Kind))+"_META")
 	}
 
 	w.Headers(headers)
 
-	for _, d := range disks {
-		p := d.Provider()
+	for _, r := range res {
+		p := r.Provider()
+
+		var row []string
+		switch r := r.(type) {
+		case unused.Disk:
+			cost := "-"
+			if c, ok := ui.Prices.MonthlyCost(r); ok {
+				cost = fmt.Sprintf("%.2f", c)
+			}
+
+			row = []string{
+				p.Name(),
+				r.Name(),
+				internal.Age(r.CreatedAt()),
+				internal.Age(r.LastUsedAt()),
+				string(r.DiskType()),
+				r.Class().Type,
+				fmt.Sprintf("%d", r.SizeGB()),
+				cost,
+			}
 
-		row := []string{
-			p.Name(),
-			d.Name(),
-			internal.Age(d.CreatedAt()),
-			internal.Age(d.LastUsedAt()),
-			string(d.DiskType()),
-			fmt.Sprintf("%d", d.SizeGB()),
+			if ui.Policy != nil {
+				v := ui.Policy.Evaluate(r)
+				rule := v.Rule
+				if rule == "" {
+					rule = "-"
+				}
+				row = append(row, string(v.Action), rule)
+			}
+
+		case unused.Address:
+			row = []string{
+				p.Name(),
+				r.Name(),
+				r.Address(),
+				internal.Age(r.CreatedAt()),
+			}
+
+		case unused.Snapshot:
+			source := r.SourceDiskID()
+			if source == "" {
+				source = "-"
+			}
+
+			row = []string{
+				p.Name(),
+				r.Name(),
+				source,
+				strconv.FormatBool(r.Orphaned()),
+				internal.Age(r.CreatedAt()),
+				strconv.Itoa(r.SizeGB()),
+			}
 		}
 
-		meta := d.Meta()
+		meta, k := r.Meta(), unused.KubernetesOf(r)
 		for _, c := range ui.ExtraColumns {
 			var v string
 			switch c {
+			case KubernetesCluster:
+				v = k.Cluster
 			case KubernetesNS:
-				v = meta.CreatedForNamespace()
+				v = k.Namespace
 			case KubernetesPV:
-				v = meta.CreatedForPV()
+				v = k.PV
 			case KubernetesPVC:
-				v = meta.CreatedForPVC()
+				v = k.PVC
+			case KubernetesStorageClass:
+				v = k.StorageClass
+			case KubernetesVolumeSnapshot:
+				v = meta.CreatedForVolumeSnapshot()
+			case KubernetesPVState:
+				v = meta[k8s.MetaPVState]
+			case DiskIOPS:
+				if d, ok := r.(unused.Disk); ok && d.Class().IOPS > 0 {
+					v = strconv.FormatInt(d.Class().IOPS, 10)
+				}
+			case DiskThroughput:
+				if d, ok := r.(unused.Disk); ok && d.Class().Throughput > 0 {
+					v = strconv.FormatInt(d.Class().Throughput, 10)
+				}
 			default:
 				v = meta[c]
 			}
@@ -79,7 +160,7 @@ func text(ctx context.Context, ui UI, w textWriter) error {
 		}
 
 		if ui.Verbose {
-			row = append(row, p.Meta().String(), d.Meta().String())
+			row = append(row, p.Meta().String(), meta.String())
 		}
 
 		w.AddRow(row)
@@ -9,43 +9,68 @@ import (
 	"time"
 
 	"github.com/grafana/unused"
+	"github.com/grafana/unused/audit"
+	"github.com/grafana/unused/deleter"
+	"github.com/grafana/unused/policy"
+	"github.com/grafana/unused/pricing"
 	"golang.org/x/sync/errgroup"
 )
 
 type Filters struct {
-	Key, Value string
-	MinAge     time.Duration
-	MinUnused  time.Duration
+	Match     unused.ResourceFilterFunc
+	MinAge    time.Duration
+	MinUnused time.Duration
 }
 
 type UI struct {
-	Filters      Filters
-	Group        string
-	Providers    []unused.Provider
-	ExtraColumns []string
-	Verbose      bool
-	DryRun       bool
-	CSV          bool
-	Interactive  bool
-	Out          io.Writer
+	Kind                 unused.ResourceKind
+	Filters              Filters
+	Group                string
+	Providers            []unused.Provider
+	ExtraColumns         []string
+	Verbose              bool
+	DryRun               bool
+	SnapshotBeforeDelete bool
+	SnapshotRetention    time.Duration
+	CSV                  bool
+	Interactive          bool
+	Prices               *pricing.Estimator
+	Policy               *policy.Policy
+	Auditor              *audit.Logger
+	DeleteOptions        []deleter.Option
+	Out                  io.Writer
 }
 
-func (ui UI) Filter(d unused.Disk) bool {
+func (ui UI) Filter(d unused.Disk) bool { return ui.FilterResource(d) }
+
+// FilterResource applies the filters to any kind of resource. The
+// minimum unused time is only checked for disks, as other kinds of
+// resources don't report when they were last used.
+func (ui UI) FilterResource(r unused.Resource) bool {
 	if ui.Filters.MinUnused != 0 {
 		ui.Filters.MinAge = ui.Filters.MinUnused
 	}
 
-	minAge := ui.Filters.MinAge == 0 || time.Since(d.CreatedAt()) >= ui.Filters.MinAge
-	keyVal := ui.Filters.Key == "" || d.Meta().Matches(ui.Filters.Key, ui.Filters.Value)
-	minUnused := ui.Filters.MinUnused == 0 || time.Since(d.LastUsedAt()) >= ui.Filters.MinUnused
+	minAge := ui.Filters.MinAge == 0 || time.Since(r.CreatedAt()) >= ui.Filters.MinAge
+	match := ui.Filters.Match == nil || ui.Filters.Match(r)
+	minUnused := true
+	if d, ok := r.(unused.Disk); ok {
+		minUnused = ui.Filters.MinUnused == 0 || time.Since(d.LastUsedAt()) >= ui.Filters.MinUnused
+	}
 
-	return minAge && keyVal && minUnused
+	return minAge && match && minUnused
 }
 
 func (ui UI) Run(ctx context.Context) error {
 	if ui.Out == nil {
 		ui.Out = os.Stdout
 	}
+	if ui.Prices == nil {
+		ui.Prices = pricing.Default()
+	}
+	if ui.Kind == "" {
+		ui.Kind = unused.KindDisk
+	}
 
 	var display func(ctx context.Context, ui UI) error
 
@@ -63,15 +88,23 @@ func (ui UI) Run(ctx context.Context) error {
 }
 
 const (
-	KubernetesNS  = "__k8s:ns__"
-	KubernetesPV  = "__k8s:pv__"
-	KubernetesPVC = "__k8s:pvc__"
+	KubernetesCluster      = "__k8s:cluster__"
+	KubernetesNS           = "__k8s:ns__"
+	KubernetesPV           = "__k8s:pv__"
+	KubernetesPVC          = "__k8s:pvc__"
+	KubernetesStorageClass = "__k8s:storageclass__"
+
+	KubernetesVolumeSnapshot = "__k8s:volumesnapshot__"
+	KubernetesPVState        = "__k8s:pvstate__"
+
+	DiskIOPS       = "__disk:iops__"
+	DiskThroughput = "__disk:throughput__"
 )
 
-func (ui UI) listUnusedDisks(ctx context.Context) (unused.Disks, error) {
+func (ui UI) listUnusedResources(ctx context.Context) (unused.Resources, error) {
 	var (
 		mu    sync.Mutex
-		total unused.Disks
+		total unused.Resources
 	)
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -82,14 +115,14 @@ func (ui UI) listUnusedDisks(ctx context.Context) (unused.Disks, error) {
 
 	for _, p := range ui.Providers {
 		g.Go(func() error {
-			disks, err := p.ListUnusedDisks(ctx)
+			res, err := unused.ListUnusedResources(ctx, p, ui.Kind, unused.WithSnapshotRetention(ui.SnapshotRetention))
 			if err != nil {
 				return fmt.Errorf("%s %s: %w", p.Name(), p.Meta(), err)
 			}
 
 			mu.Lock()
-			disks = disks.Filter(ui.Filter)
-			total = append(total, disks...)
+			res = res.Filter(ui.FilterResource)
+			total = append(total, res...)
 			mu.Unlock()
 
 			return nil
@@ -97,7 +130,7 @@ func (ui UI) listUnusedDisks(ctx context.Context) (unused.Disks, error) {
 	}
 
 	if err := g.Wait(); err != nil {
-		return nil, fmt.Errorf("listing disks: %w", err)
+		return nil, fmt.Errorf("listing %s: %w", ui.Kind.Plural(), err)
 	}
 
 	return total, nil
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/grafana/unused"
+	"github.com/grafana/unused/filter"
 	"github.com/grafana/unused/unusedtest"
 )
 
@@ -39,46 +40,78 @@ func TestUI_Filter(t *testing.T) {
 	}
 
 	tests := map[string]struct {
-		minAge   time.Duration
-		unused   time.Duration
-		key, val string
-		exp      unused.Disks
+		minAge time.Duration
+		unused time.Duration
+		expr   string
+		exp    unused.Disks
 	}{
-		"no filter": {0, 0, "", "", disks},
+		"no filter": {0, 0, "", disks},
 
-		"minage": {3 * time.Hour, 0, "", "", unused.Disks{foo, bar}},
-		"keyval": {0, 0, "dolor", "sit amet", unused.Disks{bar}},
-		"both":   {2 * time.Hour, 0, "dolor", "", unused.Disks{foo, baz}},
+		"minage": {3 * time.Hour, 0, "", unused.Disks{foo, bar}},
+		"keyval": {0, 0, `dolor=="sit amet"`, unused.Disks{bar}},
+		"both":   {2 * time.Hour, 0, `!has(dolor)`, unused.Disks{foo, baz}},
+		"expr":   {0, 0, `lorem==ipsum && !has(dolor)`, unused.Disks{foo}},
 
-		"!minage": {10 * time.Hour, 0, "", "", nil},
-		"!keyval": {0, 0, "foo", "bar", nil},
-		"!both":   {10 * time.Hour, 0, "foo", "bar", nil},
+		"!minage": {10 * time.Hour, 0, "", nil},
+		"!keyval": {0, 0, `foo==bar`, nil},
+		"!both":   {10 * time.Hour, 0, `foo==bar`, nil},
 
-		"unused":  {0, 1 * time.Hour, "", "", unused.Disks{foo, baz}},
-		"!unused": {0, 6 * time.Hour, "", "", nil},
+		"unused":  {0, 1 * time.Hour, "", unused.Disks{foo, baz}},
+		"!unused": {0, 6 * time.Hour, "", nil},
 
 		// Special case when both MinAge and MinUnused are set
-		"both unused and minage": {3 * time.Hour, 1 * time.Hour, "", "", unused.Disks{foo, baz}},
+		"both unused and minage": {3 * time.Hour, 1 * time.Hour, "", unused.Disks{foo, baz}},
 	}
 
 	for n, tt := range tests {
 		t.Run(n, func(t *testing.T) {
 			opts := UI{
 				Filters: Filters{
-					Key:       tt.key,
-					Value:     tt.val,
 					MinAge:    tt.minAge,
 					MinUnused: tt.unused,
 				},
 			}
 
+			if tt.expr != "" {
+				fn, err := filter.CompileResource(tt.expr)
+				if err != nil {
+					t.Fatalf("unexpected error compiling %q: %v", tt.expr, err)
+				}
+				opts.Filters.Match = fn
+			}
+
 			got := disks.Filter(opts.Filter)
 			if !eq(got, tt.exp) {
 				for _, d := range disks {
-					t.Error(tt.key, tt.val, d.Meta())
+					t.Error(tt.expr, d.Meta())
 				}
 				t.Errorf("slices are not equal\nexp: %v\ngot: %v", tt.exp, got)
 			}
 		})
 	}
 }
+
+func TestUI_FilterResource(t *testing.T) {
+	var (
+		p   = unusedtest.NewProvider("foo", nil)
+		now = time.Now()
+
+		old   = unusedtest.NewAddress("old", "10.0.0.1", p, now.Add(-5*time.Hour))
+		fresh = unusedtest.NewAddress("fresh", "10.0.0.2", p, now.Add(-1*time.Hour))
+	)
+
+	old.SetMeta(unused.Meta{"lorem": "ipsum"})
+
+	opts := UI{Filters: Filters{MinUnused: 3 * time.Hour}}
+	if !opts.FilterResource(old) {
+		t.Error("expecting old address to match min-unused as min-age")
+	}
+	if opts.FilterResource(fresh) {
+		t.Error("expecting fresh address not to match min-unused as min-age")
+	}
+
+	opts = UI{Filters: Filters{Match: func(r unused.Resource) bool { return r.Meta()["lorem"] == "ipsum" }}}
+	if !opts.FilterResource(old) || opts.FilterResource(fresh) {
+		t.Error("expecting only old address to match metadata filter")
+	}
+}
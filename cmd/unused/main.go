@@ -1,10 +1,27 @@
-// unused is a CLI tool to query the given providers for unused disks.
+// unused is a CLI tool to query the given providers for unused disks,
+// IP addresses or snapshots.
 //
 // In its default operation mode it outputs a table listing all the
-// unused disks. I also supports an interactive mode where the user
+// unused disks; use -kind=address or -kind=snapshot to list unused IP
+// addresses or snapshots instead. I also supports an interactive mode where the user
 // can see mark unused disks from the listing tables to individually
 // delete them.
 //
+// The plan subcommand writes a JSON plan with the unused disks
+// matching the given filters instead, which can be reviewed and later
+// deleted with the apply subcommand:
+//
+//	unused plan -gcp.project=foo -filter='k8s:ns=~".*-dev"' -o plan.json
+//	unused apply -gcp.project=foo plan.json
+//
+// The quarantine subcommand tags the unused disks matching the given
+// filters with the current time instead of deleting them, and the
+// sweep subcommand deletes the quarantined disks once their grace
+// period expires, unless the tag was removed in the meantime:
+//
+//	unused quarantine -gcp.project=foo -filter='k8s:ns=~".*-dev"'
+//	unused sweep -gcp.project=foo -grace=7d
+//
 // Provider selection is opinionated, currently accepting the
 // following authentication method for each provider:
 //   - GCP: pass gcp.project with a valid GCP project ID.
@@ -20,39 +37,108 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
-	"strings"
+	"os/user"
+	"slices"
+	"time"
 
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/audit"
 	"github.com/grafana/unused/cmd/internal"
 	"github.com/grafana/unused/cmd/unused/internal/ui"
+	"github.com/grafana/unused/deleter"
+	"github.com/grafana/unused/filter"
+	"github.com/grafana/unused/plan"
+	"github.com/grafana/unused/policy"
+	"github.com/grafana/unused/pricing"
 )
 
 func main() {
+	var cmd string
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "plan", "apply", "quarantine", "sweep":
+			cmd = os.Args[1]
+		}
+	}
+
 	var (
-		gcpProjects, awsProfiles, azureSubs internal.StringSliceFlag
+		k8sContexts   internal.StringSliceFlag
+		k8sKubeconfig string
+
+		stateFile string
+
+		middleware internal.MiddlewareConfig
+
+		pricesFile, policyFile string
+
+		auditFile, auditWebhook, auditLoki, auditLokiTenant, auditOperator string
+
+		deleteConcurrency = deleter.DefaultConcurrency
+		deleteRate        = float64(deleter.DefaultRate)
+		deleteRetries     = deleter.DefaultRetries
+
+		filters     []unused.ResourceFilterFunc
+		planFilters []string
+		outFile     string
+		grace       = 7 * 24 * time.Hour
 
 		out ui.UI
 	)
 
-	internal.ProviderFlags(flag.CommandLine, &gcpProjects, &awsProfiles, &azureSubs)
+	create := internal.ProviderFlags(flag.CommandLine)
+	internal.KubernetesFlags(flag.CommandLine, &k8sContexts, &k8sKubeconfig)
+	internal.StateFlags(flag.CommandLine, &stateFile)
+	internal.MiddlewareFlags(flag.CommandLine, &middleware)
+
+	flag.Func("kind", "Kind of unused resources to list; valid values are: disk, address, snapshot (default disk)", func(s string) error {
+		switch k := unused.ResourceKind(s); k {
+		case unused.KindDisk, unused.KindAddress, unused.KindSnapshot:
+			out.Kind = k
+		default:
+			return errors.New("valid values are disk, address, snapshot")
+		}
+
+		return nil
+	})
+
+	flag.Func("snapshot-retention", "List snapshots older than this as unused even if their source disk exists (ex: 90d)", func(s string) error {
+		dur, err := internal.ParseAge(s)
+		if err != nil {
+			return err
+		}
+
+		out.SnapshotRetention = dur
+
+		return nil
+	})
 
 	flag.BoolVar(&out.Interactive, "i", false, "Interactive UI mode")
 	flag.BoolVar(&out.Verbose, "v", false, "Verbose mode")
 	flag.BoolVar(&out.DryRun, "n", false, "Do not delete disks in interactive mode")
+	flag.BoolVar(&out.SnapshotBeforeDelete, "snapshot-before-delete", false, "Take a snapshot of each disk and wait for it to complete before deleting it in interactive mode")
 	flag.BoolVar(&out.CSV, "csv", false, "Output results in CSV form")
+	flag.StringVar(&pricesFile, "pricing.file", "", "JSON file with disk prices overriding the default ones")
+	flag.StringVar(&policyFile, "policy", "", "YAML policy file; adds the verdict and matching rule of each disk to the output")
 
-	flag.Func("filter", "Filter by disk metadata; use k8s:ns, k8s:pvc or k8s:pv for Kubernetes metadata", func(v string) error {
-		ps := strings.SplitN(v, "=", 2)
-
-		if len(ps) == 0 || ps[0] == "" {
-			return errors.New("invalid filter format")
-		}
+	flag.StringVar(&auditFile, "audit.file", "", "Append an audit event for each disk deletion to this JSONL file")
+	flag.StringVar(&auditWebhook, "audit.webhook", "", "Post an audit event for each disk deletion to this URL")
+	flag.StringVar(&auditLoki, "audit.loki", "", "Push an audit event for each disk deletion to this Loki push URL (ex: http://localhost:3100/loki/api/v1/push)")
+	flag.StringVar(&auditLokiTenant, "audit.loki-tenant", "", "Loki tenant ID for audit events")
+	flag.StringVar(&auditOperator, "audit.operator", currentUser(), "Operator recorded in audit events")
 
-		out.Filters.Key = ps[0]
+	flag.IntVar(&deleteConcurrency, "delete.concurrency", deleteConcurrency, "Maximum number of resources deleted at the same time for each provider")
+	flag.Float64Var(&deleteRate, "delete.rate", deleteRate, "Maximum number of deletions started per second for each provider; 0 disables the limit")
+	flag.IntVar(&deleteRetries, "delete.retries", deleteRetries, "Number of times a throttled or failed deletion is retried")
 
-		if len(ps) == 2 {
-			out.Filters.Value = ps[1]
+	flag.Func("filter", `Filter expression, ex: k8s:ns=~"loki-.*" && type==ssd && !has(keep); can be repeated and all must match`, func(v string) error {
+		fn, err := filter.CompileResource(v)
+		if err != nil {
+			return err
 		}
 
+		filters = append(filters, fn)
+		planFilters = append(planFilters, "-filter="+v)
+
 		return nil
 	})
 
@@ -63,6 +149,7 @@ func main() {
 		}
 
 		out.Filters.MinAge = dur
+		planFilters = append(planFilters, "-min-age="+s)
 
 		return nil
 	})
@@ -74,6 +161,7 @@ func main() {
 		}
 
 		out.Filters.MinUnused = dur
+		planFilters = append(planFilters, "-min-unused="+s)
 
 		return nil
 	})
@@ -83,40 +171,133 @@ func main() {
 		return nil
 	})
 
-	flag.Func("add-k8s-column", "Add Kubernetes metadata column; valid values are: ns, pvc, pv", func(c string) error {
+	flag.Func("add-k8s-column", "Add Kubernetes metadata column; valid values are: cluster, ns, pvc, pv, storageclass, volumesnapshot, pvstate", func(c string) error {
 		switch c {
+		case "cluster":
+			out.ExtraColumns = append(out.ExtraColumns, ui.KubernetesCluster)
 		case "ns":
 			out.ExtraColumns = append(out.ExtraColumns, ui.KubernetesNS)
 		case "pvc":
 			out.ExtraColumns = append(out.ExtraColumns, ui.KubernetesPVC)
 		case "pv":
 			out.ExtraColumns = append(out.ExtraColumns, ui.KubernetesPV)
+		case "storageclass":
+			out.ExtraColumns = append(out.ExtraColumns, ui.KubernetesStorageClass)
+		case "volumesnapshot":
+			out.ExtraColumns = append(out.ExtraColumns, ui.KubernetesVolumeSnapshot)
+		case "pvstate":
+			out.ExtraColumns = append(out.ExtraColumns, ui.KubernetesPVState)
+		default:
+			return errors.New("valid values are cluster, ns, pvc, pv, storageclass, volumesnapshot, pvstate")
+		}
+
+		return nil
+	})
+
+	flag.Func("add-disk-column", "Add disk storage column; valid values are: iops, throughput", func(c string) error {
+		switch c {
+		case "iops":
+			out.ExtraColumns = append(out.ExtraColumns, ui.DiskIOPS)
+		case "throughput":
+			out.ExtraColumns = append(out.ExtraColumns, ui.DiskThroughput)
 		default:
-			return errors.New("valid values are ns, pvc, pv")
+			return errors.New("valid values are iops, throughput")
 		}
 
 		return nil
 	})
 
-	flag.Func("group-by", "Group by disk metadata values; use k8s:ns, k8s:pvc, or k8s:pv for Kubernetes metadata", func(s string) error {
+	flag.Func("group-by", "Group by disk metadata values; use class for the native disk type, region or zone for the disk location, and k8s:cluster, k8s:ns, k8s:pvc, k8s:pv, or k8s:storageclass for Kubernetes objects", func(s string) error {
 		out.Group = s
 		return nil
 	})
 
-	flag.Parse()
+	if cmd != "" {
+		flag.StringVar(&outFile, "o", "", "File to write the "+cmd+" output to (default stdout)")
+	}
+
+	if cmd == "sweep" {
+		flag.Func("grace", "Grace period after which quarantined disks are deleted (default 7d)", func(s string) error {
+			dur, err := internal.ParseAge(s)
+			if err != nil {
+				return err
+			}
+
+			grace = dur
+
+			return nil
+		})
+	}
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [plan|apply|quarantine|sweep] [flags] [plan.json]\n", os.Args[0]) // nolint:errcheck
+		flag.PrintDefaults()
+	}
+
+	args := os.Args[1:]
+	if cmd != "" {
+		args = args[1:]
+	}
+	flag.CommandLine.Parse(args) // nolint:errcheck // exits on error
+
+	if cmd == "apply" && flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if len(filters) > 0 {
+		out.Filters.Match = unused.And(filters...)
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
 	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
 
-	providers, err := internal.CreateProviders(ctx, logger, gcpProjects, awsProfiles, azureSubs)
+	prices, err := pricing.Load(pricesFile)
+	if err != nil {
+		cancel()
+		fmt.Fprintln(os.Stderr, "loading prices:", err)
+		os.Exit(1)
+	}
+	out.Prices = prices
+
+	if policyFile != "" {
+		p, err := policy.Load(policyFile)
+		if err != nil {
+			cancel()
+			fmt.Fprintln(os.Stderr, "loading policy:", err)
+			os.Exit(1)
+		}
+		out.Policy = p
+		planFilters = append(planFilters, "-policy="+policyFile)
+	}
+
+	providers, err := internal.CreateProviders(ctx, logger, create, middleware)
 	if err != nil {
 		cancel()
 		fmt.Fprintln(os.Stderr, "creating providers:", err)
 		os.Exit(1)
 	}
 
+	providers, err = internal.WrapKubernetes(providers, k8sKubeconfig, k8sContexts)
+	if err != nil {
+		cancel()
+		fmt.Fprintln(os.Stderr, "creating providers:", err)
+		os.Exit(1)
+	}
+
+	providers, err = internal.WrapState(providers, stateFile)
+	if err != nil {
+		cancel()
+		fmt.Fprintln(os.Stderr, "creating providers:", err)
+		os.Exit(1)
+	}
+
+	if len(k8sContexts) > 0 && !slices.Contains(out.ExtraColumns, ui.KubernetesPVState) {
+		out.ExtraColumns = append(out.ExtraColumns, ui.KubernetesPVState)
+	}
+
 	if fs := out.Filters; fs.MinUnused != 0 && fs.MinAge != 0 {
 		logger.Warn("Both -min-unused and -min-age used, setting both to same value",
 			slog.Duration("min-unused", fs.MinUnused),
@@ -125,6 +306,41 @@ func main() {
 	}
 
 	out.Providers = providers
+	out.DeleteOptions = []deleter.Option{
+		deleter.WithConcurrency(deleteConcurrency),
+		deleter.WithRate(deleteRate, deleteConcurrency),
+		deleter.WithRetries(deleteRetries, deleter.DefaultMinBackoff, deleter.DefaultMaxBackoff),
+	}
+
+	var sinks []audit.Sink
+	if auditFile != "" {
+		sinks = append(sinks, audit.NewFileSink(auditFile))
+	}
+	if auditWebhook != "" {
+		sinks = append(sinks, audit.NewWebhookSink(auditWebhook, nil))
+	}
+	if auditLoki != "" {
+		sinks = append(sinks, audit.NewLokiSink(auditLoki, auditLokiTenant, nil))
+	}
+	if len(sinks) > 0 {
+		source := cmd
+		if source == "" {
+			source = "interactive"
+		}
+		out.Auditor = audit.NewLogger(auditOperator, source, sinks...)
+		out.Auditor.OnError = func(e audit.Event, err error) {
+			logger.Warn("cannot record audit event", slog.String("provider", e.Provider), slog.String("disk", e.DiskName), slog.Any("error", err))
+		}
+	}
+
+	if cmd != "" {
+		if err := runCommand(ctx, cmd, out, outFile, planFilters, grace); err != nil {
+			cancel() // cleanup resources
+			fmt.Fprintf(os.Stderr, "running %s: %v\n", cmd, err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	if err := out.Run(ctx); err != nil {
 		cancel() // cleanup resources
@@ -132,3 +348,48 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+func runCommand(ctx context.Context, cmd string, out ui.UI, outFile string, filters []string, grace time.Duration) error {
+	if out.Kind == "" {
+		out.Kind = unused.KindDisk
+	}
+
+	out.Out = os.Stdout
+	if outFile != "" {
+		f, err := os.Create(outFile)
+		if err != nil {
+			return fmt.Errorf("creating output file: %w", err)
+		}
+		defer f.Close() // nolint:errcheck
+		out.Out = f
+	}
+
+	switch cmd {
+	case "plan":
+		return ui.Plan(ctx, out, filters)
+	case "quarantine":
+		return ui.Quarantine(ctx, out)
+	case "sweep":
+		return ui.Sweep(ctx, out, grace)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		return fmt.Errorf("opening plan: %w", err)
+	}
+	defer f.Close() // nolint:errcheck
+
+	p, err := plan.Read(f)
+	if err != nil {
+		return err
+	}
+
+	return ui.Apply(ctx, out, p)
+}
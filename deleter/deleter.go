@@ -0,0 +1,300 @@
+// Package deleter deletes unused resources in bulk.
+//
+// A [Deleter] deletes resources concurrently, with a bounded number of
+// deletions in flight and a token bucket rate limit for each provider,
+// retrying throttling and transient errors with exponential backoff.
+package deleter
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/grafana/unused"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/googleapi"
+)
+
+// Default settings of a [Deleter].
+const (
+	DefaultConcurrency = 4
+	DefaultRate        = 5
+	DefaultRetries     = 5
+	DefaultMinBackoff  = time.Second
+	DefaultMaxBackoff  = 30 * time.Second
+	DefaultTimeout     = time.Minute
+)
+
+// Item is a resource to delete with the provider it belongs to.
+type Item struct {
+	Provider unused.Provider
+	Resource unused.Resource
+}
+
+// Result is the outcome of deleting an item.
+type Result struct {
+	// Index is the position of the item in the slice passed to
+	// [Deleter.Delete].
+	Index int
+	Item  Item
+
+	// Attempts is how many times deleting the item was attempted.
+	Attempts int
+	Err      error
+}
+
+// Func deletes a resource with the given provider.
+type Func func(ctx context.Context, p unused.Provider, r unused.Resource) error
+
+// Deleter deletes resources concurrently. It's safe to call Delete from
+// multiple goroutines, but the rate limit only applies to each call.
+type Deleter struct {
+	concurrency int
+	rate        rate.Limit
+	burst       int
+	retries     int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	timeout     time.Duration
+	retryable   func(error) bool
+	delete      Func
+
+	onStart func(i int, it Item)
+	onRetry func(i int, it Item, attempt int, err error)
+	onDone  func(r Result)
+}
+
+// Option configures a [Deleter].
+type Option func(*Deleter)
+
+// WithConcurrency sets how many resources of each provider are
+// deleted at the same time.
+func WithConcurrency(n int) Option {
+	return func(d *Deleter) { d.concurrency = max(n, 1) }
+}
+
+// WithRate sets how many deletions per second are started for each
+// provider, allowing bursts of up to burst deletions. A rate of zero
+// or less disables rate limiting.
+func WithRate(perSecond float64, burst int) Option {
+	return func(d *Deleter) {
+		d.rate, d.burst = rate.Limit(perSecond), max(burst, 1)
+		if perSecond <= 0 {
+			d.rate = rate.Inf
+		}
+	}
+}
+
+// WithRetries sets how many times a failed deletion is retried, waiting
+// an exponentially increasing time between min and max, with jitter,
+// between attempts.
+func WithRetries(n int, min, max time.Duration) Option {
+	return func(d *Deleter) { d.retries, d.minBackoff, d.maxBackoff = n, min, max }
+}
+
+// WithTimeout sets the timeout of each deletion attempt. Zero disables
+// the timeout.
+func WithTimeout(t time.Duration) Option {
+	return func(d *Deleter) { d.timeout = t }
+}
+
+// WithRetryable sets the function deciding whether a deletion error
+// should be retried. By default [Retryable] is used.
+func WithRetryable(fn func(error) bool) Option {
+	return func(d *Deleter) { d.retryable = fn }
+}
+
+// WithDeleteFunc sets the function used to delete resources. By default
+// resources are deleted with [unused.DeleteResource].
+func WithDeleteFunc(fn Func) Option {
+	return func(d *Deleter) { d.delete = fn }
+}
+
+// OnStart sets a function called when an item is about to be deleted
+// for the first time.
+//
+// Progress functions are called from multiple goroutines.
+func OnStart(fn func(i int, it Item)) Option {
+	return func(d *Deleter) { d.onStart = fn }
+}
+
+// OnRetry sets a function called when deleting an item failed and it's
+// going to be retried.
+func OnRetry(fn func(i int, it Item, attempt int, err error)) Option {
+	return func(d *Deleter) { d.onRetry = fn }
+}
+
+// OnDone sets a function called when an item was deleted or deleting
+// it failed for good.
+func OnDone(fn func(r Result)) Option {
+	return func(d *Deleter) { d.onDone = fn }
+}
+
+// New returns a deleter with the given options.
+func New(opts ...Option) *Deleter {
+	d := &Deleter{
+		concurrency: DefaultConcurrency,
+		rate:        DefaultRate,
+		burst:       DefaultConcurrency,
+		retries:     DefaultRetries,
+		minBackoff:  DefaultMinBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+		timeout:     DefaultTimeout,
+		retryable:   Retryable,
+		delete:      unused.DeleteResource,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Delete deletes all items, returning their results in the same order.
+//
+// Items of different providers are deleted independently from each
+// other. When ctx is canceled, pending items fail with its error.
+func (d *Deleter) Delete(ctx context.Context, items []Item) []Result {
+	results := make([]Result, len(items))
+
+	byProvider := make(map[string][]int)
+	var keys []string
+	for i, it := range items {
+		k := it.Provider.Name() + "/" + it.Provider.ID()
+		if _, ok := byProvider[k]; !ok {
+			keys = append(keys, k)
+		}
+		byProvider[k] = append(byProvider[k], i)
+	}
+
+	var wg sync.WaitGroup
+	for _, k := range keys {
+		var (
+			limiter = rate.NewLimiter(d.rate, d.burst)
+			sem     = make(chan struct{}, d.concurrency)
+		)
+
+		for _, i := range byProvider[k] {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					results[i] = Result{Index: i, Item: items[i], Err: ctx.Err()}
+					d.done(results[i])
+					return
+				}
+
+				results[i] = d.deleteItem(ctx, limiter, i, items[i])
+				d.done(results[i])
+			}()
+		}
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (d *Deleter) deleteItem(ctx context.Context, limiter *rate.Limiter, i int, it Item) Result {
+	res := Result{Index: i, Item: it}
+
+	if d.onStart != nil {
+		d.onStart(i, it)
+	}
+
+	backoff := d.minBackoff
+	for {
+		if err := limiter.Wait(ctx); err != nil {
+			res.Err = err
+			return res
+		}
+
+		res.Attempts++
+		res.Err = d.attempt(ctx, it)
+		if res.Err == nil || res.Attempts > d.retries || ctx.Err() != nil || !d.retryable(res.Err) {
+			return res
+		}
+
+		if d.onRetry != nil {
+			d.onRetry(i, it, res.Attempts, res.Err)
+		}
+
+		// full jitter, so that throttled deletions don't retry in lockstep
+		wait := time.Duration(rand.Int64N(int64(backoff) + 1))
+		backoff = min(backoff*2, d.maxBackoff)
+
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return res
+		}
+	}
+}
+
+func (d *Deleter) attempt(ctx context.Context, it Item) error {
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+
+	return d.delete(ctx, it.Provider, it.Resource)
+}
+
+func (d *Deleter) done(r Result) {
+	if d.onDone != nil {
+		d.onDone(r)
+	}
+}
+
+// Retryable reports whether err is a throttling or transient error
+// worth retrying: a timeout, a network error, or a provider API error
+// with status 429 or 5xx or an AWS throttling error code.
+func Retryable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+
+	var code int
+	var (
+		ge *googleapi.Error
+		ae *azcore.ResponseError
+		se interface{ HTTPStatusCode() int }
+	)
+	switch {
+	case errors.As(err, &ge):
+		code = ge.Code
+	case errors.As(err, &ae):
+		code = ae.StatusCode
+	case errors.As(err, &se):
+		code = se.HTTPStatusCode()
+	}
+	if code == http.StatusTooManyRequests || code >= http.StatusInternalServerError {
+		return true
+	}
+
+	// AWS reports throttling with status 400 and an error code
+	var ce interface{ ErrorCode() string }
+	if errors.As(err, &ce) {
+		c := ce.ErrorCode()
+		return strings.Contains(c, "Throttl") || c == "RequestLimitExceeded"
+	}
+
+	return false
+}
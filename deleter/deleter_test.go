@@ -0,0 +1,222 @@
+package deleter_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/aws/smithy-go"
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/deleter"
+	"github.com/grafana/unused/unusedtest"
+	"google.golang.org/api/googleapi"
+)
+
+func newItems(p unused.Provider, n int) []deleter.Item {
+	its := make([]deleter.Item, n)
+	for i := range its {
+		its[i] = deleter.Item{Provider: p, Resource: unusedtest.NewDisk(fmt.Sprintf("disk-%d", i), p, time.Now(), time.Time{})}
+	}
+	return its
+}
+
+func must(disks unused.Disks, err error) unused.Disks {
+	if err != nil {
+		panic(err)
+	}
+	return disks
+}
+
+var errThrottled = &googleapi.Error{Code: http.StatusTooManyRequests}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("default", func(t *testing.T) {
+		p := unusedtest.NewProvider("my-provider", nil)
+		its := newItems(p, 3)
+		for _, it := range its {
+			p.SetDisks(append(must(p.ListUnusedDisks(ctx)), it.Resource.(unused.Disk))...)
+		}
+
+		res := deleter.New().Delete(ctx, its)
+
+		for i, r := range res {
+			if r.Index != i || r.Err != nil || r.Attempts != 1 {
+				t.Errorf("unexpected result %d: %+v", i, r)
+			}
+		}
+		if disks := must(p.ListUnusedDisks(ctx)); len(disks) != 0 {
+			t.Errorf("expecting all disks to be deleted, got %d", len(disks))
+		}
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		var (
+			mu       sync.Mutex
+			attempts = make(map[string]int)
+		)
+
+		its := newItems(unusedtest.NewProvider("my-provider", nil), 3)
+
+		var retries, done atomic.Int32
+		d := deleter.New(
+			deleter.WithRetries(2, time.Millisecond, 2*time.Millisecond),
+			deleter.WithDeleteFunc(func(ctx context.Context, p unused.Provider, r unused.Resource) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				attempts[r.Name()]++
+				switch r.Name() {
+				case "disk-0":
+					if attempts[r.Name()] == 1 {
+						return errThrottled
+					}
+				case "disk-1":
+					return errThrottled
+				case "disk-2":
+					return errors.New("permission denied")
+				}
+				return nil
+			}),
+			deleter.OnRetry(func(int, deleter.Item, int, error) { retries.Add(1) }),
+			deleter.OnDone(func(deleter.Result) { done.Add(1) }),
+		)
+
+		res := d.Delete(ctx, its)
+
+		tests := []struct {
+			attempts int
+			failed   bool
+		}{
+			{2, false}, // succeeds after a retry
+			{3, true},  // retries exhausted
+			{1, true},  // not retryable
+		}
+		for i, tt := range tests {
+			if exp, got := tt.attempts, res[i].Attempts; exp != got {
+				t.Errorf("%d: expecting %d attempts, got %d", i, exp, got)
+			}
+			if exp, got := tt.failed, res[i].Err != nil; exp != got {
+				t.Errorf("%d: expecting failed %t, got %t: %v", i, exp, got, res[i].Err)
+			}
+		}
+
+		if exp, got := int32(3), retries.Load(); exp != got {
+			t.Errorf("expecting %d retries, got %d", exp, got)
+		}
+		if exp, got := int32(3), done.Load(); exp != got {
+			t.Errorf("expecting %d done, got %d", exp, got)
+		}
+	})
+
+	t.Run("concurrency per provider", func(t *testing.T) {
+		var (
+			mu       sync.Mutex
+			inFlight = make(map[string]int)
+			maxSeen  = make(map[string]int)
+		)
+
+		its := append(
+			newItems(unusedtest.NewProvider("a", nil), 10),
+			newItems(unusedtest.NewProvider("b", nil), 10)...)
+
+		d := deleter.New(
+			deleter.WithConcurrency(2),
+			deleter.WithRate(0, 0),
+			deleter.WithDeleteFunc(func(ctx context.Context, p unused.Provider, r unused.Resource) error {
+				mu.Lock()
+				inFlight[p.Name()]++
+				maxSeen[p.Name()] = max(maxSeen[p.Name()], inFlight[p.Name()])
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				inFlight[p.Name()]--
+				mu.Unlock()
+				return nil
+			}),
+		)
+
+		d.Delete(ctx, its)
+
+		for _, name := range []string{"a", "b"} {
+			if got := maxSeen[name]; got != 2 {
+				t.Errorf("%s: expecting at most 2 deletions in flight, got %d", name, got)
+			}
+		}
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		its := newItems(unusedtest.NewProvider("my-provider", nil), 5)
+
+		d := deleter.New(
+			deleter.WithRate(100, 1),
+			deleter.WithDeleteFunc(func(context.Context, unused.Provider, unused.Resource) error { return nil }),
+		)
+
+		start := time.Now()
+		d.Delete(ctx, its)
+
+		// the first deletion uses the burst, the rest wait 10ms each
+		if took := time.Since(start); took < 35*time.Millisecond {
+			t.Errorf("expecting deletions to be rate limited, took %v", took)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		its := newItems(unusedtest.NewProvider("my-provider", nil), 3)
+
+		var called atomic.Bool
+		d := deleter.New(deleter.WithDeleteFunc(func(context.Context, unused.Provider, unused.Resource) error {
+			called.Store(true)
+			return nil
+		}))
+
+		for i, r := range d.Delete(ctx, its) {
+			if !errors.Is(r.Err, context.Canceled) {
+				t.Errorf("%d: expecting context canceled, got %v", i, r.Err)
+			}
+		}
+		if called.Load() {
+			t.Error("expecting no deletions")
+		}
+	})
+}
+
+func TestRetryable(t *testing.T) {
+	tests := map[string]struct {
+		err error
+		exp bool
+	}{
+		"gcp throttled":       {errThrottled, true},
+		"gcp not found":       {&googleapi.Error{Code: http.StatusNotFound}, false},
+		"azure unavailable":   {&azcore.ResponseError{StatusCode: http.StatusServiceUnavailable}, true},
+		"azure conflict":      {&azcore.ResponseError{StatusCode: http.StatusConflict}, false},
+		"aws throttling":      {&smithy.GenericAPIError{Code: "RequestLimitExceeded"}, true},
+		"aws in use":          {&smithy.GenericAPIError{Code: "VolumeInUse"}, false},
+		"timeout":             {fmt.Errorf("deleting: %w", context.DeadlineExceeded), true},
+		"canceled":            {context.Canceled, false},
+		"wrapped throttling":  {fmt.Errorf("deleting: %w", errThrottled), true},
+		"unknown error":       {errors.New("boom"), false},
+		"aws throttling code": {&smithy.GenericAPIError{Code: "ThrottlingException"}, true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := deleter.Retryable(tt.err); tt.exp != got {
+				t.Errorf("expecting %t, got %t", tt.exp, got)
+			}
+		})
+	}
+}
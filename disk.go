@@ -4,16 +4,7 @@ import "time"
 
 // Disk represents an unused disk on a given cloud provider.
 type Disk interface {
-	// ID should return a unique ID for a disk within each cloud
-	// provider.
-	ID() string
-
-	// Provider returns a reference to the provider used to instantiate
-	// this disk
-	Provider() Provider
-
-	// Name returns the disk name.
-	Name() string
+	Resource
 
 	// SizeGB returns the disk size in GB (Azure/GCP) and GiB for AWS.
 	SizeGB() int
@@ -21,17 +12,52 @@ type Disk interface {
 	// SizeBytes returns the disk size in bytes.
 	SizeBytes() float64
 
-	// CreatedAt returns the time when the disk was created.
-	CreatedAt() time.Time
-
 	// LastUsedAt returns the date when the disk was last used.
 	LastUsedAt() time.Time
 
-	// Meta returns the disk metadata.
-	Meta() Meta
-
 	// DiskType returns the normalized type of disk.
 	DiskType() DiskType
+
+	// Class returns the storage class of the disk, as named by the
+	// provider, and its provisioned performance.
+	Class() DiskClass
+
+	// Location returns where the disk is located.
+	Location() Location
+
+	// Kubernetes returns the Kubernetes objects the disk was created
+	// for, if any.
+	Kubernetes() Kubernetes
+}
+
+// Location is the normalized location of a resource.
+type Location struct {
+	// Region is the region of the resource, such as us-east-1 or
+	// westeurope.
+	Region string
+
+	// Zone is the availability zone of the resource, if any. It's
+	// empty for regional resources.
+	Zone string
+}
+
+// Kubernetes identifies the Kubernetes objects a resource was created
+// for. Fields are empty when unknown.
+type Kubernetes struct {
+	Cluster      string
+	Namespace    string
+	PVC          string
+	PV           string
+	StorageClass string
+}
+
+// KubernetesOf returns the Kubernetes objects r was created for, using
+// [Disk.Kubernetes] for disks and the metadata for other resources.
+func KubernetesOf(r Resource) Kubernetes {
+	if d, ok := r.(Disk); ok {
+		return d.Kubernetes()
+	}
+	return r.Meta().Kubernetes()
 }
 
 type DiskType string
@@ -42,4 +68,51 @@ const (
 	Unknown DiskType = "unknown"
 )
 
+// DiskClass describes the storage backing a disk. It's not to be
+// confused with the Kubernetes storage class, see [Kubernetes].
+type DiskClass struct {
+	// Type is the native type of the disk, such as gp3, pd-balanced or
+	// PremiumV2_LRS.
+	Type string
+
+	// Media is the normalized media type of Type.
+	Media DiskType
+
+	// IOPS is the provisioned number of I/O operations per second, or
+	// 0 if the disk type doesn't provision them.
+	IOPS int64
+
+	// Throughput is the provisioned throughput in MB/s, or 0 if the
+	// disk type doesn't provision it.
+	Throughput int64
+
+	// Replicas is how many zones the disk is synchronously replicated
+	// in, each billed like a zonal disk, like 2 for GCP regional
+	// persistent disks, or 0 if the disk type isn't priced by replica.
+	Replicas int
+}
+
+// SharingMetaKey is the metadata key set by providers on disks that
+// can be attached to several instances at once, like Azure shared
+// disks, AWS io2 Multi-Attach volumes, or GCP Hyperdisks in a multiple
+// access mode, holding their sharing mode, like shared, multi-attach
+// or READ_WRITE_MANY. Such disks are only unused when they aren't
+// attached to any instance.
+const SharingMetaKey = "sharing"
+
 const GiBbytes = 1_073_741_824 // 2^30
+
+// UnwrapDisk returns the innermost disk in the chain of wrapped disks.
+//
+// Disks wrapping another disk, for instance to add metadata, should
+// implement an Unwrap() Disk method returning the wrapped one, so that
+// the provider specific implementation can still be retrieved.
+func UnwrapDisk(d Disk) Disk {
+	for {
+		u, ok := d.(interface{ Unwrap() Disk })
+		if !ok {
+			return d
+		}
+		d = u.Unwrap()
+	}
+}
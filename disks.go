@@ -5,8 +5,6 @@ import "sort"
 // Disks is a collection of Disk.
 type Disks []Disk
 
-type FilterFunc func(d Disk) bool
-
 func (d Disks) Filter(fn FilterFunc) Disks {
 	r := make(Disks, 0, len(d))
 	for _, e := range d {
@@ -2,7 +2,8 @@
 // and in some cases, manipulate, unused resources in different Cloud
 // Service Providers (CSPs).
 //
-// Currently only unused disks are supported.
+// Currently unused disks, IP addresses and orphaned snapshots are
+// supported.
 //
 // The following providers are already implemented:
 //   - Google Cloud Platform (GCP)
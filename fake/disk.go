@@ -25,11 +25,14 @@ type Disk struct {
 func (d Disk) CreatedAt() time.Time { return d.createdAt }
 
 // DiskType implements unused.Disk.
-func (d Disk) DiskType() unused.DiskType {
+func (d Disk) DiskType() unused.DiskType { return d.Class().Media }
+
+// Class implements unused.Disk.
+func (d Disk) Class() unused.DiskClass {
 	if d.SizeGB()%5 == 0 {
-		return unused.HDD
+		return unused.DiskClass{Type: "fake-hdd", Media: unused.HDD}
 	}
-	return unused.SSD
+	return unused.DiskClass{Type: "fake-ssd", Media: unused.SSD, IOPS: 3000, Throughput: 125}
 }
 
 // ID implements unused.Disk.
@@ -38,6 +41,9 @@ func (d Disk) ID() string { return strings.ToLower(d.name) }
 // LastUsedAt implements unused.Disk.
 func (d Disk) LastUsedAt() time.Time { return d.lastUsedAt }
 
+// Kind implements unused.Disk.
+func (d Disk) Kind() unused.ResourceKind { return unused.KindDisk }
+
 // Meta implements unused.Disk.
 func (d Disk) Meta() unused.Meta {
 	m := unused.Meta{
@@ -59,6 +65,14 @@ func (d Disk) Meta() unused.Meta {
 	return m
 }
 
+// Location implements unused.Disk.
+func (d Disk) Location() unused.Location {
+	return unused.Location{Region: "fake-region-1", Zone: "fake-region-1a"}
+}
+
+// Kubernetes implements unused.Disk.
+func (d Disk) Kubernetes() unused.Kubernetes { return d.Meta().Kubernetes() }
+
 // Name implements unused.Disk.
 func (d Disk) Name() string { return d.name }
 
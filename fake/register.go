@@ -0,0 +1,48 @@
+//go:build fake
+
+package fake
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+
+	"github.com/grafana/unused"
+)
+
+func init() {
+	unused.Register(unused.Registration{
+		Name:  "Fake",
+		Flags: flags,
+		Match: func(p unused.Provider) bool {
+			_, ok := unused.As[*Provider](p)
+			return ok
+		},
+	})
+}
+
+func flags(fs *flag.FlagSet) unused.CreateFunc {
+	var large, medium, empty bool
+
+	fs.BoolVar(&large, "large", false, "Add a provider with a large number of disks")
+	fs.BoolVar(&medium, "medium", true, "Add a provider with a medium number of disks")
+	fs.BoolVar(&empty, "empty", false, "Add a provider with no unused disks")
+
+	return func(ctx context.Context, logger *slog.Logger) ([]unused.Provider, error) {
+		logger.Warn("Using fake provider")
+
+		var ps []unused.Provider
+
+		if large {
+			ps = append(ps, NewProvider("large", 14+23+36))
+		}
+		if medium {
+			ps = append(ps, NewProvider("medium", 14))
+		}
+		if empty {
+			ps = append(ps, NewProvider("empty", 0))
+		}
+
+		return ps, nil
+	}
+}
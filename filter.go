@@ -0,0 +1,44 @@
+package unused
+
+// FilterFunc returns true when the given disk should be kept.
+type FilterFunc func(d Disk) bool
+
+// ResourceFilterFunc returns true when the given resource should be
+// kept.
+type ResourceFilterFunc func(r Resource) bool
+
+// Disks returns a [FilterFunc] applying fn to disks.
+func (fn ResourceFilterFunc) Disks() FilterFunc {
+	return func(d Disk) bool { return fn(d) }
+}
+
+// And returns a filter matching when all the given filters match. It
+// matches everything when no filters are given.
+func And[F ~func(T) bool, T Resource](fns ...F) F {
+	return func(r T) bool {
+		for _, fn := range fns {
+			if !fn(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a filter matching when any of the given filters match. It
+// matches nothing when no filters are given.
+func Or[F ~func(T) bool, T Resource](fns ...F) F {
+	return func(r T) bool {
+		for _, fn := range fns {
+			if fn(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a filter matching when the given filter doesn't match.
+func Not[F ~func(T) bool, T Resource](fn F) F {
+	return func(r T) bool { return !fn(r) }
+}
@@ -0,0 +1,370 @@
+// Package filter implements a small expression language to filter
+// unused resources.
+//
+// Expressions compare resource fields or metadata against values and
+// can be combined with &&, || and !, as well as grouped in
+// parentheses:
+//
+//	k8s:ns=~"loki-.*" && type==ssd && size_gb>100 && !has(keep)
+//
+// The supported operators are == (or =), !=, =~ and !~ for fully
+// anchored regular expressions, and >, >=, < and <=. Values can be
+// bare words or double quoted strings.
+//
+// The following keys refer to resource fields; any other key refers
+// to resource metadata, and the meta. prefix can be used to refer to
+// metadata keys with the same name as a field:
+//   - name, id, kind, provider
+//   - type: normalized disk type (ssd, hdd or unknown)
+//   - class: native disk type (ex: gp3, pd-balanced, Premium_LRS)
+//   - iops, throughput: provisioned disk IOPS and throughput in MB/s
+//   - size_gb: disk or snapshot size
+//   - age: time since creation (ex: 30d, 36h)
+//   - unused: time since the disk was last used (ex: 30d, 36h)
+//   - quarantined: time since the disk was quarantined (ex: 7d)
+//   - address: IP address
+//   - region, zone: normalized disk location
+//   - k8s:cluster, k8s:ns, k8s:pvc, k8s:pv, k8s:storageclass,
+//     k8s:volumesnapshot: Kubernetes objects
+//
+// Metadata added by the k8s package, like k8s:pv-state, is matched
+// like any other metadata key.
+//
+// Comparing a field that doesn't apply to a resource, like size_gb
+// for an IP address, never matches.
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/unused"
+)
+
+// ErrInvalidExpression is returned when an expression cannot be parsed.
+var ErrInvalidExpression = errors.New("invalid filter expression")
+
+// Compile parses the given expression and returns a filter for disks.
+func Compile(expr string) (unused.FilterFunc, error) {
+	fn, err := CompileResource(expr)
+	if err != nil {
+		return nil, err
+	}
+	return fn.Disks(), nil
+}
+
+// CompileResource parses the given expression and returns a filter
+// for any kind of resource.
+func CompileResource(expr string) (unused.ResourceFilterFunc, error) {
+	ts, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{ts: ts}
+
+	if p.peek().typ == tokenEOF {
+		return nil, fmt.Errorf("%w: empty expression", ErrInvalidExpression)
+	}
+
+	fn, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.typ != tokenEOF {
+		return nil, fmt.Errorf("%w: unexpected %s at offset %d", ErrInvalidExpression, t, t.pos)
+	}
+
+	return fn, nil
+}
+
+type valueType int
+
+const (
+	stringValue valueType = iota
+	numberValue
+	durationValue
+)
+
+// field returns a value for a resource and whether the field applies
+// to it.
+type field struct {
+	typ valueType
+	get func(r unused.Resource) (any, bool)
+}
+
+func metaField(fn func(unused.Meta) string) field {
+	return field{stringValue, func(r unused.Resource) (any, bool) {
+		v := fn(r.Meta())
+		return v, v != ""
+	}}
+}
+
+func kubernetesField(fn func(unused.Kubernetes) string) field {
+	return field{stringValue, func(r unused.Resource) (any, bool) {
+		v := fn(unused.KubernetesOf(r))
+		return v, v != ""
+	}}
+}
+
+func locationField(fn func(unused.Location) string) field {
+	return field{stringValue, func(r unused.Resource) (any, bool) {
+		d, ok := r.(unused.Disk)
+		if !ok {
+			return nil, false
+		}
+		v := fn(d.Location())
+		return v, v != ""
+	}}
+}
+
+// classNumber returns a number of the disk class, which doesn't apply
+// when not provisioned.
+func classNumber(fn func(unused.DiskClass) int64) field {
+	return field{numberValue, func(r unused.Resource) (any, bool) {
+		d, ok := r.(unused.Disk)
+		if !ok {
+			return nil, false
+		}
+		v := fn(d.Class())
+		return float64(v), v != 0
+	}}
+}
+
+var fields = map[string]field{
+	"name":     {stringValue, func(r unused.Resource) (any, bool) { return r.Name(), true }},
+	"id":       {stringValue, func(r unused.Resource) (any, bool) { return r.ID(), true }},
+	"kind":     {stringValue, func(r unused.Resource) (any, bool) { return string(r.Kind()), true }},
+	"provider": {stringValue, func(r unused.Resource) (any, bool) { return r.Provider().Name(), true }},
+
+	"type": {stringValue, func(r unused.Resource) (any, bool) {
+		d, ok := r.(unused.Disk)
+		if !ok {
+			return nil, false
+		}
+		return string(d.DiskType()), true
+	}},
+
+	"class": {stringValue, func(r unused.Resource) (any, bool) {
+		d, ok := r.(unused.Disk)
+		if !ok || d.Class().Type == "" {
+			return nil, false
+		}
+		return d.Class().Type, true
+	}},
+
+	"iops":       classNumber(func(c unused.DiskClass) int64 { return c.IOPS }),
+	"throughput": classNumber(func(c unused.DiskClass) int64 { return c.Throughput }),
+
+	"size_gb": {numberValue, func(r unused.Resource) (any, bool) {
+		s, ok := r.(interface{ SizeGB() int })
+		if !ok {
+			return nil, false
+		}
+		return float64(s.SizeGB()), true
+	}},
+
+	"age": {durationValue, func(r unused.Resource) (any, bool) {
+		if r.CreatedAt().IsZero() {
+			return nil, false
+		}
+		return time.Since(r.CreatedAt()), true
+	}},
+
+	"unused": {durationValue, func(r unused.Resource) (any, bool) {
+		d, ok := r.(unused.Disk)
+		if !ok || d.LastUsedAt().IsZero() {
+			return nil, false
+		}
+		return time.Since(d.LastUsedAt()), true
+	}},
+
+	"quarantined": {durationValue, func(r unused.Resource) (any, bool) {
+		at, ok := r.Meta().QuarantinedAt()
+		if !ok {
+			return nil, false
+		}
+		return time.Since(at), true
+	}},
+
+	"address": {stringValue, func(r unused.Resource) (any, bool) {
+		a, ok := r.(unused.Address)
+		if !ok {
+			return nil, false
+		}
+		return a.Address(), true
+	}},
+
+	"region": locationField(func(l unused.Location) string { return l.Region }),
+	"zone":   locationField(func(l unused.Location) string { return l.Zone }),
+
+	"k8s:cluster":        kubernetesField(func(k unused.Kubernetes) string { return k.Cluster }),
+	"k8s:ns":             kubernetesField(func(k unused.Kubernetes) string { return k.Namespace }),
+	"k8s:pvc":            kubernetesField(func(k unused.Kubernetes) string { return k.PVC }),
+	"k8s:pv":             kubernetesField(func(k unused.Kubernetes) string { return k.PV }),
+	"k8s:storageclass":   kubernetesField(func(k unused.Kubernetes) string { return k.StorageClass }),
+	"k8s:volumesnapshot": metaField(unused.Meta.CreatedForVolumeSnapshot),
+}
+
+func lookup(key string) field {
+	if f, ok := fields[key]; ok {
+		return f
+	}
+
+	key = strings.TrimPrefix(key, "meta.")
+	return field{stringValue, func(r unused.Resource) (any, bool) {
+		v, ok := r.Meta()[key]
+		return v, ok
+	}}
+}
+
+func has(key string) unused.ResourceFilterFunc {
+	f := lookup(key)
+	return func(r unused.Resource) bool {
+		_, ok := f.get(r)
+		return ok
+	}
+}
+
+func compare(key, op, val string) (unused.ResourceFilterFunc, error) {
+	f := lookup(key)
+
+	switch op {
+	case "!=":
+		// negate equality so that missing values are not equal
+		eq, err := compare(key, "==", val)
+		if err != nil {
+			return nil, err
+		}
+		return unused.Not(eq), nil
+
+	case "=~", "!~":
+		re, err := regexp.Compile("^(?:" + val + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", val, err)
+		}
+		match := func(r unused.Resource) bool {
+			v, ok := f.get(r)
+			return ok && re.MatchString(fmt.Sprint(v))
+		}
+		if op == "!~" {
+			return unused.Not[unused.ResourceFilterFunc](match), nil
+		}
+		return match, nil
+	}
+
+	var (
+		want any
+		cmp  func(a, b any) int
+	)
+
+	switch f.typ {
+	case numberValue:
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q for %s", val, key)
+		}
+		want, cmp = n, func(a, b any) int { return compareOrdered(a.(float64), b.(float64)) }
+
+	case durationValue:
+		d, err := ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q for %s", val, key)
+		}
+		want, cmp = d, func(a, b any) int { return compareOrdered(a.(time.Duration), b.(time.Duration)) }
+
+	default:
+		want, cmp = val, compareStrings
+	}
+
+	var test func(c int) bool
+	switch op {
+	case "==", "=":
+		test = func(c int) bool { return c == 0 }
+	case ">":
+		test = func(c int) bool { return c > 0 }
+	case ">=":
+		test = func(c int) bool { return c >= 0 }
+	case "<":
+		test = func(c int) bool { return c < 0 }
+	case "<=":
+		test = func(c int) bool { return c <= 0 }
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", op)
+	}
+
+	return func(r unused.Resource) bool {
+		v, ok := f.get(r)
+		return ok && test(cmp(v, want))
+	}, nil
+}
+
+func compareOrdered[T float64 | time.Duration](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareStrings compares metadata values numerically if both are
+// numbers, otherwise lexicographically.
+func compareStrings(a, b any) int {
+	as, bs := a.(string), b.(string)
+
+	an, aerr := strconv.ParseFloat(as, 64)
+	bn, berr := strconv.ParseFloat(bs, 64)
+	if aerr == nil && berr == nil {
+		return compareOrdered(an, bn)
+	}
+
+	return strings.Compare(as, bs)
+}
+
+// ParseDuration parses Go durations, also accepting days (d) and
+// years (y) as units, as in 1y30d or 36h. It's also used to parse the
+// age flags, so that both accept the same syntax.
+func ParseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, errors.New("empty duration")
+	}
+
+	var d time.Duration
+
+	for _, u := range []struct {
+		unit string
+		dur  time.Duration
+	}{
+		{"y", 365 * 24 * time.Hour},
+		{"d", 24 * time.Hour},
+	} {
+		before, after, found := strings.Cut(s, u.unit)
+		if !found {
+			continue
+		}
+		n, err := strconv.Atoi(before)
+		if err != nil {
+			return 0, fmt.Errorf("parsing %s: %w", u.unit, err)
+		}
+		d += time.Duration(n) * u.dur
+		s = after
+	}
+
+	if s != "" {
+		rest, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, err
+		}
+		d += rest
+	}
+
+	return d, nil
+}
@@ -0,0 +1,147 @@
+package filter_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/filter"
+	"github.com/grafana/unused/unusedtest"
+)
+
+func TestCompile(t *testing.T) {
+	var (
+		now = time.Now()
+		p   = unusedtest.NewProvider("GCP", nil)
+
+		loki = unusedtest.NewDisk("loki-data", p, now.Add(-48*time.Hour), now.Add(-30*time.Hour))
+		mimr = unusedtest.NewDisk("mimir-data", p, now.Add(-2*time.Hour), now.Add(-1*time.Hour))
+		keep = unusedtest.NewDisk("keep-me", p, now.Add(-48*time.Hour), now.Add(-48*time.Hour))
+	)
+
+	loki.SetMeta(unused.Meta{"kubernetes.io/created-for/pvc/namespace": "loki-dev", "team": "logs", "replicas": "10", "k8s:pv-state": "gone"})
+	loki.SetSize(200)
+	loki.SetClass(unused.DiskClass{Type: "pd-extreme", Media: unused.SSD, IOPS: 10000})
+	loki.SetLocation(unused.Location{Region: "us-central1", Zone: "us-central1-a"})
+
+	mimr.SetMeta(unused.Meta{"kubernetes.io/created-for/pvc/namespace": "mimir-dev", "team": "metrics", "replicas": "9"})
+	mimr.SetSize(50)
+	mimr.SetClass(unused.DiskClass{Type: "pd-standard", Media: unused.HDD})
+
+	keep.SetMeta(unused.Meta{"kubernetes.io/created-for/pvc/namespace": "loki-prod", "keep": "", "type": "custom", unused.QuarantineKey: strconv.FormatInt(now.Add(-10*24*time.Hour).Unix(), 10)})
+	keep.SetSize(500)
+	keep.SetDiskType(unused.SSD)
+	keep.SetLocation(unused.Location{Region: "europe-west1", Zone: "europe-west1-b"})
+
+	disks := unused.Disks{loki, mimr, keep}
+
+	tests := map[string][]string{
+		`k8s:ns=~"loki-.*" && type==ssd && size_gb>100 && !has(keep)`: {"loki-data"},
+
+		`name==loki-data`:              {"loki-data"},
+		`name="loki-data"`:             {"loki-data"},
+		`name!=loki-data`:              {"mimir-data", "keep-me"},
+		`name=~".*-data"`:              {"loki-data", "mimir-data"},
+		`name!~".*-data"`:              {"keep-me"},
+		`name=~loki`:                   nil,
+		`team==logs || team==metrics`:  {"loki-data", "mimir-data"},
+		`team!=logs`:                   {"mimir-data", "keep-me"},
+		`!(team==logs || has(keep))`:   {"mimir-data"},
+		`size_gb>=200 && size_gb<=200`: {"loki-data"},
+		`size_gb<100`:                  {"mimir-data"},
+		`age>1d`:                       {"loki-data", "keep-me"},
+		`unused<36h`:                   {"loki-data", "mimir-data"},
+		`replicas>9`:                   {"loki-data"},
+		`type==custom`:                 nil,
+		`meta.type==custom`:            {"keep-me"},
+		`provider==GCP && kind==disk`:  {"loki-data", "mimir-data", "keep-me"},
+		`has(k8s:ns) && !has(team)`:    {"keep-me"},
+		`k8s:pv-state==gone`:           {"loki-data"},
+		`region==us-central1`:          {"loki-data"},
+		`class=~"pd-.*"`:               {"loki-data", "mimir-data"},
+		`iops>=10000`:                  {"loki-data"},
+		`has(iops)`:                    {"loki-data"},
+		`!has(throughput)`:             {"loki-data", "mimir-data", "keep-me"},
+		`zone=~"europe-.*"`:            {"keep-me"},
+		`!has(zone)`:                   {"mimir-data"},
+		`quarantined>7d`:               {"keep-me"},
+		`quarantined<7d`:               nil,
+		`team==logs && (size_gb>1000 || type==ssd)`: {"loki-data"},
+	}
+
+	for expr, exp := range tests {
+		t.Run(expr, func(t *testing.T) {
+			fn, err := filter.Compile(expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := disks.Filter(fn)
+			if len(got) != len(exp) {
+				t.Fatalf("expecting %v, got %v", exp, got)
+			}
+			for i, d := range got {
+				if d.Name() != exp[i] {
+					t.Errorf("expecting %v, got %v", exp, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileResource(t *testing.T) {
+	var (
+		now  = time.Now()
+		p    = unusedtest.NewProvider("AWS", nil)
+		addr = unusedtest.NewAddress("ingress", "198.51.100.2", p, now)
+	)
+
+	tests := map[string]bool{
+		`address=~"198\\.51\\..*"`: true,
+		`kind==address`:            true,
+		`size_gb<100`:              false,
+		`!(size_gb<100)`:           true,
+		`type!=ssd`:                true,
+	}
+
+	for expr, exp := range tests {
+		t.Run(expr, func(t *testing.T) {
+			fn, err := filter.CompileResource(expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := fn(addr); got != exp {
+				t.Errorf("expecting %v, got %v", exp, got)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`name`,
+		`name==`,
+		`name==foo &&`,
+		`(name==foo`,
+		`name==foo)`,
+		`name=="foo`,
+		`name ~ foo`,
+		`has(`,
+		`name=~"("`,
+		`size_gb>big`,
+		`age>soon`,
+		`name==foo bar==baz`,
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			_, err := filter.Compile(expr)
+			if !errors.Is(err, filter.ErrInvalidExpression) {
+				t.Fatalf("expecting error %v, got %v", filter.ErrInvalidExpression, err)
+			}
+		})
+	}
+}
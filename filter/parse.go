@@ -0,0 +1,268 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/grafana/unused"
+)
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	typ tokenType
+	val string
+	pos int
+}
+
+func (t token) String() string {
+	if t.typ == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.val)
+}
+
+// operators is sorted so that longer operators are matched first.
+var operators = []string{"==", "!=", "=~", "!~", ">=", "<=", ">", "<", "="}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.:/-", r)
+}
+
+func lex(s string) ([]token, error) {
+	var ts []token
+
+	for i := 0; i < len(s); {
+		r, w := utf8.DecodeRuneInString(s[i:])
+
+		switch {
+		case unicode.IsSpace(r):
+			i += w
+			continue
+
+		case r == '(':
+			ts = append(ts, token{tokenLParen, "(", i})
+			i++
+			continue
+
+		case r == ')':
+			ts = append(ts, token{tokenRParen, ")", i})
+			i++
+			continue
+
+		case strings.HasPrefix(s[i:], "&&"):
+			ts = append(ts, token{tokenAnd, "&&", i})
+			i += 2
+			continue
+
+		case strings.HasPrefix(s[i:], "||"):
+			ts = append(ts, token{tokenOr, "||", i})
+			i += 2
+			continue
+
+		case r == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("%w: unterminated string at offset %d", ErrInvalidExpression, i)
+			}
+			v, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid string at offset %d: %w", ErrInvalidExpression, i, err)
+			}
+			ts = append(ts, token{tokenString, v, i})
+			i = j + 1
+			continue
+
+		case isWordRune(r):
+			j := i
+			for j < len(s) {
+				r, w := utf8.DecodeRuneInString(s[j:])
+				if !isWordRune(r) {
+					break
+				}
+				j += w
+			}
+			ts = append(ts, token{tokenWord, s[i:j], i})
+			i = j
+			continue
+		}
+
+		op := ""
+		for _, o := range operators {
+			if strings.HasPrefix(s[i:], o) {
+				op = o
+				break
+			}
+		}
+
+		switch {
+		case op != "":
+			ts = append(ts, token{tokenOp, op, i})
+			i += len(op)
+
+		case r == '!':
+			ts = append(ts, token{tokenNot, "!", i})
+			i++
+
+		default:
+			return nil, fmt.Errorf("%w: unexpected character %q at offset %d", ErrInvalidExpression, r, i)
+		}
+	}
+
+	return append(ts, token{tokenEOF, "", len(s)}), nil
+}
+
+// parser is a recursive descent parser for the following grammar:
+//
+//	expr    = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | primary
+//	primary = "(" expr ")" | "has" "(" key ")" | key op value
+type parser struct {
+	ts  []token
+	cur int
+}
+
+func (p *parser) peek() token { return p.ts[p.cur] }
+
+func (p *parser) next() token {
+	t := p.ts[p.cur]
+	if t.typ != tokenEOF {
+		p.cur++
+	}
+	return t
+}
+
+func (p *parser) expect(typ tokenType, what string) (token, error) {
+	t := p.next()
+	if t.typ != typ {
+		return t, fmt.Errorf("%w: expecting %s, got %s at offset %d", ErrInvalidExpression, what, t, t.pos)
+	}
+	return t, nil
+}
+
+func (p *parser) parseExpr() (unused.ResourceFilterFunc, error) {
+	fn, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	fns := []unused.ResourceFilterFunc{fn}
+	for p.peek().typ == tokenOr {
+		p.next()
+		fn, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		fns = append(fns, fn)
+	}
+
+	if len(fns) == 1 {
+		return fns[0], nil
+	}
+	return unused.Or(fns...), nil
+}
+
+func (p *parser) parseAnd() (unused.ResourceFilterFunc, error) {
+	fn, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	fns := []unused.ResourceFilterFunc{fn}
+	for p.peek().typ == tokenAnd {
+		p.next()
+		fn, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		fns = append(fns, fn)
+	}
+
+	if len(fns) == 1 {
+		return fns[0], nil
+	}
+	return unused.And(fns...), nil
+}
+
+func (p *parser) parseUnary() (unused.ResourceFilterFunc, error) {
+	if p.peek().typ == tokenNot {
+		p.next()
+		fn, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unused.Not(fn), nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (unused.ResourceFilterFunc, error) {
+	t := p.next()
+
+	switch t.typ {
+	case tokenLParen:
+		fn, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, `")"`); err != nil {
+			return nil, err
+		}
+		return fn, nil
+
+	case tokenWord, tokenString:
+		if t.typ == tokenWord && t.val == "has" && p.peek().typ == tokenLParen {
+			p.next()
+			k := p.next()
+			if k.typ != tokenWord && k.typ != tokenString {
+				return nil, fmt.Errorf("%w: expecting key, got %s at offset %d", ErrInvalidExpression, k, k.pos)
+			}
+			if _, err := p.expect(tokenRParen, `")"`); err != nil {
+				return nil, err
+			}
+			return has(k.val), nil
+		}
+
+		op, err := p.expect(tokenOp, "operator")
+		if err != nil {
+			return nil, err
+		}
+
+		v := p.next()
+		if v.typ != tokenWord && v.typ != tokenString {
+			return nil, fmt.Errorf("%w: expecting value, got %s at offset %d", ErrInvalidExpression, v, v.pos)
+		}
+
+		fn, err := compare(t.val, op.val, v.val)
+		if err != nil {
+			return nil, fmt.Errorf("%w: at offset %d: %w", ErrInvalidExpression, t.pos, err)
+		}
+		return fn, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unexpected %s at offset %d", ErrInvalidExpression, t, t.pos)
+	}
+}
@@ -0,0 +1,58 @@
+package unused_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/unusedtest"
+)
+
+func TestFilterCombinators(t *testing.T) {
+	var (
+		now  = time.Now()
+		disk = unusedtest.NewDisk("disk", nil, now, now)
+
+		yes unused.FilterFunc = func(unused.Disk) bool { return true }
+		no  unused.FilterFunc = func(unused.Disk) bool { return false }
+	)
+
+	tests := map[string]struct {
+		fn  unused.FilterFunc
+		exp bool
+	}{
+		"And()":        {unused.And[unused.FilterFunc](), true},
+		"And(yes)":     {unused.And(yes), true},
+		"And(yes,no)":  {unused.And(yes, no), false},
+		"Or()":         {unused.Or[unused.FilterFunc](), false},
+		"Or(no)":       {unused.Or(no), false},
+		"Or(no,yes)":   {unused.Or(no, yes), true},
+		"Not(yes)":     {unused.Not(yes), false},
+		"Not(And(no))": {unused.Not(unused.And(no)), true},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			if got := tt.fn(disk); got != tt.exp {
+				t.Errorf("expecting %v, got %v", tt.exp, got)
+			}
+		})
+	}
+}
+
+func TestResourceFilterFunc(t *testing.T) {
+	var (
+		now  = time.Now()
+		disk = unusedtest.NewDisk("disk", nil, now, now)
+		addr = unusedtest.NewAddress("addr", "10.0.0.1", nil, now)
+
+		isDisk unused.ResourceFilterFunc = func(r unused.Resource) bool { return r.Kind() == unused.KindDisk }
+	)
+
+	if !unused.Or(isDisk, unused.Not(isDisk))(addr) {
+		t.Error("expecting address to match")
+	}
+	if !isDisk.Disks()(disk) {
+		t.Error("expecting disk to match")
+	}
+}
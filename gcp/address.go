@@ -0,0 +1,45 @@
+package gcp
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/grafana/unused"
+	compute "google.golang.org/api/compute/v1"
+)
+
+var _ unused.Address = &Address{}
+
+// Address holds information about a GCP static IP address.
+type Address struct {
+	StaticAddress *compute.Address
+	provider      *Provider
+	meta          unused.Meta
+}
+
+// ID returns the GCP static address ID.
+func (a *Address) ID() string { return strconv.FormatUint(a.StaticAddress.Id, 10) }
+
+// Provider returns a reference to the provider used to instantiate
+// this address.
+func (a *Address) Provider() unused.Provider { return a.provider }
+
+// Name returns the name of the GCP static address.
+func (a *Address) Name() string { return a.StaticAddress.Name }
+
+// Address returns the IP address of the GCP static address.
+func (a *Address) Address() string { return a.StaticAddress.Address }
+
+// CreatedAt returns the time when the GCP static address was created.
+func (a *Address) CreatedAt() time.Time {
+	// it's safe to assume GCP will send a valid timestamp
+	c, _ := time.Parse(time.RFC3339, a.StaticAddress.CreationTimestamp)
+
+	return c
+}
+
+// Meta returns the address metadata.
+func (a *Address) Meta() unused.Meta { return a.meta }
+
+// Kind returns [unused.KindAddress].
+func (a *Address) Kind() unused.ResourceKind { return unused.KindAddress }
@@ -40,6 +40,9 @@ func (d *Disk) CreatedAt() time.Time {
 // Meta returns the disk metadata.
 func (d *Disk) Meta() unused.Meta { return d.meta }
 
+// Kind returns [unused.KindDisk].
+func (d *Disk) Kind() unused.ResourceKind { return unused.KindDisk }
+
 // LastUsedAt returns the time when the GCP compute disk was last
 // detached.
 func (d *Disk) LastUsedAt() time.Time {
@@ -63,16 +66,60 @@ func (d *Disk) SizeGB() int { return int(d.SizeGb) }
 // SizeBytes returns the size of the GCP compute disk in bytes.
 func (d *Disk) SizeBytes() float64 { return float64(d.SizeGb) * unused.GiBbytes }
 
-// DiskType Type returns the type of the GCP compute disk.
-func (d *Disk) DiskType() unused.DiskType {
-	splitDiskType := strings.Split(d.Type, "/")
-	diskType := splitDiskType[len(splitDiskType)-1]
-	switch diskType {
-	case "pd-ssd":
-		return unused.SSD
-	case "pd-standard":
+// DiskType returns the normalized type of the GCP compute disk.
+func (d *Disk) DiskType() unused.DiskType { return d.Class().Media }
+
+// Class returns the type of the GCP compute disk, its provisioned
+// IOPS and throughput, if any, and the number of zones regional disks
+// are replicated in.
+func (d *Disk) Class() unused.DiskClass {
+	t := d.Type[strings.LastIndexByte(d.Type, '/')+1:]
+
+	return unused.DiskClass{
+		Type:       t,
+		Media:      media(t),
+		IOPS:       d.ProvisionedIops,
+		Throughput: d.ProvisionedThroughput,
+		Replicas:   len(d.ReplicaZones),
+	}
+}
+
+// media returns the normalized media type of a GCP disk type.
+func media(t string) unused.DiskType {
+	switch {
+	case t == "pd-standard", t == "hyperdisk-throughput":
 		return unused.HDD
+	case t == "pd-ssd", t == "pd-balanced", t == "pd-extreme", t == "local-ssd",
+		strings.HasPrefix(t, "hyperdisk-"):
+		return unused.SSD
 	default:
 		return unused.Unknown
 	}
 }
+
+// Location returns the zone of the GCP compute disk and the region it
+// belongs to. Regional disks have no zone; their replica zones are in
+// their metadata.
+func (d *Disk) Location() unused.Location {
+	if d.Region != "" {
+		return unused.Location{Region: lastComponent(d.Region)}
+	}
+
+	zone := lastComponent(d.Zone)
+
+	region := zone
+	if i := strings.LastIndexByte(zone, '-'); i > 0 {
+		region = zone[:i]
+	}
+
+	return unused.Location{Region: region, Zone: zone}
+}
+
+// Kubernetes returns the Kubernetes objects the GCP compute disk was
+// created for, as found in its description, and the GKE cluster from
+// its goog-k8s-cluster-name label.
+func (d *Disk) Kubernetes() unused.Kubernetes {
+	k := d.meta.Kubernetes()
+	k.Cluster = d.Labels["goog-k8s-cluster-name"]
+	return k
+}
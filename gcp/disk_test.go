@@ -68,3 +68,92 @@ func TestDisk(t *testing.T) {
 		}
 	})
 }
+
+func TestSnapshotName(t *testing.T) {
+	ts := time.Date(2021, 7, 16, 5, 55, 0, 0, time.UTC)
+
+	tests := map[string]string{
+		"my-disk": "my-disk-20210716055500",
+		"pvc-c898536e-1601-4357-af13-01bbe82f3055-with-a-very-long-name":   "pvc-c898536e-1601-4357-af13-01bbe82f3055-with-a-20210716055500",
+		"pvc-c898536e-1601-4357-af13-01bbe82f3055-with-a-b-very-long-name": "pvc-c898536e-1601-4357-af13-01bbe82f3055-with-a-20210716055500",
+	}
+
+	for disk, exp := range tests {
+		got := snapshotName(disk, ts)
+		if exp != got {
+			t.Errorf("expecting snapshotName(%q) = %q, got %q", disk, exp, got)
+		}
+		if len(got) > 63 {
+			t.Errorf("snapshot name %q is longer than 63 characters", got)
+		}
+	}
+}
+
+func TestDiskLocation(t *testing.T) {
+	d := &Disk{&compute.Disk{Zone: "https://www.googleapis.com/compute/v1/projects/my-project/zones/us-central1-a"}, nil, nil}
+
+	if exp, got := (unused.Location{Region: "us-central1", Zone: "us-central1-a"}), d.Location(); exp != got {
+		t.Errorf("expecting Location() %v, got %v", exp, got)
+	}
+
+	d = &Disk{&compute.Disk{Region: "https://www.googleapis.com/compute/v1/projects/my-project/regions/europe-west4"}, nil, nil}
+
+	if exp, got := (unused.Location{Region: "europe-west4"}), d.Location(); exp != got {
+		t.Errorf("expecting regional disk Location() %v, got %v", exp, got)
+	}
+}
+
+func TestDiskKubernetes(t *testing.T) {
+	d := &Disk{
+		&compute.Disk{Labels: map[string]string{"goog-k8s-cluster-name": "dev"}},
+		nil,
+		unused.Meta{
+			"kubernetes.io/created-for/pvc/namespace": "ns",
+			"kubernetes.io/created-for/pvc/name":      "pvc",
+			"kubernetes.io/created-for/pv/name":       "pv",
+		},
+	}
+
+	if exp, got := (unused.Kubernetes{Cluster: "dev", Namespace: "ns", PVC: "pvc", PV: "pv"}), d.Kubernetes(); exp != got {
+		t.Errorf("expecting Kubernetes() %v, got %v", exp, got)
+	}
+}
+
+func TestDiskClass(t *testing.T) {
+	tests := map[string]unused.DiskClass{
+		"pd-standard":          {Type: "pd-standard", Media: unused.HDD},
+		"pd-balanced":          {Type: "pd-balanced", Media: unused.SSD},
+		"pd-extreme":           {Type: "pd-extreme", Media: unused.SSD, IOPS: 10000},
+		"hyperdisk-balanced":   {Type: "hyperdisk-balanced", Media: unused.SSD, IOPS: 3000, Throughput: 140},
+		"hyperdisk-throughput": {Type: "hyperdisk-throughput", Media: unused.HDD, Throughput: 180},
+		"foo":                  {Type: "foo", Media: unused.Unknown},
+	}
+
+	for typ, exp := range tests {
+		t.Run(typ, func(t *testing.T) {
+			d := &Disk{&compute.Disk{
+				Type:                  "https://www.googleapis.com/compute/v1/projects/my-project/zones/us-central1-a/diskTypes/" + typ,
+				ProvisionedIops:       exp.IOPS,
+				ProvisionedThroughput: exp.Throughput,
+			}, nil, nil}
+
+			if got := d.Class(); got != exp {
+				t.Errorf("expecting Class() %v, got %v", exp, got)
+			}
+			if got := d.DiskType(); got != exp.Media {
+				t.Errorf("expecting DiskType() %q, got %q", exp.Media, got)
+			}
+		})
+	}
+	t.Run("regional", func(t *testing.T) {
+		d := &Disk{&compute.Disk{
+			Type:         "https://www.googleapis.com/compute/v1/projects/my-project/regions/us-central1/diskTypes/pd-balanced",
+			ReplicaZones: []string{"us-central1-a", "us-central1-b"},
+		}, nil, nil}
+
+		exp := unused.DiskClass{Type: "pd-balanced", Media: unused.SSD, Replicas: 2}
+		if got := d.Class(); got != exp {
+			t.Errorf("expecting Class() %v, got %v", exp, got)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/grafana/unused"
+	"golang.org/x/sync/errgroup"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v3"
+	serviceusage "google.golang.org/api/serviceusage/v1"
+)
+
+// ProjectNameMetaKey is the metadata key holding the display name of
+// discovered projects.
+const ProjectNameMetaKey = "project-name"
+
+const computeService = "compute.googleapis.com"
+
+// serviceLookups is how many projects the state of the Compute Engine
+// API is looked up for concurrently.
+const serviceLookups = 10
+
+// Project is an active GCP project.
+type Project struct {
+	ID     string
+	Name   string
+	Labels map[string]string
+}
+
+// Meta returns the provider metadata identifying the project.
+func (p Project) Meta() unused.Meta {
+	return unused.Meta{"project": p.ID, ProjectNameMetaKey: p.Name}
+}
+
+// ListProjects returns the active projects under the given parent,
+// like organizations/123 or folders/456, and all its subfolders, which
+// have the Compute Engine API enabled and for which match returns
+// true. A nil match returns all of them.
+//
+// Projects for which the state of the Compute Engine API can't be
+// looked up, for instance because of missing permissions, are logged
+// and skipped.
+func ListProjects(ctx context.Context, logger *slog.Logger, rm *cloudresourcemanager.Service, su *serviceusage.Service, parent string, match func(Project) bool) ([]Project, error) {
+	var candidates []Project
+
+	parents := []string{parent}
+	for len(parents) > 0 {
+		parent := parents[0]
+		parents = parents[1:]
+
+		err := rm.Projects.List().Parent(parent).Pages(ctx, func(res *cloudresourcemanager.ListProjectsResponse) error {
+			for _, p := range res.Projects {
+				if p.State != "ACTIVE" {
+					continue
+				}
+				prj := Project{ID: p.ProjectId, Name: p.DisplayName, Labels: p.Labels}
+				if match == nil || match(prj) {
+					candidates = append(candidates, prj)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot list GCP projects in %s: %w", parent, err)
+		}
+
+		err = rm.Folders.List().Parent(parent).Pages(ctx, func(res *cloudresourcemanager.ListFoldersResponse) error {
+			for _, f := range res.Folders {
+				if f.State == "ACTIVE" {
+					parents = append(parents, f.Name)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot list GCP folders in %s: %w", parent, err)
+		}
+	}
+
+	enabled := make([]bool, len(candidates))
+
+	var g errgroup.Group
+	g.SetLimit(serviceLookups)
+	for i, p := range candidates {
+		g.Go(func() error {
+			svc, err := su.Services.Get("projects/" + p.ID + "/services/" + computeService).Context(ctx).Do()
+			if err != nil {
+				logger.Warn("cannot get Compute Engine API state for GCP project, skipping it", slog.String("project", p.ID), slog.String("error", err.Error()))
+				return nil
+			}
+			enabled[i] = svc.State == "ENABLED"
+			return nil
+		})
+	}
+	g.Wait() // nolint:errcheck
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	projects := make([]Project, 0, len(candidates))
+	for i, p := range candidates {
+		if enabled[i] {
+			projects = append(projects, p)
+		}
+	}
+
+	slices.SortFunc(projects, func(a, b Project) int { return strings.Compare(a.ID, b.ID) })
+
+	return projects, nil
+}
+
+// LabelSelector matches sets of labels. It is a comma separated list
+// of requirements which must all be met: key=value, key!=value, key
+// for labels which must be present and !key for labels which must be
+// absent.
+type LabelSelector []labelRequirement
+
+type labelRequirement struct {
+	key, value string
+	hasValue   bool
+	negate     bool
+}
+
+// ParseLabelSelector parses a label selector like env=prod,!temporary.
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	var ls LabelSelector
+
+	for _, req := range strings.Split(s, ",") {
+		req = strings.TrimSpace(req)
+
+		var r labelRequirement
+		switch {
+		case strings.Contains(req, "!="):
+			r.key, r.value, _ = strings.Cut(req, "!=")
+			r.hasValue, r.negate = true, true
+		case strings.Contains(req, "="):
+			r.key, r.value, _ = strings.Cut(req, "=")
+			r.hasValue = true
+		case strings.HasPrefix(req, "!"):
+			r.key, r.negate = req[1:], true
+		default:
+			r.key = req
+		}
+
+		r.key, r.value = strings.TrimSpace(r.key), strings.TrimSpace(r.value)
+		if r.key == "" {
+			return nil, fmt.Errorf("invalid label selector %q: empty label key", s)
+		}
+
+		ls = append(ls, r)
+	}
+
+	return ls, nil
+}
+
+// Matches returns true if the labels meet all the selector
+// requirements.
+func (ls LabelSelector) Matches(labels map[string]string) bool {
+	for _, r := range ls {
+		v, ok := labels[r.key]
+		if r.hasValue {
+			ok = ok && v == r.value
+		}
+		if ok == r.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// ProjectMatcher returns a function matching the projects whose labels
+// match any of the include selectors, or all of them when there are
+// none, and none of the exclude selectors.
+func ProjectMatcher(include, exclude []LabelSelector) func(Project) bool {
+	matches := func(selectors []LabelSelector, p Project) bool {
+		return slices.ContainsFunc(selectors, func(ls LabelSelector) bool { return ls.Matches(p.Labels) })
+	}
+
+	return func(p Project) bool {
+		if len(include) > 0 && !matches(include, p) {
+			return false
+		}
+		return !matches(exclude, p)
+	}
+}
@@ -0,0 +1,151 @@
+package gcp_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/grafana/unused/gcp"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v3"
+	"google.golang.org/api/option"
+	serviceusage "google.golang.org/api/serviceusage/v1"
+)
+
+func TestListProjects(t *testing.T) {
+	ctx := context.Background()
+
+	projects := map[string][]*cloudresourcemanager.Project{
+		"organizations/1": {
+			{ProjectId: "prod-a", DisplayName: "Prod A", State: "ACTIVE", Labels: map[string]string{"env": "prod"}},
+			{ProjectId: "deleted", State: "DELETE_REQUESTED", Labels: map[string]string{"env": "prod"}},
+		},
+		"folders/10": {
+			{ProjectId: "prod-b", DisplayName: "Prod B", State: "ACTIVE", Labels: map[string]string{"env": "prod"}},
+			{ProjectId: "no-compute", State: "ACTIVE", Labels: map[string]string{"env": "prod"}},
+			{ProjectId: "forbidden", State: "ACTIVE", Labels: map[string]string{"env": "prod"}},
+		},
+		"folders/11": {
+			{ProjectId: "dev", State: "ACTIVE", Labels: map[string]string{"env": "dev"}},
+			{ProjectId: "prod-tmp", State: "ACTIVE", Labels: map[string]string{"env": "prod", "temporary": "true"}},
+		},
+	}
+	folders := map[string][]*cloudresourcemanager.Folder{
+		"organizations/1": {{Name: "folders/10", State: "ACTIVE"}},
+		"folders/10":      {{Name: "folders/11", State: "ACTIVE"}, {Name: "folders/12", State: "DELETE_REQUESTED"}},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var res any
+		switch parent := req.URL.Query().Get("parent"); {
+		case req.URL.Path == "/v3/projects":
+			res = &cloudresourcemanager.ListProjectsResponse{Projects: projects[parent]}
+		case req.URL.Path == "/v3/folders":
+			if parent == "folders/12" {
+				t.Errorf("unexpected listing of inactive folder %s", parent)
+			}
+			res = &cloudresourcemanager.ListFoldersResponse{Folders: folders[parent]}
+		case strings.HasSuffix(req.URL.Path, "/services/compute.googleapis.com"):
+			if strings.Contains(req.URL.Path, "/forbidden/") {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			state := "ENABLED"
+			if strings.Contains(req.URL.Path, "/no-compute/") {
+				state = "DISABLED"
+			}
+			res = &serviceusage.GoogleApiServiceusageV1Service{State: state}
+		default:
+			t.Errorf("unexpected request %s", req.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			t.Fatalf("unexpected error encoding response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	rm, err := cloudresourcemanager.NewService(ctx, option.WithoutAuthentication(), option.WithEndpoint(ts.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	su, err := serviceusage.NewService(ctx, option.WithoutAuthentication(), option.WithEndpoint(ts.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	include, err := gcp.ParseLabelSelector("env=prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exclude, err := gcp.ParseLabelSelector("temporary")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := gcp.ListProjects(ctx, slog.New(slog.DiscardHandler), rm, su, "organizations/1", gcp.ProjectMatcher([]gcp.LabelSelector{include}, []gcp.LabelSelector{exclude}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ids []string
+	for _, p := range got {
+		ids = append(ids, p.ID)
+	}
+	if exp := []string{"prod-a", "prod-b"}; !slices.Equal(exp, ids) {
+		t.Errorf("expecting projects %v, got %v", exp, ids)
+	}
+	if exp, got := "Prod A", got[0].Meta()[gcp.ProjectNameMetaKey]; exp != got {
+		t.Errorf("expecting project name %q, got %q", exp, got)
+	}
+
+	all, err := gcp.ListProjects(ctx, slog.New(slog.DiscardHandler), rm, su, "organizations/1", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 4 {
+		t.Errorf("expecting 4 projects with compute enabled, got %v", all)
+	}
+}
+
+func TestLabelSelector(t *testing.T) {
+	labels := map[string]string{"env": "prod", "team": "storage"}
+
+	tests := map[string]bool{
+		"env=prod":          true,
+		"env=dev":           false,
+		"env!=dev":          true,
+		"env!=prod":         false,
+		"team":              true,
+		"owner":             false,
+		"!owner":            true,
+		"!team":             false,
+		"env=prod, team":    true,
+		"env=prod,!team":    false,
+		"owner!=someone":    true,
+		"env=prod,owner=me": false,
+	}
+
+	for s, exp := range tests {
+		ls, err := gcp.ParseLabelSelector(s)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", s, err)
+		}
+		if got := ls.Matches(labels); exp != got {
+			t.Errorf("expecting %q to match %v, got %v", s, exp, got)
+		}
+	}
+
+	for _, s := range []string{"", "env=prod,", "=prod", "!"} {
+		if _, err := gcp.ParseLabelSelector(s); err == nil {
+			t.Errorf("expecting error parsing %q", s)
+		}
+	}
+}
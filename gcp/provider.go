@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/grafana/unused"
 	compute "google.golang.org/api/compute/v1"
@@ -18,7 +20,13 @@ var ProviderName = "GCP"
 // when trying to create a provider.
 var ErrMissingProject = errors.New("missing project id")
 
-var _ unused.Provider = &Provider{}
+var (
+	_ unused.Provider         = &Provider{}
+	_ unused.Snapshotter      = &Provider{}
+	_ unused.AddressProvider  = &Provider{}
+	_ unused.SnapshotProvider = &Provider{}
+	_ unused.Tagger           = &Provider{}
+)
 
 // Provider implements [unused.Provider] for GCP.
 type Provider struct {
@@ -60,7 +68,11 @@ func NewProvider(logger *slog.Logger, svc *compute.Service, project string, meta
 }
 
 // ListUnusedDisks returns all the GCP compute disks that aren't
-// associated to any users, meaning that are not being in use.
+// associated to any users, meaning that are not being in use. Disks
+// in a multiple access mode list all the instances they are attached
+// to as users.
+// Regional disks are returned too, as the aggregated list includes
+// them under the regions scopes.
 func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
 	var disks unused.Disks
 
@@ -72,15 +84,7 @@ func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
 						continue
 					}
 
-					m, err := diskMetadata(d)
-					if err != nil {
-						p.logger.Error("cannot parse disk metadata",
-							slog.String("project", p.project),
-							slog.String("disk", d.Name),
-							slog.String("err", err.Error()),
-						)
-					}
-					disks = append(disks, &Disk{d, p, m})
+					disks = append(disks, &Disk{d, p, diskMetadata(d)})
 				}
 			}
 			return nil
@@ -92,28 +96,331 @@ func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
 	return disks, nil
 }
 
-func diskMetadata(d *compute.Disk) (unused.Meta, error) {
-	m := make(unused.Meta)
+// DescriptionMetaKey is the metadata key holding the description of
+// disks and snapshots when it isn't the JSON object set by Kubernetes.
+const DescriptionMetaKey = "description"
+
+// diskMetadata returns the metadata of the disk. Keys decoded from its
+// description are overridden by its labels, which are in turn
+// overridden by its sharing and location keys.
+func diskMetadata(d *compute.Disk) unused.Meta {
+	m := make(unused.Meta, len(d.Labels)+2)
 
 	// GCP sends Kubernetes metadata as a JSON string in the
-	// Description field.
+	// Description field; other descriptions are kept as is.
 	if d.Description != "" {
 		if err := json.Unmarshal([]byte(d.Description), &m); err != nil {
-			return nil, fmt.Errorf("cannot decode JSON description for disk %s: %w", d.Name, err)
+			clear(m)
+			m[DescriptionMetaKey] = d.Description
 		}
 	}
 
-	// Zone is returned as a URL, remove all but the zone name
-	m["zone"] = d.Zone[strings.LastIndexByte(d.Zone, '/')+1:]
+	for k, v := range d.Labels {
+		m[k] = v
+	}
+
+	if d.AccessMode == "READ_WRITE_MANY" || d.AccessMode == "READ_ONLY_MANY" {
+		m[unused.SharingMetaKey] = d.AccessMode
+	}
+
+	if d.Region != "" {
+		// Regional disks have no zone but are replicated in two
+		// zones of their region, all returned as URLs
+		m[RegionMetaKey] = lastComponent(d.Region)
+		zones := make([]string, len(d.ReplicaZones))
+		for i, z := range d.ReplicaZones {
+			zones[i] = lastComponent(z)
+		}
+		m[ReplicaZonesMetaKey] = strings.Join(zones, ",")
+		// a zone label would otherwise make it look zonal
+		delete(m, "zone")
+	} else {
+		// Zone is returned as a URL, remove all but the zone name
+		m["zone"] = lastComponent(d.Zone)
+	}
+
+	return m
+}
+
+// Metadata keys of regional disks, which have no zone key.
+const (
+	RegionMetaKey       = "region"
+	ReplicaZonesMetaKey = "replica-zones"
+)
+
+// lastComponent returns the last path component of a GCP resource URL,
+// like the zone name of a zone URL.
+func lastComponent(url string) string {
+	return url[strings.LastIndexByte(url, '/')+1:]
+}
+
+// diskLocation returns the zone of the given disk, or its region and
+// true when it's a regional disk. GCP disks are located by the zone or
+// region they embed, as their metadata also holds user labels; other
+// disks by their zone and region metadata.
+func diskLocation(disk unused.Disk) (zone, region string, regional bool) {
+	if d, ok := unused.UnwrapDisk(disk).(*Disk); ok {
+		if d.Region != "" {
+			return "", lastComponent(d.Region), true
+		}
+		return lastComponent(d.Zone), "", false
+	}
 
-	return m, nil
+	m := disk.Meta()
+	if m["zone"] != "" || m[RegionMetaKey] == "" {
+		return m["zone"], "", false
+	}
+	return "", m[RegionMetaKey], true
 }
 
 // Delete deletes the given disk from GCP.
 func (p *Provider) Delete(ctx context.Context, disk unused.Disk) error {
-	_, err := p.svc.Disks.Delete(p.project, disk.Meta()["zone"], disk.Name()).Do()
+	var err error
+	if zone, region, regional := diskLocation(disk); regional {
+		_, err = p.svc.RegionDisks.Delete(p.project, region, disk.Name()).Context(ctx).Do()
+	} else {
+		_, err = p.svc.Disks.Delete(p.project, zone, disk.Name()).Context(ctx).Do()
+	}
 	if err != nil {
 		return fmt.Errorf("cannot delete GCP disk: %w", err)
 	}
 	return nil
 }
+
+// TagDisk sets the given labels on the disk, keeping any other
+// existing labels.
+func (p *Provider) TagDisk(ctx context.Context, disk unused.Disk, tags map[string]string) error {
+	zone, region, regional := diskLocation(disk)
+
+	// labels are replaced as a whole and require the current
+	// fingerprint, so fetch the disk to get both
+	var (
+		d   *compute.Disk
+		err error
+	)
+	if regional {
+		d, err = p.svc.RegionDisks.Get(p.project, region, disk.Name()).Context(ctx).Do()
+	} else {
+		d, err = p.svc.Disks.Get(p.project, zone, disk.Name()).Context(ctx).Do()
+	}
+	if err != nil {
+		return fmt.Errorf("cannot get GCP disk labels: %w", err)
+	}
+
+	labels := make(map[string]string, len(d.Labels)+len(tags))
+	for k, v := range d.Labels {
+		labels[k] = v
+	}
+	for k, v := range tags {
+		labels[k] = v
+	}
+
+	if regional {
+		req := &compute.RegionSetLabelsRequest{Labels: labels, LabelFingerprint: d.LabelFingerprint}
+		_, err = p.svc.RegionDisks.SetLabels(p.project, region, disk.Name(), req).Context(ctx).Do()
+	} else {
+		req := &compute.ZoneSetLabelsRequest{Labels: labels, LabelFingerprint: d.LabelFingerprint}
+		_, err = p.svc.Disks.SetLabels(p.project, zone, disk.Name(), req).Context(ctx).Do()
+	}
+	if err != nil {
+		return fmt.Errorf("cannot set GCP disk labels: %w", err)
+	}
+
+	return nil
+}
+
+// Snapshot creates a snapshot of the given disk and waits until the
+// operation is done, returning the snapshot name.
+func (p *Provider) Snapshot(ctx context.Context, disk unused.Disk) (string, error) {
+	zone, region, regional := diskLocation(disk)
+
+	name := snapshotName(disk.Name(), time.Now())
+	snap := &compute.Snapshot{
+		Name:        name,
+		Description: fmt.Sprintf("Snapshot of %s taken before deleting it", disk.Name()),
+	}
+
+	var (
+		op  *compute.Operation
+		err error
+	)
+	if regional {
+		op, err = p.svc.RegionDisks.CreateSnapshot(p.project, region, disk.Name(), snap).Context(ctx).Do()
+	} else {
+		op, err = p.svc.Disks.CreateSnapshot(p.project, zone, disk.Name(), snap).Context(ctx).Do()
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot create GCP snapshot: %w", err)
+	}
+
+	// Wait returns after at most 2 minutes even if the operation
+	// isn't done yet, so we keep waiting until it is.
+	for op.Status != "DONE" {
+		if regional {
+			op, err = p.svc.RegionOperations.Wait(p.project, region, op.Name).Context(ctx).Do()
+		} else {
+			op, err = p.svc.ZoneOperations.Wait(p.project, zone, op.Name).Context(ctx).Do()
+		}
+		if err != nil {
+			return name, fmt.Errorf("waiting for GCP snapshot %s: %w", name, err)
+		}
+	}
+
+	if op.Error != nil && len(op.Error.Errors) > 0 {
+		return name, fmt.Errorf("creating GCP snapshot %s: %s", name, op.Error.Errors[0].Message)
+	}
+
+	return name, nil
+}
+
+// snapshotName returns a valid GCP resource name for a snapshot of
+// the given disk, by appending a timestamp to the disk name and
+// making sure it doesn't exceed 63 characters.
+func snapshotName(disk string, t time.Time) string {
+	const maxLen = 63
+
+	suffix := "-" + t.UTC().Format("20060102150405")
+	if len(disk)+len(suffix) > maxLen {
+		disk = strings.TrimRight(disk[:maxLen-len(suffix)], "-")
+	}
+
+	return disk + suffix
+}
+
+// globalRegion is the region metadata value used for global static
+// addresses.
+const globalRegion = "global"
+
+// ListUnusedAddresses returns all the GCP static addresses that are
+// reserved but not in use by any resource.
+func (p *Provider) ListUnusedAddresses(ctx context.Context) (unused.Addresses, error) {
+	var addrs unused.Addresses
+
+	err := p.svc.Addresses.AggregatedList(p.project).Filter(`status = "RESERVED"`).Pages(ctx,
+		func(res *compute.AddressAggregatedList) error {
+			for _, item := range res.Items {
+				for _, a := range item.Addresses {
+					if a.Status != "RESERVED" || len(a.Users) > 0 {
+						continue
+					}
+
+					m := make(unused.Meta, len(a.Labels)+3)
+					for k, v := range a.Labels {
+						m[k] = v
+					}
+					m["address-type"] = a.AddressType
+					m["network-tier"] = a.NetworkTier
+					// Region is returned as a URL, remove all but the region name
+					m["region"] = a.Region[strings.LastIndexByte(a.Region, '/')+1:]
+					if a.Region == "" {
+						m["region"] = globalRegion
+					}
+
+					addrs = append(addrs, &Address{a, p, m})
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("listing unused addresses: %w", err)
+	}
+
+	return addrs, nil
+}
+
+// DeleteAddress deletes the given static address from GCP.
+func (p *Provider) DeleteAddress(ctx context.Context, addr unused.Address) error {
+	var err error
+	if region := addr.Meta()["region"]; region == globalRegion {
+		_, err = p.svc.GlobalAddresses.Delete(p.project, addr.Name()).Context(ctx).Do()
+	} else {
+		_, err = p.svc.Addresses.Delete(p.project, region, addr.Name()).Context(ctx).Do()
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete GCP address: %w", err)
+	}
+	return nil
+}
+
+// ListUnusedSnapshots returns the GCP compute snapshots whose source
+// disk no longer exists, or which are older than the given retention.
+func (p *Provider) ListUnusedSnapshots(ctx context.Context, retention time.Duration) (unused.Snapshots, error) {
+	disks := make(map[string]struct{})
+
+	err := p.svc.Disks.AggregatedList(p.project).Fields("items/*/disks/id", "nextPageToken").Pages(ctx,
+		func(res *compute.DiskAggregatedList) error {
+			for _, item := range res.Items {
+				for _, d := range item.Disks {
+					disks[strconv.FormatUint(d.Id, 10)] = struct{}{}
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("listing disks: %w", err)
+	}
+
+	var snaps unused.Snapshots
+
+	err = p.svc.Snapshots.List(p.project).Pages(ctx,
+		func(res *compute.SnapshotList) error {
+			for _, s := range res.Items {
+				snap := &Snapshot{Snapshot: s, provider: p}
+
+				if s.SourceDiskId != "" {
+					_, exists := disks[s.SourceDiskId]
+					snap.orphaned = !exists
+				}
+
+				if !unused.IsUnusedSnapshot(snap, retention) {
+					continue
+				}
+
+				snap.meta = snapshotMetadata(s)
+
+				snaps = append(snaps, snap)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("listing unused snapshots: %w", err)
+	}
+
+	return snaps, nil
+}
+
+// snapshotMetadata returns the metadata of the snapshot, decoded from
+// its description like for disks, and overridden by its labels and
+// source keys.
+func snapshotMetadata(s *compute.Snapshot) unused.Meta {
+	m := make(unused.Meta, len(s.Labels)+2)
+
+	// The CSI driver sends Kubernetes metadata as a JSON string in
+	// the Description field, same as for disks; other descriptions,
+	// like those of snapshots taken by hand, are kept as is.
+	if s.Description != "" {
+		if err := json.Unmarshal([]byte(s.Description), &m); err != nil {
+			clear(m)
+			m[DescriptionMetaKey] = s.Description
+		}
+	}
+
+	for k, v := range s.Labels {
+		m[k] = v
+	}
+
+	// Source disk is returned as a URL, remove all but the disk name
+	m["source-disk"] = s.SourceDisk[strings.LastIndexByte(s.SourceDisk, '/')+1:]
+	m["storage-locations"] = strings.Join(s.StorageLocations, ",")
+
+	return m
+}
+
+// DeleteSnapshot deletes the given snapshot from GCP.
+func (p *Provider) DeleteSnapshot(ctx context.Context, snap unused.Snapshot) error {
+	_, err := p.svc.Snapshots.Delete(p.project, snap.Name()).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("cannot delete GCP snapshot: %w", err)
+	}
+	return nil
+}
@@ -1,16 +1,18 @@
 package gcp_test
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/http/httptest"
-	"regexp"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/unused"
 	"github.com/grafana/unused/gcp"
@@ -74,7 +76,7 @@ func TestProviderListUnusedDisks(t *testing.T) {
 			Items: map[string]compute.DisksScopedList{
 				"foo": {
 					Disks: []*compute.Disk{
-						{Name: "disk-1", Zone: "https://www.googleapis.com/compute/v1/projects/ops-tools-1203/zones/us-central1-a"},
+						{Name: "disk-1", Zone: "https://www.googleapis.com/compute/v1/projects/ops-tools-1203/zones/us-central1-a", Labels: map[string]string{"team": "foo", unused.QuarantineKey: "1700000000"}},
 						{Name: "with-users", Users: []string{"inkel"}},
 						{Name: "disk-2", Zone: "eu-west2-b", Description: `{"kubernetes.io-created-for-pv-name":"pvc-prometheus-1","kubernetes.io-created-for-pvc-name":"prometheus-1","kubernetes.io-created-for-pvc-namespace":"monitoring"}`},
 					},
@@ -109,7 +111,7 @@ func TestProviderListUnusedDisks(t *testing.T) {
 		t.Errorf("expecting %d disks, got %d", exp, got)
 	}
 
-	err = unusedtest.AssertEqualMeta(unused.Meta{"zone": "us-central1-a"}, disks[0].Meta())
+	err = unusedtest.AssertEqualMeta(unused.Meta{"zone": "us-central1-a", "team": "foo", unused.QuarantineKey: "1700000000"}, disks[0].Meta())
 	if err != nil {
 		t.Fatalf("metadata doesn't match: %v", err)
 	}
@@ -153,9 +155,6 @@ func TestProviderListUnusedDisks(t *testing.T) {
 			t.Fatalf("unexpected error creating GCP compute service: %v", err)
 		}
 
-		var buf bytes.Buffer
-		l := slog.New(slog.NewTextHandler(&buf, nil))
-
 		p, err := gcp.NewProvider(l, svc, "my-project", nil)
 		if err != nil {
 			t.Fatal("unexpected error creating provider:", err)
@@ -170,10 +169,525 @@ func TestProviderListUnusedDisks(t *testing.T) {
 			t.Fatalf("expecting 1 unused disk, got %d", len(disks))
 		}
 
-		// check that we logged about it
-		m, _ := regexp.MatchString(`msg="cannot parse disk metadata".+disk=disk-2`, buf.String())
-		if !m {
-			t.Fatal("expecting a log line to be emitted")
+		err = unusedtest.AssertEqualMeta(unused.Meta{"zone": "eu-west2-b", gcp.DescriptionMetaKey: "some string that isn't JSON"}, disks[0].Meta())
+		if err != nil {
+			t.Fatalf("metadata doesn't match: %v", err)
+		}
+	})
+
+	t.Run("labels override description", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			res := &compute.DiskAggregatedList{
+				Items: map[string]compute.DisksScopedList{
+					"zones/eu-west2-b": {
+						Disks: []*compute.Disk{
+							{
+								Name:        "disk-3",
+								Zone:        "eu-west2-b",
+								Description: `{"kubernetes.io/created-for/pvc/namespace":"monitoring","team":"from-description","zone":"bogus"}`,
+								Labels:      map[string]string{"team": "storage", "goog-k8s-cluster-name": "prod", "zone": "bogus"},
+							},
+						},
+					},
+				},
+			}
+
+			if err := json.NewEncoder(w).Encode(res); err != nil {
+				t.Fatalf("unexpected error writing response %v", err)
+			}
+		}))
+		defer ts.Close()
+
+		svc, err := compute.NewService(context.Background(), option.WithAPIKey("123abc"), option.WithEndpoint(ts.URL))
+		if err != nil {
+			t.Fatalf("unexpected error creating GCP compute service: %v", err)
+		}
+
+		p, err := gcp.NewProvider(l, svc, "my-project", nil)
+		if err != nil {
+			t.Fatal("unexpected error creating provider:", err)
+		}
+
+		disks, err := p.ListUnusedDisks(ctx)
+		if err != nil {
+			t.Fatal("unexpected error listing unused disks:", err)
+		}
+
+		err = unusedtest.AssertEqualMeta(unused.Meta{
+			"kubernetes.io/created-for/pvc/namespace": "monitoring",
+			"team":                  "storage",
+			"goog-k8s-cluster-name": "prod",
+			"zone":                  "eu-west2-b",
+		}, disks[0].Meta())
+		if err != nil {
+			t.Fatalf("metadata doesn't match: %v", err)
 		}
 	})
 }
+
+func TestProviderRegionalDisks(t *testing.T) {
+	ctx := context.Background()
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var res any
+		switch req.URL.Path {
+		case "/projects/my-project/aggregated/disks":
+			res = &compute.DiskAggregatedList{
+				Items: map[string]compute.DisksScopedList{
+					"regions/europe-west4": {
+						Disks: []*compute.Disk{
+							{
+								Name:   "regional",
+								Region: "https://www.googleapis.com/compute/v1/projects/my-project/regions/europe-west4",
+								ReplicaZones: []string{
+									"https://www.googleapis.com/compute/v1/projects/my-project/zones/europe-west4-a",
+									"https://www.googleapis.com/compute/v1/projects/my-project/zones/europe-west4-b",
+								},
+							},
+							{
+								// a zone label must not make it zonal
+								Name:   "labelled",
+								Region: "https://www.googleapis.com/compute/v1/projects/my-project/regions/europe-west4",
+								ReplicaZones: []string{
+									"https://www.googleapis.com/compute/v1/projects/my-project/zones/europe-west4-a",
+									"https://www.googleapis.com/compute/v1/projects/my-project/zones/europe-west4-b",
+								},
+								Labels: map[string]string{"zone": "europe-west4-c"},
+							},
+						},
+					},
+				},
+			}
+		case "/projects/my-project/regions/europe-west4/disks/regional",
+			"/projects/my-project/regions/europe-west4/disks/labelled",
+			"/projects/my-project/zones/us-central1-a/disks/zonal":
+			if req.Method != http.MethodDelete {
+				t.Errorf("expecting DELETE request, got %s", req.Method)
+			}
+			deleted = append(deleted, req.URL.Path)
+			res = &compute.Operation{Name: "op-1", Status: "DONE"}
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			t.Fatalf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	svc, err := compute.NewService(ctx, option.WithAPIKey("123abc"), option.WithEndpoint(ts.URL))
+	if err != nil {
+		t.Fatalf("unexpected error creating GCP compute service: %v", err)
+	}
+
+	p, err := gcp.NewProvider(l, svc, "my-project", nil)
+	if err != nil {
+		t.Fatal("unexpected error creating provider:", err)
+	}
+
+	disks, err := p.ListUnusedDisks(ctx)
+	if err != nil {
+		t.Fatal("unexpected error listing unused disks:", err)
+	}
+	if len(disks) != 2 {
+		t.Fatalf("expecting 2 unused disks, got %d", len(disks))
+	}
+
+	for _, d := range disks {
+		err = unusedtest.AssertEqualMeta(unused.Meta{
+			gcp.RegionMetaKey:       "europe-west4",
+			gcp.ReplicaZonesMetaKey: "europe-west4-a,europe-west4-b",
+		}, d.Meta())
+		if err != nil {
+			t.Fatalf("disk %s metadata doesn't match: %v", d.Name(), err)
+		}
+
+		if exp, got := (unused.Location{Region: "europe-west4"}), d.Location(); exp != got {
+			t.Errorf("expecting disk %s location %v, got %v", d.Name(), exp, got)
+		}
+
+		if err := p.Delete(ctx, d); err != nil {
+			t.Fatalf("unexpected error deleting regional disk %s: %v", d.Name(), err)
+		}
+	}
+
+	zonal := unusedtest.NewDisk("zonal", p, time.Now(), time.Now())
+	zonal.SetMeta(unused.Meta{"zone": "us-central1-a"})
+	if err := p.Delete(ctx, &zonal); err != nil {
+		t.Fatalf("unexpected error deleting zonal disk: %v", err)
+	}
+
+	exp := []string{
+		"/projects/my-project/regions/europe-west4/disks/regional",
+		"/projects/my-project/regions/europe-west4/disks/labelled",
+		"/projects/my-project/zones/us-central1-a/disks/zonal",
+	}
+	if !slices.Equal(exp, deleted) {
+		t.Errorf("expecting deletions %v, got %v", exp, deleted)
+	}
+}
+
+func TestProviderSharedDisks(t *testing.T) {
+	ctx := context.Background()
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		res := &compute.DiskAggregatedList{
+			Items: map[string]compute.DisksScopedList{
+				"zones/us-central1-a": {
+					Disks: []*compute.Disk{
+						{Name: "single", Zone: "us-central1-a"},
+						{Name: "read-write-many", Zone: "us-central1-a", AccessMode: "READ_WRITE_MANY"},
+						{
+							Name: "read-write-many-attached", Zone: "us-central1-a", AccessMode: "READ_WRITE_MANY",
+							Users: []string{
+								"https://www.googleapis.com/compute/v1/projects/my-project/zones/us-central1-a/instances/vm-1",
+								"https://www.googleapis.com/compute/v1/projects/my-project/zones/us-central1-a/instances/vm-2",
+							},
+						},
+						{
+							Name: "read-only-many-attached", Zone: "us-central1-a", AccessMode: "READ_ONLY_MANY",
+							Users: []string{"https://www.googleapis.com/compute/v1/projects/my-project/zones/us-central1-a/instances/vm-1"},
+						},
+					},
+				},
+			},
+		}
+
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			t.Fatalf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	svc, err := compute.NewService(ctx, option.WithAPIKey("123abc"), option.WithEndpoint(ts.URL))
+	if err != nil {
+		t.Fatalf("unexpected error creating GCP compute service: %v", err)
+	}
+
+	p, err := gcp.NewProvider(l, svc, "my-project", nil)
+	if err != nil {
+		t.Fatal("unexpected error creating provider:", err)
+	}
+
+	disks, err := p.ListUnusedDisks(ctx)
+	if err != nil {
+		t.Fatal("unexpected error listing unused disks:", err)
+	}
+
+	exp := map[string]unused.Meta{
+		"single": {"zone": "us-central1-a"},
+		"read-write-many": {
+			"zone":                "us-central1-a",
+			unused.SharingMetaKey: "READ_WRITE_MANY",
+		},
+	}
+	if len(disks) != len(exp) {
+		t.Fatalf("expecting %d unused disks, got %d", len(exp), len(disks))
+	}
+	for _, d := range disks {
+		if err := unusedtest.AssertEqualMeta(exp[d.Name()], d.Meta()); err != nil {
+			t.Errorf("disk %s metadata doesn't match: %v", d.Name(), err)
+		}
+	}
+}
+
+func TestProviderSnapshot(t *testing.T) {
+	ctx := context.Background()
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var waits int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var res *compute.Operation
+
+		switch req.URL.Path {
+		case "/projects/my-project/zones/us-central1-a/disks/disk-1/createSnapshot":
+			var snap compute.Snapshot
+			if err := json.NewDecoder(req.Body).Decode(&snap); err != nil {
+				t.Fatalf("unexpected error decoding snapshot: %v", err)
+			}
+			if !strings.HasPrefix(snap.Name, "disk-1-") {
+				t.Errorf("expecting snapshot name to start with disk name, got %q", snap.Name)
+			}
+			res = &compute.Operation{Name: "op-1", Status: "RUNNING"}
+
+		case "/projects/my-project/zones/us-central1-a/operations/op-1/wait":
+			waits++
+			res = &compute.Operation{Name: "op-1", Status: "RUNNING"}
+			if waits > 1 {
+				res.Status = "DONE"
+			}
+
+		default:
+			t.Fatalf("unexpected request to %s", req.URL.Path)
+		}
+
+		b, _ := json.Marshal(res)
+		if _, err := w.Write(b); err != nil {
+			t.Fatalf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	svc, err := compute.NewService(ctx, option.WithAPIKey("123abc"), option.WithEndpoint(ts.URL))
+	if err != nil {
+		t.Fatalf("unexpected error creating GCP compute service: %v", err)
+	}
+
+	p, err := gcp.NewProvider(l, svc, "my-project", nil)
+	if err != nil {
+		t.Fatal("unexpected error creating provider:", err)
+	}
+
+	now := time.Now()
+	d := unusedtest.NewDisk("disk-1", p, now, now)
+	d.SetMeta(unused.Meta{"zone": "us-central1-a"})
+
+	name, err := p.Snapshot(ctx, d)
+	if err != nil {
+		t.Fatalf("unexpected error taking snapshot: %v", err)
+	}
+
+	if !strings.HasPrefix(name, "disk-1-") {
+		t.Errorf("expecting snapshot name to start with disk name, got %q", name)
+	}
+
+	if exp, got := 2, waits; exp != got {
+		t.Errorf("expecting to wait %d times for the operation, got %d", exp, got)
+	}
+}
+
+func TestProviderTagDisk(t *testing.T) {
+	ctx := context.Background()
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var labels *compute.ZoneSetLabelsRequest
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var res any
+
+		switch req.URL.Path {
+		case "/projects/my-project/zones/us-central1-a/disks/disk-1":
+			res = &compute.Disk{Name: "disk-1", Labels: map[string]string{"team": "foo"}, LabelFingerprint: "abc123"}
+
+		case "/projects/my-project/zones/us-central1-a/disks/disk-1/setLabels":
+			labels = new(compute.ZoneSetLabelsRequest)
+			if err := json.NewDecoder(req.Body).Decode(labels); err != nil {
+				t.Fatalf("unexpected error decoding labels: %v", err)
+			}
+			res = &compute.Operation{Name: "op-1", Status: "DONE"}
+
+		default:
+			t.Fatalf("unexpected request to %s", req.URL.Path)
+		}
+
+		b, _ := json.Marshal(res)
+		if _, err := w.Write(b); err != nil {
+			t.Fatalf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	svc, err := compute.NewService(ctx, option.WithAPIKey("123abc"), option.WithEndpoint(ts.URL))
+	if err != nil {
+		t.Fatalf("unexpected error creating GCP compute service: %v", err)
+	}
+
+	p, err := gcp.NewProvider(l, svc, "my-project", nil)
+	if err != nil {
+		t.Fatal("unexpected error creating provider:", err)
+	}
+
+	now := time.Now()
+	d := unusedtest.NewDisk("disk-1", p, now, now)
+	d.SetMeta(unused.Meta{"zone": "us-central1-a"})
+
+	if err := p.TagDisk(ctx, d, map[string]string{unused.QuarantineKey: "1700000000"}); err != nil {
+		t.Fatalf("unexpected error tagging disk: %v", err)
+	}
+
+	if labels == nil {
+		t.Fatal("expecting labels to be set")
+	}
+	if exp, got := "abc123", labels.LabelFingerprint; exp != got {
+		t.Errorf("expecting label fingerprint %q, got %q", exp, got)
+	}
+	if exp := map[string]string{"team": "foo", unused.QuarantineKey: "1700000000"}; !maps.Equal(exp, labels.Labels) {
+		t.Errorf("expecting labels %v, got %v", exp, labels.Labels)
+	}
+}
+
+func TestProviderListUnusedAddresses(t *testing.T) {
+	ctx := context.Background()
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if got, exp := req.URL.Path, "/projects/my-project/aggregated/addresses"; exp != got {
+			t.Fatalf("expecting request to %s, got %s", exp, got)
+		}
+
+		res := &compute.AddressAggregatedList{
+			Items: map[string]compute.AddressesScopedList{
+				"regions/us-central1": {
+					Addresses: []*compute.Address{
+						{Id: 1, Name: "in-use", Address: "10.0.0.1", Status: "IN_USE", Users: []string{"some-forwarding-rule"}},
+						{Id: 2, Name: "ingress", Address: "203.0.113.2", Status: "RESERVED", AddressType: "EXTERNAL", NetworkTier: "PREMIUM", Region: "https://www.googleapis.com/compute/v1/projects/my-project/regions/us-central1", Labels: map[string]string{"team": "platform"}},
+					},
+				},
+				"global": {
+					Addresses: []*compute.Address{
+						{Id: 3, Name: "global-lb", Address: "203.0.113.3", Status: "RESERVED", AddressType: "EXTERNAL"},
+					},
+				},
+			},
+		}
+
+		b, _ := json.Marshal(res)
+		if _, err := w.Write(b); err != nil {
+			t.Fatalf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	svc, err := compute.NewService(ctx, option.WithAPIKey("123abc"), option.WithEndpoint(ts.URL))
+	if err != nil {
+		t.Fatalf("unexpected error creating GCP compute service: %v", err)
+	}
+
+	p, err := gcp.NewProvider(l, svc, "my-project", nil)
+	if err != nil {
+		t.Fatal("unexpected error creating provider:", err)
+	}
+
+	addrs, err := p.ListUnusedAddresses(ctx)
+	if err != nil {
+		t.Fatal("unexpected error listing unused addresses:", err)
+	}
+
+	if exp, got := 2, len(addrs); exp != got {
+		t.Fatalf("expecting %d addresses, got %d", exp, got)
+	}
+
+	byName := make(map[string]unused.Address)
+	for _, a := range addrs {
+		byName[a.Name()] = a
+	}
+
+	err = unusedtest.AssertEqualMeta(unused.Meta{
+		"address-type": "EXTERNAL",
+		"network-tier": "PREMIUM",
+		"region":       "us-central1",
+		"team":         "platform",
+	}, byName["ingress"].Meta())
+	if err != nil {
+		t.Fatalf("metadata doesn't match: %v", err)
+	}
+
+	if exp, got := "global", byName["global-lb"].Meta()["region"]; exp != got {
+		t.Errorf("expecting global address region %q, got %q", exp, got)
+	}
+}
+
+func TestProviderListUnusedSnapshots(t *testing.T) {
+	ctx := context.Background()
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var res any
+		switch req.URL.Path {
+		case "/projects/my-project/aggregated/disks":
+			res = &compute.DiskAggregatedList{
+				Items: map[string]compute.DisksScopedList{
+					"zones/us-central1-a": {Disks: []*compute.Disk{{Id: 1}}},
+				},
+			}
+
+		case "/projects/my-project/global/snapshots":
+			res = &compute.SnapshotList{
+				Items: []*compute.Snapshot{
+					{Id: 10, Name: "exists", SourceDiskId: "1", SourceDisk: "projects/my-project/zones/us-central1-a/disks/disk-1", CreationTimestamp: "2022-03-12T17:25:21Z", Description: "taken by hand"},
+					{
+						Id: 20, Name: "orphaned", SourceDiskId: "2", SourceDisk: "projects/my-project/zones/us-central1-a/disks/disk-2", CreationTimestamp: "2022-03-12T17:25:21Z",
+						DiskSizeGb: 20, StorageLocations: []string{"us"}, Labels: map[string]string{"team": "platform"},
+						Description: `{"kubernetes.io/created-for/volumesnapshot/name":"snap-foo","kubernetes.io/created-for/volumesnapshot/namespace":"ns-bar"}`,
+					},
+				},
+			}
+
+		default:
+			t.Fatalf("unexpected request to %s", req.URL.Path)
+		}
+
+		b, _ := json.Marshal(res)
+		if _, err := w.Write(b); err != nil {
+			t.Fatalf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	svc, err := compute.NewService(ctx, option.WithAPIKey("123abc"), option.WithEndpoint(ts.URL))
+	if err != nil {
+		t.Fatalf("unexpected error creating GCP compute service: %v", err)
+	}
+
+	p, err := gcp.NewProvider(l, svc, "my-project", nil)
+	if err != nil {
+		t.Fatal("unexpected error creating provider:", err)
+	}
+
+	snaps, err := p.ListUnusedSnapshots(ctx, 0)
+	if err != nil {
+		t.Fatal("unexpected error listing unused snapshots:", err)
+	}
+
+	if exp, got := 1, len(snaps); exp != got {
+		t.Fatalf("expecting %d snapshots, got %d", exp, got)
+	}
+
+	s := snaps[0]
+	if exp, got := "orphaned", s.Name(); exp != got {
+		t.Errorf("expecting Name() %q, got %q", exp, got)
+	}
+	if !s.Orphaned() {
+		t.Error("expecting snapshot to be orphaned")
+	}
+
+	err = unusedtest.AssertEqualMeta(unused.Meta{
+		"kubernetes.io/created-for/volumesnapshot/name":      "snap-foo",
+		"kubernetes.io/created-for/volumesnapshot/namespace": "ns-bar",
+		"source-disk":       "disk-2",
+		"storage-locations": "us",
+		"team":              "platform",
+	}, s.Meta())
+	if err != nil {
+		t.Fatalf("metadata doesn't match: %v", err)
+	}
+
+	snaps, err = p.ListUnusedSnapshots(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatal("unexpected error listing unused snapshots:", err)
+	}
+	if exp, got := 2, len(snaps); exp != got {
+		t.Fatalf("expecting %d snapshots with retention, got %d", exp, got)
+	}
+
+	for _, s := range snaps {
+		if s.Name() != "exists" {
+			continue
+		}
+		err = unusedtest.AssertEqualMeta(unused.Meta{
+			gcp.DescriptionMetaKey: "taken by hand",
+			"source-disk":          "disk-1",
+			"storage-locations":    "",
+		}, s.Meta())
+		if err != nil {
+			t.Fatalf("metadata doesn't match: %v", err)
+		}
+	}
+}
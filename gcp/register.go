@@ -0,0 +1,129 @@
+package gcp
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/grafana/unused"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v3"
+	compute "google.golang.org/api/compute/v1"
+	serviceusage "google.golang.org/api/serviceusage/v1"
+)
+
+func init() {
+	unused.Register(unused.Registration{
+		Name:  ProviderName,
+		Flags: flags,
+		Match: func(p unused.Provider) bool {
+			_, ok := unused.As[*Provider](p)
+			return ok
+		},
+		DiscoveryFlags: []string{"gcp.parent"},
+	})
+}
+
+func flags(fs *flag.FlagSet) unused.CreateFunc {
+	var (
+		projects []string
+		parents  []string
+		include  []LabelSelector
+		exclude  []LabelSelector
+	)
+
+	fs.Func("gcp.project", "GCP project ID (can be specified multiple times)", func(v string) error {
+		projects = append(projects, v)
+		return nil
+	})
+	fs.StringVar(&ProviderName, "gcp.providername", ProviderName, `GCP provider name to use, default: "GCP" (e.g. "GKE")`)
+	fs.Func("gcp.parent", "GCP organization or folder, like organizations/123 or folders/456, to list disks in all its projects with the Compute Engine API enabled (can be specified multiple times)", func(v string) error {
+		if !strings.HasPrefix(v, "organizations/") && !strings.HasPrefix(v, "folders/") {
+			return fmt.Errorf("expecting organizations/ID or folders/ID, got %q", v)
+		}
+		parents = append(parents, v)
+		return nil
+	})
+	fs.Func("gcp.project.include", "Only list disks in the discovered GCP projects whose labels match this selector, like env=prod,team (can be specified multiple times)", func(v string) error {
+		ls, err := ParseLabelSelector(v)
+		if err != nil {
+			return err
+		}
+		include = append(include, ls)
+		return nil
+	})
+	fs.Func("gcp.project.exclude", "Skip the discovered GCP projects whose labels match this selector, like !env or env=dev (can be specified multiple times)", func(v string) error {
+		ls, err := ParseLabelSelector(v)
+		if err != nil {
+			return err
+		}
+		exclude = append(exclude, ls)
+		return nil
+	})
+
+	return func(ctx context.Context, logger *slog.Logger) ([]unused.Provider, error) {
+		providers := make([]unused.Provider, 0, len(projects))
+		seen := make(map[string]bool)
+
+		// a single compute service, and thus HTTP client and
+		// credentials, is shared by all the providers created in this
+		// run, which can be hundreds when discovering projects
+		var svc *compute.Service
+
+		newProvider := func(projectID string, meta unused.Meta) error {
+			if svc == nil {
+				var err error
+				svc, err = compute.NewService(ctx)
+				if err != nil {
+					return fmt.Errorf("cannot create GCP compute service: %w", err)
+				}
+			}
+			p, err := NewProvider(logger, svc, projectID, meta)
+			if err != nil {
+				return fmt.Errorf("creating GCP provider for project %s: %w", projectID, err)
+			}
+			providers = append(providers, p)
+			seen[projectID] = true
+			return nil
+		}
+
+		for _, projectID := range projects {
+			if err := newProvider(projectID, map[string]string{"project": projectID}); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(parents) == 0 {
+			return providers, nil
+		}
+
+		rm, err := cloudresourcemanager.NewService(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create GCP resource manager service: %w", err)
+		}
+		su, err := serviceusage.NewService(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create GCP service usage service: %w", err)
+		}
+
+		match := ProjectMatcher(include, exclude)
+		for _, parent := range parents {
+			discovered, err := ListProjects(ctx, logger, rm, su, parent, match)
+			if err != nil {
+				return nil, fmt.Errorf("discovering GCP projects in %s: %w", parent, err)
+			}
+
+			for _, prj := range discovered {
+				if seen[prj.ID] {
+					continue
+				}
+				if err := newProvider(prj.ID, prj.Meta()); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		return providers, nil
+	}
+}
@@ -0,0 +1,53 @@
+package gcp
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/grafana/unused"
+	compute "google.golang.org/api/compute/v1"
+)
+
+var _ unused.Snapshot = &Snapshot{}
+
+// Snapshot holds information about a GCP compute snapshot.
+type Snapshot struct {
+	*compute.Snapshot
+	provider *Provider
+	meta     unused.Meta
+	orphaned bool
+}
+
+// ID returns the GCP compute snapshot ID.
+func (s *Snapshot) ID() string { return strconv.FormatUint(s.Id, 10) }
+
+// Provider returns a reference to the provider used to instantiate
+// this snapshot.
+func (s *Snapshot) Provider() unused.Provider { return s.provider }
+
+// Name returns the name of the GCP compute snapshot.
+func (s *Snapshot) Name() string { return s.Snapshot.Name }
+
+// CreatedAt returns the time when the GCP compute snapshot was created.
+func (s *Snapshot) CreatedAt() time.Time {
+	// it's safe to assume GCP will send a valid timestamp
+	c, _ := time.Parse(time.RFC3339, s.CreationTimestamp)
+
+	return c
+}
+
+// Meta returns the snapshot metadata.
+func (s *Snapshot) Meta() unused.Meta { return s.meta }
+
+// Kind returns [unused.KindSnapshot].
+func (s *Snapshot) Kind() unused.ResourceKind { return unused.KindSnapshot }
+
+// SourceDiskID returns the ID of the disk this snapshot was taken
+// from.
+func (s *Snapshot) SourceDiskID() string { return s.SourceDiskId }
+
+// Orphaned returns true when the source disk no longer exists.
+func (s *Snapshot) Orphaned() bool { return s.orphaned }
+
+// SizeGB returns the size of the source disk in binary GB (aka GiB).
+func (s *Snapshot) SizeGB() int { return int(s.DiskSizeGb) }
@@ -0,0 +1,278 @@
+// Package k8s cross-checks unused disks against live Kubernetes
+// clusters.
+//
+// Disks created by Kubernetes carry metadata about the
+// PersistentVolume (PV) and PersistentVolumeClaim (PVC) they were
+// created for. A [Resolver] looks up those objects in the cluster the
+// disk belongs to and adds their current state to the disk metadata,
+// so that disks whose PV is gone can be told apart from disks that are
+// still claimed or retained.
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/grafana/unused"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Metadata keys added to disks by a [Resolver].
+const (
+	MetaPVState         = "k8s:pv-state"
+	MetaPVReclaimPolicy = "k8s:pv-reclaim-policy"
+	MetaPVCState        = "k8s:pvc-state"
+	MetaCluster         = "k8s:cluster"
+	MetaStorageClass    = "k8s:storage-class"
+)
+
+// PVState is the state of the PersistentVolume a disk was created for.
+type PVState string
+
+const (
+	// PVGone means the PV doesn't exist in the cluster of the disk.
+	PVGone PVState = "gone"
+
+	// PVUnknown means the PV couldn't be looked up, because the
+	// cluster of the disk isn't configured or isn't known and none of
+	// the configured clusters has its PV.
+	PVUnknown PVState = "unknown"
+
+	// PVReleased means the PVC was deleted but the PV was kept,
+	// usually because its reclaim policy is Retain.
+	PVReleased PVState = "released"
+
+	// PVBound means the PV is still bound to a PVC.
+	PVBound PVState = "bound"
+)
+
+// State returns the PV state of the given disk as resolved by a
+// [Resolver], or an empty string if it wasn't resolved.
+func State(d unused.Disk) PVState { return PVState(d.Meta()[MetaPVState]) }
+
+// Resolver resolves the PV and PVC of disks in a set of clusters.
+type Resolver struct {
+	clusters []cluster
+}
+
+type cluster struct {
+	name   string
+	client kubernetes.Interface
+
+	// aliases are other names the cluster is known by in disk
+	// metadata
+	aliases []string
+}
+
+// is returns whether the cluster is known by the given name.
+func (c cluster) is(name string) bool {
+	return name == c.name || slices.Contains(c.aliases, name)
+}
+
+// NewResolver returns a [Resolver] using the given clients, indexed
+// by cluster name. Disks are looked up in the cluster whose name is
+// the one found in their Kubernetes objects, see [unused.Disk].
+func NewResolver(clients map[string]kubernetes.Interface) *Resolver {
+	r := &Resolver{clusters: make([]cluster, 0, len(clients))}
+	for _, name := range slices.Sorted(maps.Keys(clients)) {
+		r.clusters = append(r.clusters, cluster{name: name, client: clients[name]})
+	}
+	return r
+}
+
+// NewResolverFromKubeconfig returns a [Resolver] for the given
+// kubeconfig contexts. An empty kubeconfig path uses the default
+// loading rules, honoring the KUBECONFIG environment variable.
+//
+// Disks are looked up in the context named like their cluster, or
+// whose kubeconfig cluster is, including GKE (gke_PROJECT_LOCATION_NAME)
+// and EKS (arn:aws:eks:REGION:ACCOUNT:cluster/NAME) cluster names.
+func NewResolverFromKubeconfig(kubeconfig string, contexts []string) (*Resolver, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+
+	raw, err := rules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("loading kubeconfig: %w", err)
+	}
+
+	r := &Resolver{clusters: make([]cluster, 0, len(contexts))}
+	for _, name := range contexts {
+		cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: name}).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("loading kubeconfig context %s: %w", name, err)
+		}
+
+		c, err := kubernetes.NewForConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("creating Kubernetes client for context %s: %w", name, err)
+		}
+
+		var aliases []string
+		if kc, ok := raw.Contexts[name]; ok && kc.Cluster != "" {
+			aliases = clusterAliases(kc.Cluster)
+		}
+
+		r.clusters = append(r.clusters, cluster{name: name, client: c, aliases: aliases})
+	}
+
+	slices.SortFunc(r.clusters, func(a, b cluster) int { return strings.Compare(a.name, b.name) })
+
+	return r, nil
+}
+
+// clusterAliases returns the names a kubeconfig cluster is known by in
+// disk metadata: the cluster name itself and, for GKE and EKS
+// clusters, their short name.
+func clusterAliases(name string) []string {
+	aliases := []string{name}
+	if rest, ok := strings.CutPrefix(name, "gke_"); ok {
+		// GKE cluster names can't contain underscores
+		aliases = append(aliases, rest[strings.LastIndex(rest, "_")+1:])
+	} else if _, short, ok := strings.Cut(name, ":cluster/"); ok && strings.HasPrefix(name, "arn:") {
+		aliases = append(aliases, short)
+	}
+	return aliases
+}
+
+// Annotate returns the given disks with the state of their PV and PVC
+// added to their metadata. Disks without Kubernetes metadata are
+// returned unchanged.
+//
+// The PV of each disk is looked up in the cluster found in its
+// Kubernetes objects. Disks from clusters that aren't configured are
+// reported with the [PVUnknown] state. Disks whose cluster is unknown
+// are looked up in all the clusters, and are reported as [PVUnknown]
+// if none or more than one cluster has their PV.
+func (r *Resolver) Annotate(ctx context.Context, disks unused.Disks) (unused.Disks, error) {
+	// PVs are indexed by cluster as names are only unique within a
+	// cluster
+	pvs := make(map[string]map[string]*corev1.PersistentVolume, len(r.clusters))
+	pvcs := make(map[string]corev1.PersistentVolumeClaimPhase)
+
+	for _, c := range r.clusters {
+		pvl, err := c.client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing PVs in cluster %s: %w", c.name, err)
+		}
+		pvs[c.name] = make(map[string]*corev1.PersistentVolume, len(pvl.Items))
+		for i := range pvl.Items {
+			pvs[c.name][pvl.Items[i].Name] = &pvl.Items[i]
+		}
+
+		pvcl, err := c.client.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing PVCs in cluster %s: %w", c.name, err)
+		}
+		for _, pvc := range pvcl.Items {
+			pvcs[c.name+"/"+pvc.Namespace+"/"+pvc.Name] = pvc.Status.Phase
+		}
+	}
+
+	res := make(unused.Disks, len(disks))
+
+	for i, d := range disks {
+		name := d.Meta().CreatedForPV()
+		if name == "" {
+			res[i] = d
+			continue
+		}
+
+		m := make(unused.Meta, len(d.Meta())+4)
+		for k, v := range d.Meta() {
+			m[k] = v
+		}
+
+		c, pv, state := r.lookup(pvs, d.Kubernetes().Cluster, name)
+		if pv == nil {
+			m[MetaPVState] = string(state)
+			res[i] = &disk{d, m}
+			continue
+		}
+
+		m[MetaCluster] = c
+		m[MetaPVState] = strings.ToLower(string(pv.Status.Phase))
+		m[MetaPVReclaimPolicy] = string(pv.Spec.PersistentVolumeReclaimPolicy)
+		if sc := pv.Spec.StorageClassName; sc != "" {
+			m[MetaStorageClass] = sc
+		}
+
+		pvcState := "gone"
+		if ref := pv.Spec.ClaimRef; ref != nil {
+			if phase, ok := pvcs[c+"/"+ref.Namespace+"/"+ref.Name]; ok {
+				pvcState = strings.ToLower(string(phase))
+			}
+		}
+		m[MetaPVCState] = pvcState
+
+		res[i] = &disk{d, m}
+	}
+
+	return res, nil
+}
+
+// lookup returns the cluster and PV with the given name for a disk of
+// the given cluster, or the state to report if there's no such PV.
+func (r *Resolver) lookup(pvs map[string]map[string]*corev1.PersistentVolume, diskCluster, name string) (string, *corev1.PersistentVolume, PVState) {
+	if diskCluster != "" {
+		i := slices.IndexFunc(r.clusters, func(c cluster) bool { return c.is(diskCluster) })
+		if i < 0 {
+			return "", nil, PVUnknown
+		}
+
+		c := r.clusters[i].name
+		if pv, ok := pvs[c][name]; ok {
+			return c, pv, ""
+		}
+		return "", nil, PVGone
+	}
+
+	var (
+		found string
+		pv    *corev1.PersistentVolume
+	)
+	for _, c := range r.clusters {
+		if p, ok := pvs[c.name][name]; ok {
+			if pv != nil {
+				return "", nil, PVUnknown
+			}
+			found, pv = c.name, p
+		}
+	}
+	if pv == nil {
+		return "", nil, PVUnknown
+	}
+
+	return found, pv, ""
+}
+
+// disk wraps a disk to override its metadata.
+type disk struct {
+	unused.Disk
+	meta unused.Meta
+}
+
+func (d *disk) Meta() unused.Meta { return d.meta }
+
+// Kubernetes returns the Kubernetes objects of the wrapped disk,
+// completed with the cluster and storage class of its PV.
+func (d *disk) Kubernetes() unused.Kubernetes {
+	k := d.Disk.Kubernetes()
+	if c := d.meta[MetaCluster]; c != "" {
+		k.Cluster = c
+	}
+	if sc := d.meta[MetaStorageClass]; sc != "" {
+		k.StorageClass = sc
+	}
+	return k
+}
+
+func (d *disk) Unwrap() unused.Disk { return d.Disk }
@@ -0,0 +1,220 @@
+package k8s_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/k8s"
+	"github.com/grafana/unused/unusedtest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolverAnnotate(t *testing.T) {
+	var (
+		ctx = context.Background()
+		now = time.Now()
+		p   = unusedtest.NewProvider("foo", nil)
+	)
+
+	newDisk := func(name, cluster, pv string) unused.Disk {
+		d := unusedtest.NewDisk(name, p, now, now)
+		d.SetCluster(cluster)
+		if pv != "" {
+			d.SetMeta(unused.Meta{"kubernetes.io/created-for/pv/name": pv})
+		}
+		return d
+	}
+
+	pv := func(name string, phase corev1.PersistentVolumePhase, policy corev1.PersistentVolumeReclaimPolicy, claim string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeReclaimPolicy: policy,
+				StorageClassName:              "standard-rwo",
+				ClaimRef:                      &corev1.ObjectReference{Namespace: "ns", Name: claim},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: phase},
+		}
+	}
+
+	r := k8s.NewResolver(map[string]kubernetes.Interface{
+		"dev": fake.NewClientset(
+			pv("pv-bound", corev1.VolumeBound, corev1.PersistentVolumeReclaimDelete, "data"),
+			pv("pv-shared", corev1.VolumeBound, corev1.PersistentVolumeReclaimDelete, "data"),
+			&corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "data"},
+				Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+			},
+		),
+		"prod": fake.NewClientset(
+			pv("pv-released", corev1.VolumeReleased, corev1.PersistentVolumeReclaimRetain, "deleted"),
+			pv("pv-shared", corev1.VolumeReleased, corev1.PersistentVolumeReclaimRetain, "deleted"),
+		),
+	})
+
+	disks, err := r.Annotate(ctx, unused.Disks{
+		newDisk("bound", "dev", "pv-bound"),
+		newDisk("released", "", "pv-released"),
+		newDisk("gone", "dev", "pv-gone"),
+		newDisk("other-cluster", "staging", "pv-bound"),
+		newDisk("other-cluster-gone", "staging", "pv-gone"),
+		newDisk("same-name", "prod", "pv-shared"),
+		newDisk("same-name-unknown-cluster", "", "pv-shared"),
+		newDisk("unknown-cluster-gone", "", "pv-gone"),
+		newDisk("manual", "dev", ""),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		state   k8s.PVState
+		cluster string
+		policy  string
+		pvc     string
+	}{
+		{k8s.PVBound, "dev", "Delete", "bound"},
+		{k8s.PVReleased, "prod", "Retain", "gone"},
+		{k8s.PVGone, "", "", ""},
+		{k8s.PVUnknown, "", "", ""},
+		{k8s.PVUnknown, "", "", ""},
+		{k8s.PVReleased, "prod", "Retain", "gone"},
+		{k8s.PVUnknown, "", "", ""},
+		{k8s.PVUnknown, "", "", ""},
+		{"", "", "", ""},
+	}
+
+	for i, tt := range tests {
+		d := disks[i]
+		t.Run(d.Name(), func(t *testing.T) {
+			m := d.Meta()
+			if got := k8s.State(d); got != tt.state {
+				t.Errorf("expecting state %q, got %q", tt.state, got)
+			}
+			if got := m[k8s.MetaCluster]; got != tt.cluster {
+				t.Errorf("expecting cluster %q, got %q", tt.cluster, got)
+			}
+			if got := d.Kubernetes().Cluster; tt.cluster != "" && got != tt.cluster {
+				t.Errorf("expecting Kubernetes() cluster %q, got %q", tt.cluster, got)
+			}
+			if got, found := d.Kubernetes().StorageClass, tt.cluster != ""; (got == "standard-rwo") != found {
+				t.Errorf("unexpected Kubernetes() storage class %q", got)
+			}
+			if got := m[k8s.MetaPVReclaimPolicy]; got != tt.policy {
+				t.Errorf("expecting reclaim policy %q, got %q", tt.policy, got)
+			}
+			if got := m[k8s.MetaPVCState]; got != tt.pvc {
+				t.Errorf("expecting PVC state %q, got %q", tt.pvc, got)
+			}
+		})
+	}
+
+	if _, ok := unused.UnwrapDisk(disks[0]).(unusedtest.Disk); !ok {
+		t.Errorf("expecting annotated disk to unwrap to the original disk, got %T", unused.UnwrapDisk(disks[0]))
+	}
+}
+
+func TestWrapProvider(t *testing.T) {
+	var (
+		ctx = context.Background()
+		now = time.Now()
+
+		d = unusedtest.NewDisk("gone", nil, now, now)
+		p = unusedtest.NewProvider("foo", nil, d)
+	)
+
+	d.SetMeta(unused.Meta{"kubernetes.io/created-for/pv/name": "pv-gone"})
+	d.SetCluster("dev")
+	p = unusedtest.NewProvider("foo", nil, d)
+	p.SetAddresses(unusedtest.NewAddress("addr", "10.0.0.1", p, now))
+
+	w := k8s.WrapProvider(p, k8s.NewResolver(map[string]kubernetes.Interface{"dev": fake.NewClientset()}))
+
+	disks, err := w.ListUnusedDisks(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(disks) != 1 || k8s.State(disks[0]) != k8s.PVGone {
+		t.Fatalf("expecting one disk with gone PV, got %v", disks)
+	}
+
+	addrs, err := unused.ListUnusedResources(ctx, w, unused.KindAddress)
+	if err != nil {
+		t.Fatalf("unexpected error listing addresses through wrapper: %v", err)
+	}
+	if len(addrs) != 1 {
+		t.Fatalf("expecting 1 address, got %d", len(addrs))
+	}
+
+	if err := unused.DeleteResource(ctx, w, disks[0]); err != nil {
+		t.Fatalf("unexpected error deleting annotated disk: %v", err)
+	}
+}
+
+func TestResolverFromKubeconfig(t *testing.T) {
+	ctx := context.Background()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"apiVersion": "v1", "items": []}`)
+	}))
+	defer ts.Close()
+
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+  - name: gke_project_us-central1_gke-prod
+    cluster: {server: `+ts.URL+`}
+  - name: arn:aws:eks:us-east-1:123456789012:cluster/eks-prod
+    cluster: {server: `+ts.URL+`}
+users:
+  - name: user
+    user: {}
+contexts:
+  - name: gke
+    context: {cluster: gke_project_us-central1_gke-prod, user: user}
+  - name: eks
+    context: {cluster: "arn:aws:eks:us-east-1:123456789012:cluster/eks-prod", user: user}
+`), 0o600)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := k8s.NewResolverFromKubeconfig(kubeconfig, []string{"gke", "eks"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]k8s.PVState{
+		"gke":                              k8s.PVGone,
+		"gke-prod":                         k8s.PVGone,
+		"gke_project_us-central1_gke-prod": k8s.PVGone,
+		"eks-prod":                         k8s.PVGone,
+		"gke-dev":                          k8s.PVUnknown,
+	}
+
+	for cluster, exp := range tests {
+		d := unusedtest.NewDisk("disk", nil, time.Now(), time.Now())
+		d.SetMeta(unused.Meta{"kubernetes.io/created-for/pv/name": "pv"})
+		d.SetCluster(cluster)
+
+		disks, err := r.Annotate(ctx, unused.Disks{d})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := k8s.State(disks[0]); got != exp {
+			t.Errorf("expecting state %q for disk in cluster %s, got %q", exp, cluster, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/unused"
+)
+
+var _ unused.Provider = &Provider{}
+
+// Provider wraps an [unused.Provider] annotating the disks it lists
+// with a [Resolver].
+type Provider struct {
+	unused.Provider
+	resolver *Resolver
+}
+
+// WrapProvider returns a provider annotating the disks listed by p
+// with the state of their Kubernetes PV and PVC.
+func WrapProvider(p unused.Provider, r *Resolver) *Provider {
+	return &Provider{p, r}
+}
+
+// ListUnusedDisks returns the unused disks of the wrapped provider
+// annotated with their Kubernetes state.
+func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
+	disks, err := p.Provider.ListUnusedDisks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	disks, err = p.resolver.Annotate(ctx, disks)
+	if err != nil {
+		return nil, fmt.Errorf("resolving Kubernetes state: %w", err)
+	}
+
+	return disks, nil
+}
+
+// Unwrap returns the wrapped provider.
+func (p *Provider) Unwrap() unused.Provider { return p.Provider }
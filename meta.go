@@ -57,6 +57,8 @@ func (m Meta) Matches(key, val string) bool {
 		return m.CreatedForPVC() == val
 	case "k8s:ns":
 		return m.CreatedForNamespace() == val
+	case "k8s:volumesnapshot":
+		return m.CreatedForVolumeSnapshot() == val
 	}
 	return m[key] == val
 }
@@ -69,8 +71,31 @@ func (m Meta) CreatedForPVC() string {
 	return m.coalesce("kubernetes.io/created-for/pvc/name", "kubernetes.io-created-for-pvc-name")
 }
 
+// CreatedForNamespace returns the Kubernetes namespace of the PVC or,
+// for snapshots, of the CSI VolumeSnapshot the resource was created for.
 func (m Meta) CreatedForNamespace() string {
-	return m.coalesce("kubernetes.io/created-for/pvc/namespace", "kubernetes.io-created-for-pvc-namespace")
+	return m.coalesce(
+		"kubernetes.io/created-for/pvc/namespace", "kubernetes.io-created-for-pvc-namespace",
+		"kubernetes.io/created-for/volumesnapshot/namespace", "kubernetes.io-created-for-volumesnapshot-namespace",
+	)
+}
+
+func (m Meta) CreatedForVolumeSnapshot() string {
+	return m.coalesce("kubernetes.io/created-for/volumesnapshot/name", "kubernetes.io-created-for-volumesnapshot-name")
+}
+
+func (m Meta) CreatedForVolumeSnapshotContent() string {
+	return m.coalesce("kubernetes.io/created-for/volumesnapshotcontent/name", "kubernetes.io-created-for-volumesnapshotcontent-name")
+}
+
+// Kubernetes returns the Kubernetes namespace, PVC and PV found in
+// the metadata.
+func (m Meta) Kubernetes() Kubernetes {
+	return Kubernetes{
+		Namespace: m.CreatedForNamespace(),
+		PVC:       m.CreatedForPVC(),
+		PV:        m.CreatedForPV(),
+	}
 }
 
 func (m Meta) Zone() string {
@@ -66,6 +66,24 @@ func TestMetaMatches(t *testing.T) {
 			t.Error("expecting to match namespace")
 		}
 	})
+
+	t.Run("Kubernetes VolumeSnapshot", func(t *testing.T) {
+		m := Meta{
+			"kubernetes.io-created-for-volumesnapshot-name":        "snap-foo",
+			"kubernetes.io-created-for-volumesnapshot-namespace":   "ns-quux",
+			"kubernetes.io-created-for-volumesnapshotcontent-name": "snapcontent-bar",
+		}
+
+		if !m.Matches("k8s:volumesnapshot", "snap-foo") {
+			t.Error("expecting to match VolumeSnapshot")
+		}
+		if !m.Matches("k8s:ns", "ns-quux") {
+			t.Error("expecting to match VolumeSnapshot namespace")
+		}
+		if exp, got := "snapcontent-bar", m.CreatedForVolumeSnapshotContent(); exp != got {
+			t.Errorf("expecting VolumeSnapshotContent %q, got %q", exp, got)
+		}
+	})
 }
 
 func TestCoalesce(t *testing.T) {
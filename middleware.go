@@ -0,0 +1,247 @@
+package unused
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Middleware wraps a provider adding cross-cutting behavior to listing
+// and deleting its disks. Wrapped providers implement an Unwrap method,
+// so [As] still finds the capabilities of the original provider.
+type Middleware func(Provider) Provider
+
+// Chain wraps p with the given middlewares, the first one being the
+// outermost.
+func Chain(p Provider, mws ...Middleware) Provider {
+	for i := len(mws) - 1; i >= 0; i-- {
+		p = mws[i](p)
+	}
+	return p
+}
+
+var (
+	_ Provider = &RetryingProvider{}
+	_ Provider = &CachingProvider{}
+	_ Provider = &InstrumentedProvider{}
+)
+
+// RetryingProvider retries listing and deleting disks with exponential
+// backoff.
+type RetryingProvider struct {
+	Provider
+	retries    int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	retryable  func(error) bool
+}
+
+// Retrying returns a middleware retrying failed calls up to retries
+// times, waiting an exponentially increasing time between minBackoff
+// and maxBackoff, with jitter, between attempts. Only the errors for
+// which retryable returns true are retried; when nil, all errors but
+// context cancellations are.
+func Retrying(retries int, minBackoff, maxBackoff time.Duration, retryable func(error) bool) Middleware {
+	if retryable == nil {
+		retryable = func(err error) bool { return !errors.Is(err, context.Canceled) }
+	}
+
+	return func(p Provider) Provider {
+		return &RetryingProvider{p, retries, minBackoff, maxBackoff, retryable}
+	}
+}
+
+// ListUnusedDisks lists the unused disks of the wrapped provider,
+// retrying on failure.
+func (p *RetryingProvider) ListUnusedDisks(ctx context.Context) (Disks, error) {
+	var disks Disks
+	err := p.retry(ctx, func() error {
+		var err error
+		disks, err = p.Provider.ListUnusedDisks(ctx)
+		return err
+	})
+	return disks, err
+}
+
+// Delete deletes the disk with the wrapped provider, retrying on
+// failure. A deletion failing with a retryable error might have
+// deleted the disk after all, in which case the retry fails instead.
+func (p *RetryingProvider) Delete(ctx context.Context, d Disk) error {
+	return p.retry(ctx, func() error { return p.Provider.Delete(ctx, d) })
+}
+
+func (p *RetryingProvider) retry(ctx context.Context, fn func() error) error {
+	backoff := p.minBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.retries || ctx.Err() != nil || !p.retryable(err) {
+			return err
+		}
+
+		t := time.NewTimer(time.Duration(rand.Int64N(int64(backoff) + 1)))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		}
+		backoff = min(backoff*2, p.maxBackoff)
+	}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *RetryingProvider) Unwrap() Provider { return p.Provider }
+
+// CachingProvider keeps the unused disks listed by the wrapped provider
+// for some time, removing them from the cache once deleted through it.
+// Errors aren't cached.
+type CachingProvider struct {
+	Provider
+	ttl time.Duration
+
+	mu      sync.Mutex
+	disks   Disks
+	expires time.Time
+}
+
+// Caching returns a middleware keeping the listed disks for ttl.
+func Caching(ttl time.Duration) Middleware {
+	return func(p Provider) Provider {
+		return &CachingProvider{Provider: p, ttl: ttl}
+	}
+}
+
+// ListUnusedDisks returns the cached unused disks, listing them with
+// the wrapped provider if they expired. Concurrent calls wait for the
+// same listing.
+func (p *CachingProvider) ListUnusedDisks(ctx context.Context) (Disks, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.disks == nil || !time.Now().Before(p.expires) {
+		disks, err := p.Provider.ListUnusedDisks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if disks == nil {
+			disks = Disks{}
+		}
+		p.disks, p.expires = disks, time.Now().Add(p.ttl)
+	}
+
+	return slices.Clone(p.disks), nil
+}
+
+// Delete deletes the disk with the wrapped provider and removes it from
+// the cache.
+func (p *CachingProvider) Delete(ctx context.Context, d Disk) error {
+	if err := p.Provider.Delete(ctx, d); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.disks = slices.DeleteFunc(slices.Clone(p.disks), func(c Disk) bool { return c.ID() == d.ID() })
+
+	return nil
+}
+
+// Invalidate drops the cached disks, so they are listed again on the
+// next call.
+func (p *CachingProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.disks = nil
+}
+
+// Unwrap returns the wrapped provider.
+func (p *CachingProvider) Unwrap() Provider { return p.Provider }
+
+// ProviderMetrics are the Prometheus metrics recorded by instrumented
+// providers.
+type ProviderMetrics struct {
+	calls    *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewProviderMetrics creates the metrics for instrumented providers
+// and registers them with reg.
+func NewProviderMetrics(reg prometheus.Registerer) (*ProviderMetrics, error) {
+	m := &ProviderMetrics{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "unused",
+			Subsystem: "provider",
+			Name:      "calls_total",
+			Help:      "Number of calls made to a provider API by method and result.",
+		}, []string{"provider", "provider_id", "method", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "unused",
+			Subsystem: "provider",
+			Name:      "call_duration_seconds",
+			Help:      "Duration of calls made to a provider API by method.",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+		}, []string{"provider", "provider_id", "method"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.calls, m.duration} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// InstrumentedProvider records the number and duration of calls to
+// list and delete disks.
+type InstrumentedProvider struct {
+	Provider
+	metrics *ProviderMetrics
+}
+
+// Instrumented returns a middleware recording calls with m.
+func Instrumented(m *ProviderMetrics) Middleware {
+	return func(p Provider) Provider {
+		return &InstrumentedProvider{p, m}
+	}
+}
+
+// ListUnusedDisks lists the unused disks of the wrapped provider,
+// recording the call.
+func (p *InstrumentedProvider) ListUnusedDisks(ctx context.Context) (Disks, error) {
+	start := time.Now()
+	disks, err := p.Provider.ListUnusedDisks(ctx)
+	p.record("ListUnusedDisks", start, err)
+
+	return disks, err
+}
+
+// Delete deletes the disk with the wrapped provider, recording the
+// call.
+func (p *InstrumentedProvider) Delete(ctx context.Context, d Disk) error {
+	start := time.Now()
+	err := p.Provider.Delete(ctx, d)
+	p.record("Delete", start, err)
+
+	return err
+}
+
+func (p *InstrumentedProvider) record(method string, start time.Time, err error) {
+	p.metrics.duration.WithLabelValues(p.Name(), p.ID(), method).Observe(time.Since(start).Seconds())
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	p.metrics.calls.WithLabelValues(p.Name(), p.ID(), method, result).Inc()
+}
+
+// Unwrap returns the wrapped provider.
+func (p *InstrumentedProvider) Unwrap() Provider { return p.Provider }
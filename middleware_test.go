@@ -0,0 +1,232 @@
+package unused_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/unusedtest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// countingProvider counts the calls to list and delete disks, failing
+// the first lists and deletions.
+type countingProvider struct {
+	*unusedtest.Provider
+	lists, deletes, fails int
+	deleteFails           int
+	err                   error
+}
+
+func (p *countingProvider) Delete(ctx context.Context, d unused.Disk) error {
+	p.deletes++
+	if p.deletes <= p.deleteFails {
+		return p.err
+	}
+	return p.Provider.Delete(ctx, d)
+}
+
+func (p *countingProvider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
+	p.lists++
+	if p.lists <= p.fails {
+		return nil, p.err
+	}
+	return p.Provider.ListUnusedDisks(ctx)
+}
+
+func newCountingProvider(fails int, err error) *countingProvider {
+	now := time.Now()
+	return &countingProvider{
+		Provider: unusedtest.NewProvider("foo", nil,
+			unusedtest.NewDisk("disk-1", nil, now, now),
+			unusedtest.NewDisk("disk-2", nil, now, now)),
+		fails: fails,
+		err:   err,
+	}
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+	mw := func(name string) unused.Middleware {
+		return func(p unused.Provider) unused.Provider {
+			order = append(order, name)
+			return p
+		}
+	}
+
+	p := unusedtest.NewProvider("foo", nil)
+	if got := unused.Chain(p, mw("outer"), mw("inner")); got != p {
+		t.Errorf("expecting the same provider, got %v", got)
+	}
+
+	if len(order) != 2 || order[0] != "inner" || order[1] != "outer" {
+		t.Errorf("expecting inner middleware applied first, got %v", order)
+	}
+
+	c := unused.Chain(p, unused.Caching(time.Minute), unused.Retrying(1, 0, 0, nil))
+	if _, ok := c.(*unused.CachingProvider); !ok {
+		t.Errorf("expecting caching provider outermost, got %T", c)
+	}
+	if got, ok := unused.As[*unusedtest.Provider](c); !ok || got != p {
+		t.Errorf("expecting to unwrap the original provider, got %v", got)
+	}
+}
+
+func TestRetryingProvider(t *testing.T) {
+	ctx := context.Background()
+	errTransient := errors.New("transient")
+
+	t.Run("succeeds after retries", func(t *testing.T) {
+		cp := newCountingProvider(2, errTransient)
+		p := unused.Retrying(2, time.Millisecond, time.Millisecond, nil)(cp)
+
+		disks, err := p.ListUnusedDisks(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(disks) != 2 || cp.lists != 3 {
+			t.Errorf("expecting 2 disks after 3 calls, got %d after %d", len(disks), cp.lists)
+		}
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		cp := newCountingProvider(5, errTransient)
+		p := unused.Retrying(2, time.Millisecond, time.Millisecond, nil)(cp)
+
+		if _, err := p.ListUnusedDisks(ctx); !errors.Is(err, errTransient) {
+			t.Errorf("expecting error %v, got %v", errTransient, err)
+		}
+		if cp.lists != 3 {
+			t.Errorf("expecting 3 calls, got %d", cp.lists)
+		}
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		cp := newCountingProvider(5, errTransient)
+		p := unused.Retrying(2, time.Millisecond, time.Millisecond, func(error) bool { return false })(cp)
+
+		if _, err := p.ListUnusedDisks(ctx); !errors.Is(err, errTransient) {
+			t.Errorf("expecting error %v, got %v", errTransient, err)
+		}
+		if cp.lists != 1 {
+			t.Errorf("expecting 1 call, got %d", cp.lists)
+		}
+	})
+
+	t.Run("deletions retried", func(t *testing.T) {
+		cp := newCountingProvider(0, errTransient)
+		cp.deleteFails = 2
+		p := unused.Retrying(2, time.Millisecond, time.Millisecond, nil)(cp)
+
+		disks, _ := cp.Provider.ListUnusedDisks(ctx)
+		if err := p.Delete(ctx, disks[0]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cp.deletes != 3 {
+			t.Errorf("expecting 3 calls, got %d", cp.deletes)
+		}
+	})
+
+	t.Run("deletions not retryable", func(t *testing.T) {
+		cp := newCountingProvider(0, nil)
+		retryable := func(err error) bool { return !errors.Is(err, unusedtest.ErrDiskNotFound) }
+		p := unused.Retrying(2, time.Millisecond, time.Millisecond, retryable)(cp)
+
+		d := unusedtest.NewDisk("missing", cp, time.Now(), time.Now())
+		if err := p.Delete(ctx, d); !errors.Is(err, unusedtest.ErrDiskNotFound) {
+			t.Errorf("expecting error %v, got %v", unusedtest.ErrDiskNotFound, err)
+		}
+		if cp.deletes != 1 {
+			t.Errorf("expecting 1 call, got %d", cp.deletes)
+		}
+	})
+}
+
+func TestCachingProvider(t *testing.T) {
+	ctx := context.Background()
+
+	cp := newCountingProvider(1, errors.New("boom"))
+	p := unused.Caching(time.Hour)(cp)
+
+	if _, err := p.ListUnusedDisks(ctx); err == nil {
+		t.Fatal("expecting error")
+	}
+
+	// errors are not cached
+	for range 3 {
+		disks, err := p.ListUnusedDisks(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(disks) != 2 {
+			t.Fatalf("expecting 2 disks, got %d", len(disks))
+		}
+	}
+	if cp.lists != 2 {
+		t.Errorf("expecting 2 calls, got %d", cp.lists)
+	}
+
+	disks, _ := p.ListUnusedDisks(ctx)
+	if err := p.Delete(ctx, disks[0]); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	}
+
+	disks, _ = p.ListUnusedDisks(ctx)
+	if len(disks) != 1 || disks[0].Name() != "disk-2" || cp.lists != 2 {
+		t.Errorf("expecting deleted disk removed from the cache, got %v after %d calls", disks, cp.lists)
+	}
+
+	p.(*unused.CachingProvider).Invalidate()
+	if _, err := p.ListUnusedDisks(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp.lists != 3 {
+		t.Errorf("expecting 3 calls after invalidating, got %d", cp.lists)
+	}
+
+	t.Run("expires", func(t *testing.T) {
+		cp := newCountingProvider(0, nil)
+		p := unused.Caching(10 * time.Millisecond)(cp)
+
+		p.ListUnusedDisks(ctx) // nolint:errcheck
+		time.Sleep(20 * time.Millisecond)
+		p.ListUnusedDisks(ctx) // nolint:errcheck
+
+		if cp.lists != 2 {
+			t.Errorf("expecting 2 calls, got %d", cp.lists)
+		}
+	})
+}
+
+func TestInstrumentedProvider(t *testing.T) {
+	ctx := context.Background()
+
+	reg := prometheus.NewPedanticRegistry()
+	m, err := unused.NewProviderMetrics(reg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cp := newCountingProvider(1, errors.New("boom"))
+	p := unused.Instrumented(m)(cp)
+
+	p.ListUnusedDisks(ctx) // nolint:errcheck
+	disks, _ := p.ListUnusedDisks(ctx)
+	if err := p.Delete(ctx, disks[0]); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	}
+
+	if n := testutil.CollectAndCount(reg, "unused_provider_calls_total"); n != 3 {
+		t.Errorf("expecting 3 call series, got %d", n)
+	}
+	if n := testutil.CollectAndCount(reg, "unused_provider_call_duration_seconds"); n != 2 {
+		t.Errorf("expecting 2 duration series, got %d", n)
+	}
+
+	if _, err := unused.NewProviderMetrics(reg); err == nil {
+		t.Error("expecting error registering metrics twice")
+	}
+}
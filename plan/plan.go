@@ -0,0 +1,325 @@
+// Package plan implements a reviewable plan/apply workflow to delete
+// unused disks without user interaction.
+//
+// A [Plan] records the disks to delete along with the filters used to
+// select them and the providers they belong to. It is written as JSON
+// so it can be reviewed, and later applied with [Apply], which checks
+// that each disk is still unused and unchanged before deleting it.
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/deleter"
+)
+
+// Version is the version of the plan file format.
+const Version = 1
+
+// ErrInvalidPlan is returned when a plan file cannot be read.
+var ErrInvalidPlan = errors.New("invalid plan")
+
+// Plan is a list of disks to delete.
+type Plan struct {
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	Filters   []string   `json:"filters,omitempty"`
+	Providers []Provider `json:"providers"`
+	Disks     []Disk     `json:"disks"`
+}
+
+// Provider identifies a provider in a plan.
+type Provider struct {
+	Name string      `json:"name"`
+	ID   string      `json:"id"`
+	Meta unused.Meta `json:"meta,omitempty"`
+}
+
+func (p Provider) key() string { return p.Name + "/" + p.ID }
+
+func providerKey(p unused.Provider) string { return p.Name() + "/" + p.ID() }
+
+// Disk is a disk planned for deletion.
+type Disk struct {
+	Provider string      `json:"provider"`
+	ID       string      `json:"id"`
+	Name     string      `json:"name"`
+	SizeGB   int         `json:"size_gb"`
+	Meta     unused.Meta `json:"meta,omitempty"`
+}
+
+// New returns a plan to delete the given disks, recording the filters
+// used to select them.
+func New(filters []string, disks unused.Disks) *Plan {
+	p := &Plan{
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		Filters:   filters,
+		Disks:     make([]Disk, 0, len(disks)),
+	}
+
+	seen := make(map[string]struct{})
+
+	for _, d := range disks {
+		pp := Provider{d.Provider().Name(), d.Provider().ID(), d.Provider().Meta()}
+		if _, ok := seen[pp.key()]; !ok {
+			seen[pp.key()] = struct{}{}
+			p.Providers = append(p.Providers, pp)
+		}
+
+		p.Disks = append(p.Disks, Disk{
+			Provider: pp.key(),
+			ID:       d.ID(),
+			Name:     d.Name(),
+			SizeGB:   d.SizeGB(),
+			Meta:     d.Meta(),
+		})
+	}
+
+	return p
+}
+
+// Write encodes the plan as JSON to w.
+func (p *Plan) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(p); err != nil {
+		return fmt.Errorf("encoding plan: %w", err)
+	}
+	return nil
+}
+
+// Read decodes a JSON plan from r.
+func Read(r io.Reader) (*Plan, error) {
+	var p Plan
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPlan, err)
+	}
+
+	if p.Version != Version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidPlan, p.Version)
+	}
+
+	providers := make(map[string]struct{}, len(p.Providers))
+	for _, pp := range p.Providers {
+		providers[pp.key()] = struct{}{}
+	}
+	for _, d := range p.Disks {
+		if _, ok := providers[d.Provider]; !ok {
+			return nil, fmt.Errorf("%w: disk %s references unknown provider %s", ErrInvalidPlan, d.ID, d.Provider)
+		}
+	}
+
+	return &p, nil
+}
+
+// Status is the result of applying a plan to a disk.
+type Status string
+
+const (
+	// Deleted means the disk was deleted.
+	Deleted Status = "deleted"
+
+	// WouldDelete means the disk would have been deleted in a dry
+	// run.
+	WouldDelete Status = "would-delete"
+
+	// Failed means deleting the disk failed.
+	Failed Status = "failed"
+
+	// Skipped means the disk was not deleted because it's no longer
+	// unused, it changed since the plan was made, or its provider is
+	// not available.
+	Skipped Status = "skipped"
+)
+
+// Result is the outcome of applying a plan to a disk.
+type Result struct {
+	Provider string `json:"provider"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Report lists the result for each disk in a plan.
+type Report struct {
+	AppliedAt time.Time `json:"applied_at"`
+	DryRun    bool      `json:"dry_run,omitempty"`
+	Results   []Result  `json:"results"`
+}
+
+// Failed returns whether deleting any disk failed.
+func (r *Report) Failed() bool {
+	for _, res := range r.Results {
+		if res.Status == Failed {
+			return true
+		}
+	}
+	return false
+}
+
+// Write encodes the report as JSON to w.
+func (r *Report) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("encoding report: %w", err)
+	}
+	return nil
+}
+
+// DeleteFunc deletes a disk with the given provider, unless dryRun is
+// true.
+type DeleteFunc func(ctx context.Context, p unused.Provider, d unused.Disk, dryRun bool) error
+
+func deleteDisk(ctx context.Context, p unused.Provider, d unused.Disk, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+	return p.Delete(ctx, d)
+}
+
+type applyOptions struct {
+	delete  DeleteFunc
+	deleter []deleter.Option
+}
+
+// ApplyOption configures how a plan is applied.
+type ApplyOption func(*applyOptions)
+
+// WithDeleteFunc sets the function used to delete disks, which is also
+// called for disks that would be deleted in a dry run. By default
+// disks are deleted with [unused.Provider.Delete].
+func WithDeleteFunc(fn DeleteFunc) ApplyOption {
+	return func(o *applyOptions) { o.delete = fn }
+}
+
+// WithDeleterOptions configures the [deleter.Deleter] used to delete
+// disks concurrently.
+func WithDeleterOptions(opts ...deleter.Option) ApplyOption {
+	return func(o *applyOptions) { o.deleter = append(o.deleter, opts...) }
+}
+
+// Apply deletes the disks in the plan using the given providers.
+//
+// Each provider in the plan is listed again, and a disk is only
+// deleted if it's still listed as unused with the same size and
+// metadata. When dryRun is true no disk is deleted. Disks are deleted
+// concurrently with a [deleter.Deleter], retrying transient errors.
+//
+// The returned error is only non-nil if listing a provider failed;
+// errors deleting individual disks are reported in the results.
+func Apply(ctx context.Context, p *Plan, providers []unused.Provider, dryRun bool, opts ...ApplyOption) (*Report, error) {
+	o := applyOptions{delete: deleteDisk}
+	for _, fn := range opts {
+		fn(&o)
+	}
+
+	byKey := make(map[string]unused.Provider, len(providers))
+	for _, pp := range providers {
+		byKey[providerKey(pp)] = pp
+	}
+
+	current := make(map[string]map[string]unused.Disk, len(p.Providers))
+	for _, pp := range p.Providers {
+		prov, ok := byKey[pp.key()]
+		if !ok {
+			continue
+		}
+
+		disks, err := prov.ListUnusedDisks(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing unused disks for %s: %w", pp.key(), err)
+		}
+
+		current[pp.key()] = make(map[string]unused.Disk, len(disks))
+		for _, d := range disks {
+			current[pp.key()][d.ID()] = d
+		}
+	}
+
+	r := &Report{
+		AppliedAt: time.Now().UTC(),
+		DryRun:    dryRun,
+		Results:   make([]Result, 0, len(p.Disks)),
+	}
+
+	var (
+		items   []deleter.Item
+		pending []int
+	)
+
+	for _, pd := range p.Disks {
+		res := Result{Provider: pd.Provider, ID: pd.ID, Name: pd.Name}
+
+		disks, ok := current[pd.Provider]
+		d := disks[pd.ID]
+
+		switch {
+		case !ok:
+			res.Status, res.Reason = Skipped, "provider not configured"
+
+		case d == nil:
+			res.Status, res.Reason = Skipped, "disk not found or no longer unused"
+
+		case d.SizeGB() != pd.SizeGB:
+			res.Status, res.Reason = Skipped, fmt.Sprintf("size changed from %d GB to %d GB", pd.SizeGB, d.SizeGB())
+
+		case !maps.Equal(nativeMeta(d.Meta()), nativeMeta(pd.Meta)):
+			res.Status, res.Reason = Skipped, "metadata changed"
+
+		default:
+			items = append(items, deleter.Item{Provider: byKey[pd.Provider], Resource: d})
+			pending = append(pending, len(r.Results))
+		}
+
+		r.Results = append(r.Results, res)
+	}
+
+	dopts := append(o.deleter, deleter.WithDeleteFunc(func(ctx context.Context, p unused.Provider, r unused.Resource) error {
+		return o.delete(ctx, p, r.(unused.Disk), dryRun)
+	}))
+
+	for i, dr := range deleter.New(dopts...).Delete(ctx, items) {
+		res := &r.Results[pending[i]]
+		if dr.Err != nil {
+			res.Status, res.Reason = Failed, dr.Err.Error()
+		} else if dryRun {
+			res.Status = WouldDelete
+		} else {
+			res.Status = Deleted
+		}
+	}
+
+	return r, nil
+}
+
+// enrichmentPrefixes are the prefixes of the metadata keys that aren't
+// set by the providers but derived from other sources, like Kubernetes
+// or CloudTrail, which can change between planning and applying a plan
+// without the disk itself changing.
+var enrichmentPrefixes = []string{"k8s:", "aws:"}
+
+// nativeMeta returns a copy of the given metadata without its
+// enrichment keys.
+func nativeMeta(m unused.Meta) unused.Meta {
+	m = maps.Clone(m)
+	maps.DeleteFunc(m, func(k, _ string) bool {
+		for _, p := range enrichmentPrefixes {
+			if strings.HasPrefix(k, p) {
+				return true
+			}
+		}
+		return false
+	})
+	return m
+}
@@ -0,0 +1,168 @@
+package plan_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/plan"
+	"github.com/grafana/unused/unusedtest"
+)
+
+func TestPlanRoundTrip(t *testing.T) {
+	var (
+		now = time.Now()
+		p   = unusedtest.NewProvider("GCP", unused.Meta{"project": "foo"})
+		d1  = unusedtest.NewDisk("d1", p, now, now)
+		d2  = unusedtest.NewDisk("d2", p, now, now)
+	)
+	d1.SetMeta(unused.Meta{"zone": "us-central1-a"})
+	d1.SetSize(10)
+
+	pl := plan.New([]string{"name=~d.*"}, unused.Disks{d1, d2})
+
+	var buf bytes.Buffer
+	if err := pl.Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := plan.Read(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got.Providers) != 1 || got.Providers[0].Name != "GCP" || got.Providers[0].Meta["project"] != "foo" {
+		t.Errorf("unexpected providers %+v", got.Providers)
+	}
+	if len(got.Filters) != 1 || got.Filters[0] != "name=~d.*" {
+		t.Errorf("unexpected filters %v", got.Filters)
+	}
+	if len(got.Disks) != 2 {
+		t.Fatalf("expecting 2 disks, got %d", len(got.Disks))
+	}
+	if d := got.Disks[0]; d.Name != "d1" || d.SizeGB != 10 || d.Meta["zone"] != "us-central1-a" {
+		t.Errorf("unexpected disk %+v", d)
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := map[string]string{
+		"invalid json":     `{`,
+		"version":          `{"version": 2}`,
+		"unknown provider": `{"version": 1, "disks": [{"provider": "GCP/foo", "id": "d1"}]}`,
+	}
+
+	for name, doc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := plan.Read(strings.NewReader(doc))
+			if !errors.Is(err, plan.ErrInvalidPlan) {
+				t.Fatalf("expecting error %v, got %v", plan.ErrInvalidPlan, err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	setup := func() (*unusedtest.Provider, *plan.Plan) {
+		p := unusedtest.NewProvider("GCP", nil)
+
+		var disks []unusedtest.Disk
+		for _, name := range []string{"same", "gone", "resized", "relabeled", "enriched"} {
+			d := unusedtest.NewDisk(name, p, now, now)
+			d.SetMeta(unused.Meta{"team": "foo"})
+			d.SetSize(10)
+			disks = append(disks, d)
+		}
+
+		disks[4].SetMeta(unused.Meta{"team": "foo", "k8s:pv-state": "Released"})
+
+		pl := plan.New(nil, unused.Disks{disks[0], disks[1], disks[2], disks[3], disks[4]})
+
+		disks[2].SetSize(20)
+		disks[3].SetMeta(unused.Meta{"team": "bar"})
+		// only enrichment keys changed, so the disk is still deleted
+		disks[4].SetMeta(unused.Meta{"team": "foo", "aws:last-attached-instance": "i-123"})
+
+		return unusedtest.NewProvider("GCP", nil, disks[0], disks[2], disks[3], disks[4]), pl
+	}
+
+	exp := []plan.Status{plan.Deleted, plan.Skipped, plan.Skipped, plan.Skipped, plan.Deleted}
+
+	t.Run("apply", func(t *testing.T) {
+		p, pl := setup()
+
+		r, err := plan.Apply(ctx, pl, []unused.Provider{p}, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for i, res := range r.Results {
+			if res.Status != exp[i] {
+				t.Errorf("%s: expecting status %s, got %s (%s)", res.Name, exp[i], res.Status, res.Reason)
+			}
+		}
+
+		disks, _ := p.ListUnusedDisks(ctx)
+		if len(disks) != 2 {
+			t.Errorf("expecting 2 remaining disks, got %d", len(disks))
+		}
+		if r.Failed() {
+			t.Error("expecting report to not have failures")
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		p, pl := setup()
+
+		r, err := plan.Apply(ctx, pl, []unused.Provider{p}, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s := r.Results[0].Status; s != plan.WouldDelete {
+			t.Errorf("expecting status %s, got %s", plan.WouldDelete, s)
+		}
+
+		disks, _ := p.ListUnusedDisks(ctx)
+		if len(disks) != 4 {
+			t.Errorf("expecting 4 remaining disks, got %d", len(disks))
+		}
+	})
+
+	t.Run("delete func", func(t *testing.T) {
+		p, pl := setup()
+
+		var calls []bool
+		fn := func(ctx context.Context, p unused.Provider, d unused.Disk, dryRun bool) error {
+			calls = append(calls, dryRun)
+			return nil
+		}
+
+		if _, err := plan.Apply(ctx, pl, []unused.Provider{p}, true, plan.WithDeleteFunc(fn)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(calls) != 2 || !calls[0] || !calls[1] {
+			t.Errorf("expecting two dry-run calls, got %v", calls)
+		}
+	})
+
+	t.Run("missing provider", func(t *testing.T) {
+		_, pl := setup()
+
+		r, err := plan.Apply(ctx, pl, []unused.Provider{unusedtest.NewProvider("AWS", nil)}, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, res := range r.Results {
+			if res.Status != plan.Skipped {
+				t.Errorf("%s: expecting status %s, got %s", res.Name, plan.Skipped, res.Status)
+			}
+		}
+	})
+}
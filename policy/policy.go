@@ -0,0 +1,221 @@
+// Package policy evaluates unused disks against a declarative set of
+// retention rules.
+//
+// Policies are loaded from YAML files with an ordered list of rules;
+// the first rule matching a disk decides its verdict:
+//
+//	default: keep
+//	rules:
+//	  - name: keep-tagged
+//	    match: keep==true
+//	    action: keep
+//	  - name: dev
+//	    match: k8s:ns=~".*-dev"
+//	    min_unused: 14d
+//	    action: delete
+//	  - name: prod
+//	    match: k8s:ns=~".*-prod"
+//	    min_unused: 90d
+//	    action: delete
+//
+// Rules match disks using the expression language of the filter
+// package, and can optionally require a minimum age or unused time.
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/filter"
+	"go.yaml.in/yaml/v3"
+)
+
+// ErrInvalidPolicy is returned when a policy cannot be loaded.
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// Action is the verdict of a policy for a disk.
+type Action string
+
+const (
+	// Keep means the disk must not be deleted.
+	Keep Action = "keep"
+
+	// Warn means the disk should be reviewed.
+	Warn Action = "warn"
+
+	// Delete means the disk can be deleted.
+	Delete Action = "delete"
+)
+
+// Actions lists all the valid actions.
+var Actions = []Action{Keep, Warn, Delete}
+
+func (a Action) valid() bool {
+	switch a {
+	case Keep, Warn, Delete:
+		return true
+	default:
+		return false
+	}
+}
+
+// Duration is a [time.Duration] accepting days and years as units in
+// YAML documents, as in 14d or 1y.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(n *yaml.Node) error {
+	var s string
+	if err := n.Decode(&s); err != nil {
+		return err
+	}
+
+	v, err := filter.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+
+	*d = Duration(v)
+
+	return nil
+}
+
+// Rule is a policy rule.
+type Rule struct {
+	// Name identifies the rule in verdicts.
+	Name string `yaml:"name"`
+
+	// Match is a filter expression; an empty expression matches all
+	// disks.
+	Match string `yaml:"match"`
+
+	// MinAge is the minimum time since the disk was created.
+	MinAge Duration `yaml:"min_age"`
+
+	// MinUnused is the minimum time since the disk was last used. Disks
+	// whose last use is unknown, as is usual for providers that don't
+	// report it, never match a rule with a MinUnused, as their creation
+	// time says nothing about how long ago they were detached.
+	MinUnused Duration `yaml:"min_unused"`
+
+	// Action is the verdict for disks matching this rule.
+	Action Action `yaml:"action"`
+
+	match unused.FilterFunc
+}
+
+// Matches returns whether the given disk matches the rule.
+func (r *Rule) Matches(d unused.Disk) bool {
+	if r.match != nil && !r.match(d) {
+		return false
+	}
+
+	if time.Since(d.CreatedAt()) < time.Duration(r.MinAge) {
+		return false
+	}
+
+	if r.MinUnused == 0 {
+		return true
+	}
+
+	lastUsed := d.LastUsedAt()
+	if lastUsed.IsZero() {
+		return false
+	}
+
+	return time.Since(lastUsed) >= time.Duration(r.MinUnused)
+}
+
+// Policy is an ordered list of rules.
+type Policy struct {
+	// Default is the action for disks not matching any rule; it
+	// defaults to [Keep].
+	Default Action `yaml:"default"`
+
+	Rules []*Rule `yaml:"rules"`
+}
+
+// Verdict is the result of evaluating a disk against a policy.
+type Verdict struct {
+	Action Action
+
+	// Rule is the name of the matching rule, or empty if the default
+	// action was used.
+	Rule string
+}
+
+// Evaluate returns the verdict for the given disk.
+func (p *Policy) Evaluate(d unused.Disk) Verdict {
+	for _, r := range p.Rules {
+		if r.Matches(d) {
+			return Verdict{r.Action, r.Name}
+		}
+	}
+
+	return Verdict{Action: p.Default}
+}
+
+// Parse parses and validates a YAML policy.
+func Parse(b []byte) (*Policy, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+
+	var p Policy
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+	}
+
+	if p.Default == "" {
+		p.Default = Keep
+	}
+	if !p.Default.valid() {
+		return nil, fmt.Errorf("%w: invalid default action %q", ErrInvalidPolicy, p.Default)
+	}
+
+	names := make(map[string]struct{}, len(p.Rules))
+
+	for i, r := range p.Rules {
+		if r == nil {
+			return nil, fmt.Errorf("%w: rule %d is empty", ErrInvalidPolicy, i)
+		}
+		if r.Name == "" {
+			return nil, fmt.Errorf("%w: rule %d has no name", ErrInvalidPolicy, i)
+		}
+		if _, ok := names[r.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate rule %s", ErrInvalidPolicy, r.Name)
+		}
+		names[r.Name] = struct{}{}
+
+		if !r.Action.valid() {
+			return nil, fmt.Errorf("%w: rule %s has invalid action %q", ErrInvalidPolicy, r.Name, r.Action)
+		}
+
+		if r.Match != "" {
+			fn, err := filter.Compile(r.Match)
+			if err != nil {
+				return nil, fmt.Errorf("%w: rule %s: %w", ErrInvalidPolicy, r.Name, err)
+			}
+			r.match = fn
+		}
+	}
+
+	return &p, nil
+}
+
+// Load reads and parses the policy in the given YAML file.
+func Load(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy file: %w", err)
+	}
+
+	p, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("loading policy file %s: %w", path, err)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,143 @@
+package policy_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/policy"
+	"github.com/grafana/unused/unusedtest"
+)
+
+const doc = `
+rules:
+  - name: keep-tagged
+    match: keep==true
+    action: keep
+  - name: dev
+    match: k8s:ns=~".*-dev"
+    min_unused: 14d
+    action: delete
+  - name: prod
+    match: k8s:ns=~".*-prod"
+    min_unused: 90d
+    action: delete
+  - name: prod-review
+    match: k8s:ns=~".*-prod" && size_gb>=100
+    min_age: 30d
+    action: warn
+`
+
+func TestPolicyEvaluate(t *testing.T) {
+	p, err := policy.Parse([]byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var (
+		now = time.Now()
+		day = 24 * time.Hour
+		pr  = unusedtest.NewProvider("GCP", nil)
+	)
+
+	disk := func(name, ns string, created, lastUsed time.Duration, size int, meta unused.Meta) unused.Disk {
+		var lu time.Time
+		if lastUsed != 0 {
+			lu = now.Add(-lastUsed)
+		}
+		d := unusedtest.NewDisk(name, pr, now.Add(-created), lu)
+		if meta == nil {
+			meta = unused.Meta{}
+		}
+		meta["kubernetes.io/created-for/pvc/namespace"] = ns
+		d.SetMeta(meta)
+		d.SetSize(size)
+		return d
+	}
+
+	tests := []struct {
+		disk unused.Disk
+		exp  policy.Verdict
+	}{
+		{disk("tagged", "loki-dev", 100*day, 100*day, 10, unused.Meta{"keep": "true"}), policy.Verdict{policy.Keep, "keep-tagged"}},
+		{disk("dev-old", "loki-dev", 30*day, 15*day, 10, nil), policy.Verdict{policy.Delete, "dev"}},
+		{disk("dev-recent", "loki-dev", 30*day, 2*day, 10, nil), policy.Verdict{policy.Keep, ""}},
+		{disk("dev-unknown-last-use", "loki-dev", 20*day, 0, 10, nil), policy.Verdict{policy.Keep, ""}},
+		{disk("prod-old-unknown-last-use", "loki-prod", 365*day, 0, 10, nil), policy.Verdict{policy.Keep, ""}},
+		{disk("prod-large-unknown-last-use", "loki-prod", 365*day, 0, 500, nil), policy.Verdict{policy.Warn, "prod-review"}},
+		{disk("prod-old", "loki-prod", 200*day, 91*day, 10, nil), policy.Verdict{policy.Delete, "prod"}},
+		{disk("prod-large", "loki-prod", 60*day, 40*day, 500, nil), policy.Verdict{policy.Warn, "prod-review"}},
+		{disk("prod-small", "loki-prod", 60*day, 40*day, 10, nil), policy.Verdict{policy.Keep, ""}},
+		{disk("other", "", 400*day, 400*day, 10, nil), policy.Verdict{policy.Keep, ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.disk.Name(), func(t *testing.T) {
+			if got := p.Evaluate(tt.disk); got != tt.exp {
+				t.Errorf("expecting %+v, got %+v", tt.exp, got)
+			}
+		})
+	}
+}
+
+func TestPolicyDefault(t *testing.T) {
+	p, err := policy.Parse([]byte("default: warn\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d := unusedtest.NewDisk("foo", nil, time.Now(), time.Now())
+	if got, exp := p.Evaluate(d), (policy.Verdict{Action: policy.Warn}); got != exp {
+		t.Errorf("expecting %+v, got %+v", exp, got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"invalid yaml":     "rules: [",
+		"unknown field":    "rules:\n  - name: foo\n    action: keep\n    whatever: true\n",
+		"default action":   "default: nuke\n",
+		"empty rule":       "rules:\n  - ~\n",
+		"missing name":     "rules:\n  - action: keep\n",
+		"duplicate name":   "rules:\n  - name: foo\n    action: keep\n  - name: foo\n    action: warn\n",
+		"invalid action":   "rules:\n  - name: foo\n    action: nuke\n",
+		"missing action":   "rules:\n  - name: foo\n",
+		"invalid match":    "rules:\n  - name: foo\n    match: name==\n    action: keep\n",
+		"invalid duration": "rules:\n  - name: foo\n    min_unused: soon\n    action: keep\n",
+	}
+
+	for name, doc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := policy.Parse([]byte(doc))
+			if !errors.Is(err, policy.ErrInvalidPolicy) {
+				t.Fatalf("expecting error %v, got %v", policy.ErrInvalidPolicy, err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := policy.Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+			t.Fatal("expecting error, got nil")
+		}
+	})
+
+	t.Run("valid file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+			t.Fatalf("writing policy file: %v", err)
+		}
+
+		p, err := policy.Load(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(p.Rules) != 4 {
+			t.Errorf("expecting 4 rules, got %d", len(p.Rules))
+		}
+	})
+}
@@ -0,0 +1,142 @@
+// Package pricing estimates the monthly cost of unused disks.
+//
+// Prices are expressed in USD per GB (GiB for AWS) per month and are
+// indexed by provider, region and the provider native disk type.
+// Providers are keyed by their lowercased registered name, see
+// [unused.Register], so that any registered provider can be priced.
+// A default set of prices is embedded in the package; these are
+// approximations of the public list prices and can be overridden by
+// loading a local file with the same format.
+package pricing
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"strings"
+
+	"github.com/grafana/unused"
+)
+
+// Provider keys used in the price tables for the built-in providers,
+// their lowercased registered names.
+const (
+	AWS   = "aws"
+	GCP   = "gcp"
+	Azure = "azure"
+)
+
+// AnyRegion is the region key used for prices that apply to every
+// region without a specific entry.
+const AnyRegion = "*"
+
+//go:embed prices.json
+var defaultPrices []byte
+
+// Prices maps providers to regions to native disk types to the price
+// of one GB per month.
+type Prices map[string]map[string]map[string]float64
+
+// Lookup returns the price per GB per month for the given provider,
+// region and native disk type, falling back to the price for any
+// region if there's no specific entry for the region.
+func (p Prices) Lookup(provider, region, diskType string) (float64, bool) {
+	regions := p[provider]
+
+	if price, ok := regions[region][diskType]; ok {
+		return price, true
+	}
+
+	price, ok := regions[AnyRegion][diskType]
+	return price, ok
+}
+
+// merge overrides the prices with the ones in o.
+func (p Prices) merge(o Prices) {
+	for provider, regions := range o {
+		if p[provider] == nil {
+			p[provider] = make(map[string]map[string]float64)
+		}
+		for region, types := range regions {
+			if p[provider][region] == nil {
+				p[provider][region] = make(map[string]float64)
+			}
+			for t, price := range types {
+				p[provider][region][t] = price
+			}
+		}
+	}
+}
+
+// Estimator estimates the monthly cost of disks.
+type Estimator struct {
+	prices Prices
+}
+
+// Default returns an [Estimator] using the embedded default prices.
+func Default() *Estimator {
+	var prices Prices
+	if err := json.Unmarshal(defaultPrices, &prices); err != nil {
+		panic(fmt.Sprintf("invalid embedded prices: %v", err))
+	}
+
+	return &Estimator{prices}
+}
+
+// Load returns an [Estimator] using the default prices overridden by
+// the ones in the given JSON file. An empty path returns the default
+// estimator.
+func Load(path string) (*Estimator, error) {
+	e := Default()
+
+	if path == "" {
+		return e, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading prices file: %w", err)
+	}
+
+	var prices Prices
+	if err := json.Unmarshal(b, &prices); err != nil {
+		return nil, fmt.Errorf("decoding prices file %s: %w", path, err)
+	}
+
+	e.prices.merge(prices)
+
+	return e, nil
+}
+
+// MonthlyCost returns the estimated monthly cost in USD of the given
+// disk, including all its replicas. It returns false if there is no
+// price for the disk.
+func (e *Estimator) MonthlyCost(d unused.Disk) (float64, bool) {
+	provider := providerKey(d.Provider())
+	if provider == "" {
+		return 0, false
+	}
+
+	class := d.Class()
+	price, ok := e.prices.Lookup(provider, d.Location().Region, class.Type)
+	if !ok {
+		return 0, false
+	}
+
+	return price * float64(d.SizeGB()) * float64(max(class.Replicas, 1)), true
+}
+
+// providerKey returns the price table key of the given provider: the
+// lowercased name it was registered with, or its lowercased name if
+// it's not registered, so that renamed providers keep their prices.
+func providerKey(p unused.Provider) string {
+	if p == nil {
+		return ""
+	}
+	if r, ok := unused.LookupRegistration(p); ok {
+		return strings.ToLower(r.Name)
+	}
+	return strings.ToLower(p.Name())
+}
@@ -0,0 +1,139 @@
+package pricing_test
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/aws"
+	"github.com/grafana/unused/azure"
+	"github.com/grafana/unused/gcp"
+	"github.com/grafana/unused/pricing"
+	"github.com/grafana/unused/unusedtest"
+)
+
+// newDisk returns a test disk of the given provider, type and size.
+func newDisk(provider, typ string, sizeGB int) unused.Disk {
+	d := unusedtest.NewDisk("disk", unusedtest.NewProvider(provider, nil), time.Now(), time.Now())
+	d.SetClass(unused.DiskClass{Type: typ})
+	d.SetSize(sizeGB)
+	return d
+}
+
+func TestPricesLookup(t *testing.T) {
+	prices := pricing.Prices{
+		"aws": {
+			"*":         {"gp3": 0.08},
+			"eu-west-1": {"gp3": 0.088},
+		},
+	}
+
+	tests := []struct {
+		provider, region, diskType string
+		exp                        float64
+		ok                         bool
+	}{
+		{"aws", "eu-west-1", "gp3", 0.088, true},
+		{"aws", "us-east-1", "gp3", 0.08, true},
+		{"aws", "", "gp3", 0.08, true},
+		{"aws", "eu-west-1", "gp2", 0, false},
+		{"gcp", "us-central1", "pd-ssd", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := prices.Lookup(tt.provider, tt.region, tt.diskType)
+		if got != tt.exp || ok != tt.ok {
+			t.Errorf("expecting Lookup(%q, %q, %q) = (%v, %v), got (%v, %v)", tt.provider, tt.region, tt.diskType, tt.exp, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestEstimatorMonthlyCost(t *testing.T) {
+	e := pricing.Default()
+
+	regional := unusedtest.NewDisk("regional", unusedtest.NewProvider(gcp.ProviderName, nil), time.Now(), time.Now())
+	regional.SetClass(unused.DiskClass{Type: "pd-standard", Replicas: 2})
+	regional.SetSize(100)
+
+	tests := map[string]struct {
+		disk unused.Disk
+		exp  float64
+		ok   bool
+	}{
+		"AWS":              {newDisk(aws.ProviderName, "gp3", 100), 8, true},
+		"GCP":              {newDisk(gcp.ProviderName, "pd-standard", 100), 4, true},
+		"GCP regional":     {regional, 8, true},
+		"Azure":            {newDisk(azure.ProviderName, "Premium_LRS", 100), 13.5, true},
+		"unknown type":     {newDisk(aws.ProviderName, "foo", 100), 0, false},
+		"unknown provider": {newDisk("test", "gp3", 100), 0, false},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got, ok := e.MonthlyCost(tt.disk)
+			if ok != tt.ok || math.Abs(got-tt.exp) > 1e-9 {
+				t.Errorf("expecting MonthlyCost() = (%v, %v), got (%v, %v)", tt.exp, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("empty path returns defaults", func(t *testing.T) {
+		e, err := pricing.Load("")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if e == nil {
+			t.Fatal("expecting estimator, got nil")
+		}
+	})
+
+	t.Run("file overrides defaults", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "prices.json")
+		err := os.WriteFile(path, []byte(`{"aws":{"*":{"gp3":1}},"custom":{"*":{"fast":2}}}`), 0o600)
+		if err != nil {
+			t.Fatalf("unexpected error writing prices file: %v", err)
+		}
+
+		e, err := pricing.Load(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got, ok := e.MonthlyCost(newDisk(aws.ProviderName, "gp3", 10))
+		if !ok || got != 10 {
+			t.Errorf("expecting overridden gp3 cost of 10, got (%v, %v)", got, ok)
+		}
+
+		got, ok = e.MonthlyCost(newDisk(aws.ProviderName, "gp2", 10))
+		if !ok || got != 1 {
+			t.Errorf("expecting default gp2 cost of 1, got (%v, %v)", got, ok)
+		}
+
+		got, ok = e.MonthlyCost(newDisk("Custom", "fast", 10))
+		if !ok || got != 20 {
+			t.Errorf("expecting custom provider cost of 20, got (%v, %v)", got, ok)
+		}
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "prices.json")
+		if err := os.WriteFile(path, []byte(`not json`), 0o600); err != nil {
+			t.Fatalf("unexpected error writing prices file: %v", err)
+		}
+
+		if _, err := pricing.Load(path); err == nil {
+			t.Fatal("expecting error loading invalid file")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := pricing.Load(filepath.Join(t.TempDir(), "nope.json")); err == nil {
+			t.Fatal("expecting error loading missing file")
+		}
+	})
+}
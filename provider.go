@@ -20,3 +20,44 @@ type Provider interface {
 	// Delete deletes a disk from the provider.
 	Delete(ctx context.Context, disk Disk) error
 }
+
+// Snapshotter is implemented by providers that are able to take a
+// snapshot of a disk, for instance as a safety net before deleting
+// it.
+type Snapshotter interface {
+	// Snapshot creates a snapshot of the given disk and waits until
+	// it has completed, returning the provider specific ID of the
+	// newly created snapshot.
+	Snapshot(ctx context.Context, disk Disk) (string, error)
+}
+
+// Tagger is implemented by providers that are able to set tags (AWS
+// and Azure) or labels (GCP) on disks. Tags set on a disk are returned
+// as part of its metadata.
+type Tagger interface {
+	// TagDisk sets the given tags on the disk, keeping any other
+	// existing tags.
+	TagDisk(ctx context.Context, disk Disk, tags map[string]string) error
+}
+
+// As finds the first provider in the chain of wrapped providers that
+// implements T, returning it and true if found.
+//
+// Providers wrapping another provider to extend its behavior should
+// implement an Unwrap() Provider method returning the wrapped one, so
+// that optional interfaces like [Snapshotter] or [AddressProvider]
+// can still be found.
+func As[T any](p Provider) (T, bool) {
+	for {
+		if t, ok := p.(T); ok {
+			return t, true
+		}
+
+		u, ok := p.(interface{ Unwrap() Provider })
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		p = u.Unwrap()
+	}
+}
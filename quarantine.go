@@ -0,0 +1,45 @@
+package unused
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// QuarantineKey is the tag set on quarantined disks, with the Unix
+// time in seconds when they were quarantined as value. Seconds are
+// used as they are a valid tag or label value in all providers.
+const QuarantineKey = "unused-quarantined-at"
+
+// ErrTaggingUnsupported is returned when quarantining a disk of a
+// provider that doesn't implement [Tagger].
+var ErrTaggingUnsupported = errors.New("provider doesn't support tagging disks")
+
+// QuarantineDisk tags the given disk as quarantined at the given time.
+func QuarantineDisk(ctx context.Context, p Provider, d Disk, at time.Time) error {
+	t, ok := As[Tagger](p)
+	if !ok {
+		return fmt.Errorf("%s: %w", p.Name(), ErrTaggingUnsupported)
+	}
+
+	return t.TagDisk(ctx, d, map[string]string{QuarantineKey: strconv.FormatInt(at.Unix(), 10)})
+}
+
+// QuarantinedAt returns when the disk with this metadata was
+// quarantined, and false if it isn't quarantined or the tag value is
+// not valid.
+func (m Meta) QuarantinedAt() (time.Time, bool) {
+	v, ok := m[QuarantineKey]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(sec, 0), true
+}
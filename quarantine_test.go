@@ -0,0 +1,66 @@
+package unused_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/unusedtest"
+)
+
+func TestQuarantineDisk(t *testing.T) {
+	var (
+		ctx = context.Background()
+		now = time.Now()
+		at  = time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	)
+
+	d := unusedtest.NewDisk("disk-1", nil, now, now)
+	d.SetMeta(unused.Meta{"team": "foo"})
+	p := unusedtest.NewProvider("foo", nil, d)
+
+	if err := unused.QuarantineDisk(ctx, p, d, at); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	disks, _ := p.ListUnusedDisks(ctx)
+	m := disks[0].Meta()
+
+	got, ok := m.QuarantinedAt()
+	if !ok || !got.Equal(at) {
+		t.Errorf("expecting disk quarantined at %v, got %v (%v)", at, got, ok)
+	}
+	if m["team"] != "foo" {
+		t.Errorf("expecting existing metadata to be kept, got %v", m)
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		err := unused.QuarantineDisk(ctx, disksOnlyProvider{p}, d, at)
+		if !errors.Is(err, unused.ErrTaggingUnsupported) {
+			t.Fatalf("expecting error %v, got %v", unused.ErrTaggingUnsupported, err)
+		}
+	})
+}
+
+func TestMetaQuarantinedAt(t *testing.T) {
+	tests := map[string]struct {
+		meta unused.Meta
+		exp  time.Time
+		ok   bool
+	}{
+		"not quarantined": {unused.Meta{}, time.Time{}, false},
+		"quarantined":     {unused.Meta{unused.QuarantineKey: "1700000000"}, time.Unix(1700000000, 0), true},
+		"invalid":         {unused.Meta{unused.QuarantineKey: "yesterday"}, time.Time{}, false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := tt.meta.QuarantinedAt()
+			if ok != tt.ok || !got.Equal(tt.exp) {
+				t.Errorf("expecting (%v, %v), got (%v, %v)", tt.exp, tt.ok, got, ok)
+			}
+		})
+	}
+}
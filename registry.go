@@ -0,0 +1,84 @@
+package unused
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// CreateFunc creates the providers configured by the flags of a
+// [Registration]. It returns no providers when none were configured.
+type CreateFunc func(ctx context.Context, logger *slog.Logger) ([]Provider, error)
+
+// Registration describes a kind of provider, so that binaries can
+// configure and create providers without knowing about each of them.
+type Registration struct {
+	// Name is the name of the providers, as returned by their Name
+	// method.
+	Name string
+
+	// Flags adds the flags configuring the providers to fs, returning
+	// the function creating the providers once the flags are parsed.
+	Flags func(fs *flag.FlagSet) CreateFunc
+
+	// Match reports whether p was created by this registration, in
+	// case its name was changed. Optional.
+	Match func(p Provider) bool
+
+	// DiscoveryFlags are the names of the flags enabling the discovery
+	// of providers, like the projects of a GCP organization, which can
+	// change while binaries run. Optional.
+	DiscoveryFlags []string
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []Registration
+)
+
+// Register makes a kind of provider available to binaries. It's meant
+// to be called from the init function of provider packages, and panics
+// if the registration has no name or flags, or if its name is already
+// registered.
+func Register(r Registration) {
+	if r.Name == "" || r.Flags == nil {
+		panic("unused: Register called without name or flags")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if slices.ContainsFunc(registry, func(o Registration) bool { return strings.EqualFold(o.Name, r.Name) }) {
+		panic(fmt.Sprintf("unused: Register called twice for provider %s", r.Name))
+	}
+
+	registry = append(registry, r)
+	slices.SortFunc(registry, func(a, b Registration) int { return strings.Compare(a.Name, b.Name) })
+}
+
+// Registrations returns the registered kinds of providers sorted by
+// name.
+func Registrations() []Registration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return slices.Clone(registry)
+}
+
+// LookupRegistration returns the registration of the given provider.
+func LookupRegistration(p Provider) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, r := range registry {
+		if r.Name == p.Name() || (r.Match != nil && r.Match(p)) {
+			return r, true
+		}
+	}
+
+	return Registration{}, false
+}
@@ -0,0 +1,73 @@
+package unused_test
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"testing"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/unusedtest"
+)
+
+func TestRegister(t *testing.T) {
+	var created []string
+
+	unused.Register(unused.Registration{
+		Name: "registry-test",
+		Flags: func(fs *flag.FlagSet) unused.CreateFunc {
+			project := fs.String("registry-test.project", "", "")
+			return func(context.Context, *slog.Logger) ([]unused.Provider, error) {
+				created = append(created, *project)
+				return nil, nil
+			}
+		},
+		Match: func(p unused.Provider) bool { return p.Name() == "renamed" },
+	})
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var create []unused.CreateFunc
+	for _, r := range unused.Registrations() {
+		create = append(create, r.Flags(fs))
+	}
+	if err := fs.Parse([]string{"-registry-test.project=foo"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, fn := range create {
+		fn(context.Background(), slog.Default()) // nolint:errcheck
+	}
+	if len(created) != 1 || created[0] != "foo" {
+		t.Errorf("expecting provider created from flags, got %v", created)
+	}
+
+	tests := map[string]struct {
+		provider string
+		found    bool
+	}{
+		"by name":  {"registry-test", true},
+		"by match": {"renamed", true},
+		"unknown":  {"unknown", false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, ok := unused.LookupRegistration(unusedtest.NewProvider(tt.provider, nil))
+			if ok != tt.found || (ok && r.Name != "registry-test") {
+				t.Errorf("expecting registration found %t, got %t for %q", tt.found, ok, r.Name)
+			}
+		})
+	}
+
+	t.Run("duplicate", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expecting panic registering a provider twice")
+			}
+		}()
+
+		unused.Register(unused.Registration{
+			Name:  "Registry-Test",
+			Flags: func(*flag.FlagSet) unused.CreateFunc { return nil },
+		})
+	})
+}
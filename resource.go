@@ -0,0 +1,160 @@
+package unused
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Resource represents an unused resource on a given cloud provider.
+type Resource interface {
+	// ID should return a unique ID for a resource within each cloud
+	// provider.
+	ID() string
+
+	// Provider returns a reference to the provider used to instantiate
+	// this resource.
+	Provider() Provider
+
+	// Name returns the resource name.
+	Name() string
+
+	// CreatedAt returns the time when the resource was created.
+	CreatedAt() time.Time
+
+	// Meta returns the resource metadata.
+	Meta() Meta
+
+	// Kind returns the kind of resource.
+	Kind() ResourceKind
+}
+
+// ResourceKind identifies the different kinds of unused resources.
+type ResourceKind string
+
+const (
+	KindDisk     ResourceKind = "disk"
+	KindAddress  ResourceKind = "address"
+	KindSnapshot ResourceKind = "snapshot"
+)
+
+// Plural returns the plural form of the resource kind, useful for
+// displaying it to users.
+func (k ResourceKind) Plural() string {
+	if k == KindAddress {
+		return "addresses"
+	}
+	return string(k) + "s"
+}
+
+// Resources is a collection of Resource.
+type Resources []Resource
+
+// Filter returns the resources for which fn returns true.
+func (r Resources) Filter(fn ResourceFilterFunc) Resources {
+	rs := make(Resources, 0, len(r))
+	for _, e := range r {
+		if fn(e) {
+			rs = append(rs, e)
+		}
+	}
+	return rs
+}
+
+// ErrUnsupportedKind is returned when a provider doesn't support the
+// requested kind of resource.
+var ErrUnsupportedKind = errors.New("unsupported resource kind")
+
+type listOptions struct {
+	snapshotRetention time.Duration
+}
+
+// ListOption configures how unused resources are listed.
+type ListOption func(*listOptions)
+
+// WithSnapshotRetention sets the retention after which snapshots are
+// considered unused even if their source disk still exists.
+func WithSnapshotRetention(d time.Duration) ListOption {
+	return func(o *listOptions) { o.snapshotRetention = d }
+}
+
+// ListUnusedResources returns the unused resources of the given kind
+// in the provider.
+func ListUnusedResources(ctx context.Context, p Provider, kind ResourceKind, opts ...ListOption) (Resources, error) {
+	var o listOptions
+	for _, fn := range opts {
+		fn(&o)
+	}
+
+	switch kind {
+	case KindDisk:
+		disks, err := p.ListUnusedDisks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rs := make(Resources, len(disks))
+		for i, d := range disks {
+			rs[i] = d
+		}
+		return rs, nil
+
+	case KindAddress:
+		ap, ok := As[AddressProvider](p)
+		if !ok {
+			return nil, fmt.Errorf("%w %s for provider %s", ErrUnsupportedKind, kind, p.Name())
+		}
+		addrs, err := ap.ListUnusedAddresses(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rs := make(Resources, len(addrs))
+		for i, a := range addrs {
+			rs[i] = a
+		}
+		return rs, nil
+
+	case KindSnapshot:
+		sp, ok := As[SnapshotProvider](p)
+		if !ok {
+			return nil, fmt.Errorf("%w %s for provider %s", ErrUnsupportedKind, kind, p.Name())
+		}
+		snaps, err := sp.ListUnusedSnapshots(ctx, o.snapshotRetention)
+		if err != nil {
+			return nil, err
+		}
+		rs := make(Resources, len(snaps))
+		for i, s := range snaps {
+			rs[i] = s
+		}
+		return rs, nil
+
+	default:
+		return nil, fmt.Errorf("%w %s", ErrUnsupportedKind, kind)
+	}
+}
+
+// DeleteResource deletes the given resource using the provider.
+func DeleteResource(ctx context.Context, p Provider, r Resource) error {
+	switch r := r.(type) {
+	case Disk:
+		return p.Delete(ctx, r)
+
+	case Address:
+		ap, ok := As[AddressProvider](p)
+		if !ok {
+			return fmt.Errorf("%w %s for provider %s", ErrUnsupportedKind, r.Kind(), p.Name())
+		}
+		return ap.DeleteAddress(ctx, r)
+
+	case Snapshot:
+		sp, ok := As[SnapshotProvider](p)
+		if !ok {
+			return fmt.Errorf("%w %s for provider %s", ErrUnsupportedKind, r.Kind(), p.Name())
+		}
+		return sp.DeleteSnapshot(ctx, r)
+
+	default:
+		return fmt.Errorf("%w %s", ErrUnsupportedKind, r.Kind())
+	}
+}
@@ -0,0 +1,145 @@
+package unused_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/unusedtest"
+)
+
+type disksOnlyProvider struct {
+	unused.Provider
+}
+
+func TestListUnusedResources(t *testing.T) {
+	var (
+		ctx = context.Background()
+		now = time.Now()
+
+		disk = unusedtest.NewDisk("disk", nil, now, now)
+		addr = unusedtest.NewAddress("addr", "10.0.0.1", nil, now)
+		p    = unusedtest.NewProvider("foo", nil, disk)
+	)
+
+	p.SetAddresses(addr)
+	p.SetSnapshots(
+		unusedtest.NewSnapshot("snap", "disk-gone", true, nil, now),
+		unusedtest.NewSnapshot("recent", "disk", false, nil, now),
+	)
+
+	tests := map[unused.ResourceKind]string{
+		unused.KindDisk:     "disk",
+		unused.KindAddress:  "addr",
+		unused.KindSnapshot: "snap",
+	}
+
+	for kind, exp := range tests {
+		t.Run(string(kind), func(t *testing.T) {
+			rs, err := unused.ListUnusedResources(ctx, p, kind)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(rs) != 1 {
+				t.Fatalf("expecting 1 resource, got %d", len(rs))
+			}
+			if got := rs[0].Name(); got != exp {
+				t.Errorf("expecting resource %q, got %q", exp, got)
+			}
+			if got := rs[0].Kind(); got != kind {
+				t.Errorf("expecting kind %q, got %q", kind, got)
+			}
+		})
+	}
+
+	t.Run("snapshot retention", func(t *testing.T) {
+		rs, err := unused.ListUnusedResources(ctx, p, unused.KindSnapshot, unused.WithSnapshotRetention(time.Nanosecond))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(rs) != 2 {
+			t.Fatalf("expecting 2 snapshots, got %d", len(rs))
+		}
+	})
+
+	t.Run("unsupported kind", func(t *testing.T) {
+		_, err := unused.ListUnusedResources(ctx, p, "foo")
+		if !errors.Is(err, unused.ErrUnsupportedKind) {
+			t.Fatalf("expecting error %v, got %v", unused.ErrUnsupportedKind, err)
+		}
+
+		_, err = unused.ListUnusedResources(ctx, disksOnlyProvider{p}, unused.KindAddress)
+		if !errors.Is(err, unused.ErrUnsupportedKind) {
+			t.Fatalf("expecting error %v, got %v", unused.ErrUnsupportedKind, err)
+		}
+	})
+}
+
+func TestDeleteResource(t *testing.T) {
+	var (
+		ctx = context.Background()
+		now = time.Now()
+
+		disk = unusedtest.NewDisk("disk", nil, now, now)
+		addr = unusedtest.NewAddress("addr", "10.0.0.1", nil, now)
+		snap = unusedtest.NewSnapshot("snap", "", true, nil, now)
+		p    = unusedtest.NewProvider("foo", nil, disk)
+	)
+
+	p.SetAddresses(addr)
+	p.SetSnapshots(snap)
+
+	for _, r := range []unused.Resource{disk, addr, snap} {
+		if err := unused.DeleteResource(ctx, p, r); err != nil {
+			t.Fatalf("unexpected error deleting %s: %v", r.Kind(), err)
+		}
+	}
+
+	if ds, _ := p.ListUnusedDisks(ctx); len(ds) != 0 {
+		t.Errorf("expecting disk to be deleted, got %v", ds)
+	}
+	if as, _ := p.ListUnusedAddresses(ctx); len(as) != 0 {
+		t.Errorf("expecting address to be deleted, got %v", as)
+	}
+	if ss, _ := p.ListUnusedSnapshots(ctx, 0); len(ss) != 0 {
+		t.Errorf("expecting snapshot to be deleted, got %v", ss)
+	}
+
+	err := unused.DeleteResource(ctx, disksOnlyProvider{p}, addr)
+	if !errors.Is(err, unused.ErrUnsupportedKind) {
+		t.Fatalf("expecting error %v, got %v", unused.ErrUnsupportedKind, err)
+	}
+}
+
+func TestResourcesFilter(t *testing.T) {
+	var (
+		now = time.Now()
+		p   = unusedtest.NewProvider("foo", nil)
+
+		rs = unused.Resources{
+			unusedtest.NewDisk("disk", p, now, now),
+			unusedtest.NewAddress("addr", "10.0.0.1", p, now),
+		}
+	)
+
+	got := rs.Filter(func(r unused.Resource) bool { return r.Kind() == unused.KindAddress })
+	if len(got) != 1 || got[0].Name() != "addr" {
+		t.Errorf("expecting only the address, got %v", got)
+	}
+}
+
+func TestResourceKindPlural(t *testing.T) {
+	tests := map[unused.ResourceKind]string{
+		unused.KindDisk:     "disks",
+		unused.KindAddress:  "addresses",
+		unused.KindSnapshot: "snapshots",
+	}
+
+	for k, exp := range tests {
+		if got := k.Plural(); got != exp {
+			t.Errorf("expecting %q, got %q", exp, got)
+		}
+	}
+}
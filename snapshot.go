@@ -0,0 +1,43 @@
+package unused
+
+import (
+	"context"
+	"time"
+)
+
+// Snapshot represents a disk snapshot on a given cloud provider.
+type Snapshot interface {
+	Resource
+
+	// SourceDiskID returns the provider ID of the disk this snapshot
+	// was taken from, if any.
+	SourceDiskID() string
+
+	// Orphaned returns true when the source disk of this snapshot no
+	// longer exists.
+	Orphaned() bool
+
+	// SizeGB returns the size of the source disk in GB.
+	SizeGB() int
+}
+
+// Snapshots is a collection of Snapshot.
+type Snapshots []Snapshot
+
+// SnapshotProvider is implemented by providers that can list and
+// delete unused snapshots.
+type SnapshotProvider interface {
+	// ListUnusedSnapshots returns a list of snapshots whose source
+	// disk no longer exists or which are older than the given
+	// retention. A zero retention only returns orphaned snapshots.
+	ListUnusedSnapshots(ctx context.Context, retention time.Duration) (Snapshots, error)
+
+	// DeleteSnapshot deletes the given snapshot.
+	DeleteSnapshot(ctx context.Context, snap Snapshot) error
+}
+
+// IsUnusedSnapshot returns true when the snapshot is orphaned or is
+// older than the given retention, if any.
+func IsUnusedSnapshot(s Snapshot, retention time.Duration) bool {
+	return s.Orphaned() || (retention > 0 && time.Since(s.CreatedAt()) >= retention)
+}
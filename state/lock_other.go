@@ -0,0 +1,7 @@
+//go:build !unix
+
+package state
+
+// lock is a no-op where advisory file locks aren't supported, so the
+// state file is only safe to share within a process.
+func (s *Store) lock() (func(), error) { return func() {}, nil }
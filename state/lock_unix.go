@@ -0,0 +1,28 @@
+//go:build unix
+
+package state
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lock takes an exclusive advisory lock on the lock file of the store,
+// blocking until it's available, and returns a function releasing it.
+func (s *Store) lock() (func(), error) {
+	f, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening state lock file: %w", err)
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close() // nolint:errcheck
+		return nil, fmt.Errorf("locking state file: %w", err)
+	}
+
+	// closing the file releases the lock
+	return func() {
+		f.Close() // nolint:errcheck
+	}, nil
+}
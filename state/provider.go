@@ -0,0 +1,62 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/unused"
+)
+
+var _ unused.Provider = &Provider{}
+
+// Provider wraps an [unused.Provider] recording the disks it lists in
+// a [Store].
+type Provider struct {
+	unused.Provider
+	store *Store
+}
+
+// WrapProvider returns a provider recording the disks listed by p in
+// the store. Disks without a last used time get the time they were
+// first seen as unused as an estimate.
+func WrapProvider(p unused.Provider, s *Store) *Provider {
+	return &Provider{p, s}
+}
+
+// ListUnusedDisks returns the unused disks of the wrapped provider,
+// estimating their last used time if missing.
+func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
+	disks, err := p.Provider.ListUnusedDisks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := p.store.Observe(p.Provider, disks, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("recording state: %w", err)
+	}
+
+	res := make(unused.Disks, len(disks))
+	for i, d := range disks {
+		if d.LastUsedAt().IsZero() {
+			d = &disk{d, rs[d.ID()].FirstSeenUnused}
+		}
+		res[i] = d
+	}
+
+	return res, nil
+}
+
+// Unwrap returns the wrapped provider.
+func (p *Provider) Unwrap() unused.Provider { return p.Provider }
+
+// disk wraps a disk to estimate its last used time.
+type disk struct {
+	unused.Disk
+	lastUsed time.Time
+}
+
+func (d *disk) LastUsedAt() time.Time { return d.lastUsed }
+
+func (d *disk) Unwrap() unused.Disk { return d.Disk }
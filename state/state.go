@@ -0,0 +1,177 @@
+// Package state keeps track of unused disks across runs.
+//
+// Some providers, like AWS, don't report when a disk was last used.
+// A [Store] records when each disk was first seen as unused, and when
+// it was last seen in use, so that wrapping a provider with
+// [WrapProvider] can estimate when its disks were last used.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/grafana/unused"
+)
+
+// Retention is how long records of disks that are no longer listed as
+// unused are kept.
+const Retention = 90 * 24 * time.Hour
+
+// Record holds what's known about a disk across runs.
+type Record struct {
+	// FirstSeenUnused is when the disk was first listed as unused
+	// since it was last seen in use.
+	FirstSeenUnused time.Time `json:"first_seen_unused"`
+
+	// LastSeenUnused is the last time the disk was listed as unused.
+	LastSeenUnused time.Time `json:"last_seen_unused"`
+
+	// LastSeenUsed is the last time the disk was known but not listed
+	// as unused, meaning it was attached or deleted.
+	LastSeenUsed time.Time `json:"last_seen_used,omitzero"`
+}
+
+func (r Record) unused() bool { return !r.LastSeenUnused.Before(r.LastSeenUsed) }
+
+// Store persists records in a JSON file, indexed by provider and disk
+// ID. Records are read from and written to the file on each
+// observation while holding an advisory lock on a .lock file next to
+// it, so the same file can be shared by several processes, like the
+// exporter and the CLI, on systems supporting such locks.
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+type records map[string]map[string]Record
+
+// Open returns a store persisting records in the given path, which is
+// created on the first observation if it doesn't exist.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	if _, err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Scoper is implemented by providers listing only part of the disks of
+// their account, like AWS providers listing some regions. Their disks
+// are recorded separately for each scope, so that listing fewer
+// regions doesn't record the disks of the others as used.
+type Scoper interface {
+	Scope() string
+}
+
+// Observe records the given disks as unused at the given time for the
+// provider, and the previously known disks of the provider missing
+// from the list as used. It returns the records of the given disks,
+// indexed by disk ID.
+func (s *Store) Observe(p unused.Provider, disks unused.Disks, now time.Time) (map[string]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	all, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	key := p.Name() + "/" + p.ID()
+	if sp, ok := unused.As[Scoper](p); ok && sp.Scope() != "" {
+		key += "/" + sp.Scope()
+	}
+
+	prev := all[key]
+	cur := make(map[string]Record, len(disks))
+
+	for _, d := range disks {
+		r, ok := prev[d.ID()]
+		if !ok || !r.unused() {
+			r.FirstSeenUnused = now
+		}
+		r.LastSeenUnused = now
+		cur[d.ID()] = r
+	}
+
+	res := make(map[string]Record, len(cur))
+	for id, r := range cur {
+		res[id] = r
+	}
+
+	for id, r := range prev {
+		if _, ok := cur[id]; ok || now.Sub(r.LastSeenUnused) > Retention {
+			continue
+		}
+		if r.unused() {
+			r.LastSeenUsed = now
+		}
+		cur[id] = r
+	}
+
+	all[key] = cur
+
+	if err := s.save(all); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *Store) load() (records, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(records), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading state file: %w", err)
+	}
+
+	rs := make(records)
+	if err := json.Unmarshal(b, &rs); err != nil {
+		return nil, fmt.Errorf("decoding state file %s: %w", s.path, err)
+	}
+
+	return rs, nil
+}
+
+// save writes the records to a temporary file renamed over the state
+// file, so readers never see a partially written file.
+func (s *Store) save(rs records) error {
+	b, err := json.Marshal(rs)
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("creating state file: %w", err)
+	}
+	defer os.Remove(f.Name()) // nolint:errcheck
+
+	if _, err := f.Write(b); err != nil {
+		f.Close() // nolint:errcheck
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		return fmt.Errorf("replacing state file: %w", err)
+	}
+
+	return nil
+}
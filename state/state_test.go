@@ -0,0 +1,223 @@
+package state_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/unused"
+	"github.com/grafana/unused/state"
+	"github.com/grafana/unused/unusedtest"
+)
+
+func TestStoreObserve(t *testing.T) {
+	var (
+		path = filepath.Join(t.TempDir(), "state.json")
+		p    = unusedtest.NewProvider("AWS", nil)
+		t0   = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		day  = 24 * time.Hour
+
+		a = unusedtest.NewDisk("a", p, t0, time.Time{})
+		b = unusedtest.NewDisk("b", p, t0, time.Time{})
+	)
+
+	s, err := state.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	observe := func(s *state.Store, now time.Time, disks ...unused.Disk) map[string]state.Record {
+		t.Helper()
+		rs, err := s.Observe(p, disks, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(rs) != len(disks) {
+			t.Fatalf("expecting %d records, got %d", len(disks), len(rs))
+		}
+		return rs
+	}
+
+	observe(s, t0, a, b)
+	observe(s, t0.Add(day), a) // b got attached
+
+	// reopen the store to check records are persisted
+	s, err = state.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rs := observe(s, t0.Add(2*day), a, b) // b is unused again
+
+	if exp, got := t0, rs["a"].FirstSeenUnused; !exp.Equal(got) {
+		t.Errorf("expecting a first seen unused at %v, got %v", exp, got)
+	}
+	if exp, got := t0.Add(2*day), rs["a"].LastSeenUnused; !exp.Equal(got) {
+		t.Errorf("expecting a last seen unused at %v, got %v", exp, got)
+	}
+
+	if exp, got := t0.Add(2*day), rs["b"].FirstSeenUnused; !exp.Equal(got) {
+		t.Errorf("expecting b first seen unused at %v, got %v", exp, got)
+	}
+	if exp, got := t0.Add(day), rs["b"].LastSeenUsed; !exp.Equal(got) {
+		t.Errorf("expecting b last seen used at %v, got %v", exp, got)
+	}
+
+	t.Run("retention", func(t *testing.T) {
+		now := t0.Add(2*day + state.Retention + day)
+		observe(s, now)
+
+		rs := observe(s, now.Add(time.Hour), a)
+		if exp, got := now.Add(time.Hour), rs["a"].FirstSeenUnused; !exp.Equal(got) {
+			t.Errorf("expecting expired record to be dropped, got first seen unused at %v", got)
+		}
+	})
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatalf("writing state file: %v", err)
+	}
+
+	if _, err := state.Open(path); err == nil {
+		t.Fatal("expecting error opening invalid state file, got nil")
+	}
+}
+
+// scopedProvider lists the disks of a scope of the wrapped provider.
+type scopedProvider struct {
+	unused.Provider
+	scope string
+}
+
+func (p *scopedProvider) Scope() string { return p.scope }
+
+func (p *scopedProvider) Unwrap() unused.Provider { return p.Provider }
+
+func TestStoreObserveScopes(t *testing.T) {
+	var (
+		path = filepath.Join(t.TempDir(), "state.json")
+		p    = unusedtest.NewProvider("AWS", nil)
+		t0   = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		day  = 24 * time.Hour
+
+		eu = &scopedProvider{p, "eu-west-1"}
+		us = &scopedProvider{p, "us-east-1"}
+		a  = unusedtest.NewDisk("a", p, t0, time.Time{})
+	)
+
+	s, err := state.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := s.Observe(eu, unused.Disks{a}, t0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// listing another region doesn't see the disk
+	if _, err := s.Observe(us, nil, t0.Add(day)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rs, err := s.Observe(eu, unused.Disks{a}, t0.Add(2*day))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp, got := t0, rs["a"].FirstSeenUnused; !exp.Equal(got) {
+		t.Errorf("expecting a first seen unused at %v, got %v", exp, got)
+	}
+	if got := rs["a"].LastSeenUsed; !got.IsZero() {
+		t.Errorf("expecting a never seen used, got %v", got)
+	}
+}
+
+func TestStoreShared(t *testing.T) {
+	var (
+		path = filepath.Join(t.TempDir(), "state.json")
+		now  = time.Now()
+	)
+
+	// stores opened on the same file, like by different processes,
+	// don't overwrite each other's records
+	var wg sync.WaitGroup
+	for i := range 10 {
+		s, err := state.Open(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		wg.Go(func() {
+			p := unusedtest.NewProvider("AWS-"+strconv.Itoa(i), nil)
+			if _, err := s.Observe(p, unused.Disks{unusedtest.NewDisk("disk", p, now, time.Time{})}, now); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var rs map[string]map[string]state.Record
+	if err := json.Unmarshal(b, &rs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rs) != 10 {
+		t.Errorf("expecting records of 10 providers, got %d", len(rs))
+	}
+}
+
+func TestWrapProvider(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		now  = time.Now()
+		used = now.Add(-48 * time.Hour)
+
+		p = unusedtest.NewProvider("AWS", nil)
+	)
+
+	p.SetDisks(
+		unusedtest.NewDisk("never", p, now.Add(-72*time.Hour), time.Time{}),
+		unusedtest.NewDisk("known", p, now.Add(-72*time.Hour), used),
+	)
+
+	s, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	w := state.WrapProvider(p, s)
+
+	first, err := w.ListUnusedDisks(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	estimate := first[0].LastUsedAt()
+	if estimate.IsZero() || estimate.Before(now) {
+		t.Errorf("expecting estimated last used time after %v, got %v", now, estimate)
+	}
+	if got := first[1].LastUsedAt(); !got.Equal(used) {
+		t.Errorf("expecting known last used time %v, got %v", used, got)
+	}
+
+	second, err := w.ListUnusedDisks(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := second[0].LastUsedAt(); !got.Equal(estimate) {
+		t.Errorf("expecting estimate to be kept across listings as %v, got %v", estimate, got)
+	}
+
+	if _, ok := unused.UnwrapDisk(second[0]).(unusedtest.Disk); !ok {
+		t.Errorf("expecting estimated disk to unwrap to the original disk, got %T", unused.UnwrapDisk(second[0]))
+	}
+}
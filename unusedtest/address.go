@@ -0,0 +1,32 @@
+package unusedtest
+
+import (
+	"time"
+
+	"github.com/grafana/unused"
+)
+
+var _ unused.Address = Address{}
+
+// Address implements [unused.Address] for testing purposes.
+type Address struct {
+	name, address string
+	provider      unused.Provider
+	createdAt     time.Time
+	meta          unused.Meta
+}
+
+// NewAddress returns a new test IP address.
+func NewAddress(name, address string, provider unused.Provider, createdAt time.Time) Address {
+	return Address{name, address, provider, createdAt, nil}
+}
+
+func (a Address) ID() string                { return a.name }
+func (a Address) Provider() unused.Provider { return a.provider }
+func (a Address) Name() string              { return a.name }
+func (a Address) Address() string           { return a.address }
+func (a Address) CreatedAt() time.Time      { return a.createdAt }
+func (a Address) Meta() unused.Meta         { return a.meta }
+func (a Address) Kind() unused.ResourceKind { return unused.KindAddress }
+
+func (a *Address) SetMeta(m unused.Meta) { a.meta = m }
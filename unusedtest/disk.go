@@ -16,12 +16,14 @@ type Disk struct {
 	lastUsedAt time.Time
 	meta       unused.Meta
 	size       int
-	diskType   unused.DiskType
+	class      unused.DiskClass
+	location   unused.Location
+	cluster    string
 }
 
 // NewDisk returns a new test disk.
 func NewDisk(name string, provider unused.Provider, createdAt, lastUsedAt time.Time) Disk {
-	return Disk{name, name, provider, createdAt, lastUsedAt, nil, 0, unused.Unknown}
+	return Disk{name, name, provider, createdAt, lastUsedAt, nil, 0, unused.DiskClass{Media: unused.Unknown}, unused.Location{}, ""}
 }
 
 func (d Disk) ID() string                { return d.name }
@@ -32,6 +34,25 @@ func (d Disk) Meta() unused.Meta         { return d.meta }
 func (d Disk) LastUsedAt() time.Time     { return d.lastUsedAt }
 func (d Disk) SizeGB() int               { return d.size }
 func (d Disk) SizeBytes() float64        { return float64(d.size) * unused.GiBbytes }
-func (d Disk) DiskType() unused.DiskType { return d.diskType }
+func (d Disk) DiskType() unused.DiskType { return d.class.Media }
+func (d Disk) Class() unused.DiskClass   { return d.class }
+func (d Disk) Kind() unused.ResourceKind { return unused.KindDisk }
+func (d Disk) Location() unused.Location { return d.location }
+
+func (d Disk) Kubernetes() unused.Kubernetes {
+	k := d.meta.Kubernetes()
+	k.Cluster = d.cluster
+	return k
+}
 
 func (d *Disk) SetMeta(m unused.Meta) { d.meta = m }
+
+func (d *Disk) SetSize(gb int) { d.size = gb }
+
+func (d *Disk) SetDiskType(t unused.DiskType) { d.class.Media = t }
+
+func (d *Disk) SetClass(c unused.DiskClass) { d.class = c }
+
+func (d *Disk) SetLocation(l unused.Location) { d.location = l }
+
+func (d *Disk) SetCluster(name string) { d.cluster = name }
@@ -4,17 +4,30 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/grafana/unused"
 )
 
-var _ unused.Provider = &Provider{}
+var (
+	_ unused.Provider         = &Provider{}
+	_ unused.AddressProvider  = &Provider{}
+	_ unused.SnapshotProvider = &Provider{}
+	_ unused.Tagger           = &Provider{}
+)
 
-// Provider implements [unused.Provider] for testing purposes.
+// Provider implements [unused.Provider] for testing purposes. It's
+// safe for concurrent use.
 type Provider struct {
-	name  string
-	disks unused.Disks
-	meta  unused.Meta
+	mu        sync.Mutex
+	name      string
+	disks     unused.Disks
+	addresses unused.Addresses
+	snapshots unused.Snapshots
+	meta      unused.Meta
 }
 
 // NewProvider returns a new test provider that return the given disks
@@ -23,7 +36,7 @@ func NewProvider(name string, meta unused.Meta, disks ...unused.Disk) *Provider
 	if meta == nil {
 		meta = make(unused.Meta)
 	}
-	return &Provider{name, disks, meta}
+	return &Provider{name: name, disks: disks, meta: meta}
 }
 
 func (p *Provider) Name() string { return p.name }
@@ -34,13 +47,25 @@ func (p *Provider) Meta() unused.Meta { return p.meta }
 
 func (p *Provider) SetMeta(meta unused.Meta) { p.meta = meta }
 
+// SetDisks sets the disks returned as unused.
+func (p *Provider) SetDisks(disks ...unused.Disk) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.disks = disks
+}
+
 func (p *Provider) ListUnusedDisks(ctx context.Context) (unused.Disks, error) {
-	return p.disks, nil
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.disks), nil
 }
 
 var ErrDiskNotFound = errors.New("disk not found")
 
 func (p *Provider) Delete(ctx context.Context, disk unused.Disk) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	for i := range p.disks {
 		if disk.Name() == p.disks[i].Name() {
 			p.disks = append(p.disks[:i], p.disks[i+1:]...)
@@ -51,6 +76,95 @@ func (p *Provider) Delete(ctx context.Context, disk unused.Disk) error {
 	return ErrDiskNotFound
 }
 
+// TagDisk adds the given tags to the metadata of the disk. Only disks
+// created with [NewDisk] can be tagged.
+func (p *Provider) TagDisk(ctx context.Context, disk unused.Disk, tags map[string]string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := range p.disks {
+		d, ok := p.disks[i].(Disk)
+		if !ok || disk.Name() != d.Name() {
+			continue
+		}
+
+		m := make(unused.Meta, len(d.meta)+len(tags))
+		maps.Copy(m, d.meta)
+		maps.Copy(m, tags)
+		d.meta = m
+		p.disks[i] = d
+
+		return nil
+	}
+
+	return ErrDiskNotFound
+}
+
+// SetAddresses sets the IP addresses returned as unused.
+func (p *Provider) SetAddresses(addrs ...unused.Address) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.addresses = addrs
+}
+
+func (p *Provider) ListUnusedAddresses(ctx context.Context) (unused.Addresses, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.addresses), nil
+}
+
+var ErrAddressNotFound = errors.New("address not found")
+
+func (p *Provider) DeleteAddress(ctx context.Context, addr unused.Address) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := range p.addresses {
+		if addr.Name() == p.addresses[i].Name() {
+			p.addresses = append(p.addresses[:i], p.addresses[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrAddressNotFound
+}
+
+// SetSnapshots sets the snapshots returned by ListUnusedSnapshots.
+func (p *Provider) SetSnapshots(snaps ...unused.Snapshot) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.snapshots = snaps
+}
+
+func (p *Provider) ListUnusedSnapshots(ctx context.Context, retention time.Duration) (unused.Snapshots, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var snaps unused.Snapshots
+	for _, s := range p.snapshots {
+		if unused.IsUnusedSnapshot(s, retention) {
+			snaps = append(snaps, s)
+		}
+	}
+	return snaps, nil
+}
+
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+func (p *Provider) DeleteSnapshot(ctx context.Context, snap unused.Snapshot) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := range p.snapshots {
+		if snap.Name() == p.snapshots[i].Name() {
+			p.snapshots = append(p.snapshots[:i], p.snapshots[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrSnapshotNotFound
+}
+
 // TestProviderMeta returns nil if the provider properly implements
 // storing metadata.
 //
@@ -0,0 +1,35 @@
+package unusedtest
+
+import (
+	"time"
+
+	"github.com/grafana/unused"
+)
+
+var _ unused.Snapshot = Snapshot{}
+
+// Snapshot implements [unused.Snapshot] for testing purposes.
+type Snapshot struct {
+	name, source string
+	orphaned     bool
+	provider     unused.Provider
+	createdAt    time.Time
+	meta         unused.Meta
+}
+
+// NewSnapshot returns a new test snapshot.
+func NewSnapshot(name, source string, orphaned bool, provider unused.Provider, createdAt time.Time) Snapshot {
+	return Snapshot{name, source, orphaned, provider, createdAt, nil}
+}
+
+func (s Snapshot) ID() string                { return s.name }
+func (s Snapshot) Provider() unused.Provider { return s.provider }
+func (s Snapshot) Name() string              { return s.name }
+func (s Snapshot) SourceDiskID() string      { return s.source }
+func (s Snapshot) Orphaned() bool            { return s.orphaned }
+func (s Snapshot) SizeGB() int               { return 10 }
+func (s Snapshot) CreatedAt() time.Time      { return s.createdAt }
+func (s Snapshot) Meta() unused.Meta         { return s.meta }
+func (s Snapshot) Kind() unused.ResourceKind { return unused.KindSnapshot }
+
+func (s *Snapshot) SetMeta(m unused.Meta) { s.meta = m }